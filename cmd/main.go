@@ -1,6 +1,7 @@
 package main
 
 import (
+	"awesomeProject/internal/config"
 	"awesomeProject/internal/database"
 	"awesomeProject/internal/delivery/http/router"
 	"awesomeProject/internal/domain/model"
@@ -9,6 +10,9 @@ import (
 )
 
 func main() {
+	if err := config.GetConfig().Validate(); err != nil {
+		log.Fatalf("Некорректная конфигурация: %v", err)
+	}
 	database.InitDatabase()
 	model.InitModels()
 	r := router.SetupRouter()
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"awesomeProject/internal/config"
+	apikeyService "awesomeProject/internal/domain/service/apikey"
+	tokenService "awesomeProject/internal/domain/service/token"
+	userService "awesomeProject/internal/domain/service/user"
+	"awesomeProject/internal/signing"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// ErrNoCredentials - стратегия не нашла в запросе своих учетных данных, и
+	// Authenticator переходит к следующей
+	ErrNoCredentials = errors.New("authentication required")
+	// ErrInvalidCredentials - учетные данные есть, но не прошли проверку
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Strategy - один способ аутентификации. Если учетных данных этого типа в запросе нет,
+// стратегия возвращает ErrNoCredentials; неверные данные возвращаются как ErrInvalidCredentials.
+type Strategy interface {
+	Authenticate(c *gin.Context) (*Principal, error)
+}
+
+// Authenticator применяет стратегии по порядку. Первая стратегия, нашедшая учетные
+// данные, решает исход: неверный токен не проверяется остальными стратегиями.
+type Authenticator struct {
+	strategies []Strategy
+}
+
+func NewAuthenticator(strategies ...Strategy) *Authenticator {
+	return &Authenticator{strategies: strategies}
+}
+
+var (
+	defaultAuthenticator *Authenticator
+	defaultOnce          sync.Once
+)
+
+// Default возвращает Authenticator со стратегиями из настроек. Создается лениво,
+// так как база и ключи инициализируются после загрузки пакета.
+func Default() *Authenticator {
+	defaultOnce.Do(func() {
+		defaultAuthenticator = newDefaultAuthenticator()
+	})
+	return defaultAuthenticator
+}
+
+// Authenticate определяет участника запроса
+func (a *Authenticator) Authenticate(c *gin.Context) (*Principal, error) {
+	for _, strategy := range a.strategies {
+		principal, err := strategy.Authenticate(c)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return principal, nil
+	}
+	return nil, ErrNoCredentials
+}
+
+// Middleware пропускает только аутентифицированные запросы
+func (a *Authenticator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := a.Authenticate(c)
+		if err != nil {
+			if errors.Is(err, ErrNoCredentials) || errors.Is(err, ErrInvalidCredentials) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			} else {
+				log.Printf("Authentication failed: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to authenticate"})
+			}
+			c.Abort()
+			return
+		}
+		SetPrincipal(c, principal)
+		c.Next()
+	}
+}
+
+// newDefaultAuthenticator собирает цепочку: bearer-токен, ключ API, затем cookie сессии, если она включена
+func newDefaultAuthenticator() *Authenticator {
+	cfg := config.GetConfig()
+	users := userService.NewUserService()
+	verifier := NewTokenVerifier(signing.GetKeySet(), tokenService.NewRevocationService(), users)
+
+	strategies := []Strategy{
+		NewBearerStrategy(verifier),
+		NewAPIKeyStrategy(apikeyService.NewAPIKeyService(), users),
+	}
+	if cfg.Auth.CookieEnabled {
+		strategies = append(strategies, NewCookieStrategy(cfg.Auth.CookieName, verifier))
+	}
+	return NewAuthenticator(strategies...)
+}
@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"awesomeProject/internal/config"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetSessionCookie выдает браузеру cookie с access-токеном, если вход по cookie включен
+func SetSessionCookie(c *gin.Context, token string, ttl time.Duration) {
+	cfg := config.GetConfig().Auth
+	if !cfg.CookieEnabled || token == "" {
+		return
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(cfg.CookieName, token, int(ttl.Seconds()), "/", "", cfg.CookieSecure, true)
+}
+
+// ClearSessionCookie удаляет cookie сессии при выходе
+func ClearSessionCookie(c *gin.Context) {
+	cfg := config.GetConfig().Auth
+	if !cfg.CookieEnabled {
+		return
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(cfg.CookieName, "", -1, "/", "", cfg.CookieSecure, true)
+}
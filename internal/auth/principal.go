@@ -0,0 +1,62 @@
+// Package auth определяет, кто выполняет запрос. Authenticator перебирает стратегии
+// (bearer JWT, cookie сессии, ключ API) и кладет в контекст Gin типизированный Principal.
+package auth
+
+import (
+	"awesomeProject/internal/domain/model/user"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Способы аутентификации
+const (
+	MethodBearer = "bearer"
+	MethodCookie = "cookie"
+	MethodAPIKey = "api_key"
+)
+
+// Ключи контекста Gin
+const (
+	principalKey = "principal"
+	userKey      = "user"
+)
+
+// Principal - аутентифицированный участник запроса
+type Principal struct {
+	User   user.User
+	Method string
+	// Permissions - права, с которыми выдан токен. nil означает, что права
+	// определяются текущей ролью пользователя.
+	Permissions []string
+	// TokenID, SessionID и ExpiresAt заполняются для токенов доступа
+	TokenID   string
+	SessionID string
+	ExpiresAt time.Time
+	// APIKeyID - ключ, которым выполнен запрос, для MethodAPIKey
+	APIKeyID uint
+	// ImpersonatorID - администратор, работающий от имени User (claim act токена)
+	ImpersonatorID uint
+}
+
+// IsImpersonated сообщает, что запрос выполняет администратор от имени пользователя
+func (p *Principal) IsImpersonated() bool {
+	return p.ImpersonatorID != 0
+}
+
+// SetPrincipal сохраняет участника в контексте. Пользователь дополнительно доступен
+// по ключу "user", которым пользуются обработчики.
+func SetPrincipal(c *gin.Context, p *Principal) {
+	c.Set(principalKey, p)
+	c.Set(userKey, p.User)
+}
+
+// PrincipalFrom возвращает участника, сохраненного Authenticator
+func PrincipalFrom(c *gin.Context) (*Principal, bool) {
+	value, exists := c.Get(principalKey)
+	if !exists {
+		return nil, false
+	}
+	p, ok := value.(*Principal)
+	return p, ok
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	apikeyService "awesomeProject/internal/domain/service/apikey"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BearerStrategy принимает access-токен из заголовка "Authorization: Bearer <token>".
+// Заголовки с другими схемами оставляет следующим стратегиям.
+type BearerStrategy struct {
+	verifier *TokenVerifier
+}
+
+func NewBearerStrategy(verifier *TokenVerifier) *BearerStrategy {
+	return &BearerStrategy{verifier: verifier}
+}
+
+func (s *BearerStrategy) Authenticate(c *gin.Context) (*Principal, error) {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, ErrNoCredentials
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, fmt.Errorf("%w: token is required", ErrInvalidCredentials)
+	}
+	return s.verifier.Verify(token, MethodBearer)
+}
+
+// CSRFHeader - заголовок, без которого изменяющие запросы с cookie отклоняются.
+// Браузер не отправит его с чужого сайта без разрешения CORS.
+const CSRFHeader = "X-Requested-With"
+
+// CookieStrategy принимает access-токен из HttpOnly cookie сессии браузера
+type CookieStrategy struct {
+	name     string
+	verifier *TokenVerifier
+}
+
+func NewCookieStrategy(name string, verifier *TokenVerifier) *CookieStrategy {
+	return &CookieStrategy{name: name, verifier: verifier}
+}
+
+func (s *CookieStrategy) Authenticate(c *gin.Context) (*Principal, error) {
+	token, err := c.Cookie(s.name)
+	if err != nil || token == "" {
+		return nil, ErrNoCredentials
+	}
+	if !isSafeMethod(c.Request.Method) && c.GetHeader(CSRFHeader) == "" {
+		return nil, fmt.Errorf("%w: %s header is required for cookie authentication", ErrInvalidCredentials, CSRFHeader)
+	}
+	return s.verifier.Verify(token, MethodCookie)
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// APIKeyHeader - альтернативный заголовок для ключа API
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyStrategy принимает персональный ключ из "Authorization: ApiKey <key>" или X-API-Key.
+// Права запроса - scopes ключа, пересеченные с текущими правами роли владельца.
+type APIKeyStrategy struct {
+	keys  *apikeyService.APIKeyService
+	users UserFinder
+}
+
+func NewAPIKeyStrategy(keys *apikeyService.APIKeyService, users UserFinder) *APIKeyStrategy {
+	return &APIKeyStrategy{keys: keys, users: users}
+}
+
+func (s *APIKeyStrategy) Authenticate(c *gin.Context) (*Principal, error) {
+	raw := c.GetHeader(APIKeyHeader)
+	if scheme, value, ok := strings.Cut(c.GetHeader("Authorization"), " "); ok && strings.EqualFold(scheme, "ApiKey") {
+		raw = value
+	}
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, ErrNoCredentials
+	}
+
+	key, err := s.keys.Authenticate(raw)
+	if err != nil {
+		if errors.Is(err, apikeyService.ErrInvalidKey) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+		}
+		return nil, err
+	}
+	u, err := s.users.GetUserByID(key.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: user not found", ErrInvalidCredentials)
+	}
+	if !u.IsActive {
+		return nil, fmt.Errorf("%w: account is deactivated", ErrInvalidCredentials)
+	}
+	permissions, err := s.keys.EffectivePermissions(key, u.Role)
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{
+		User:        u,
+		Method:      MethodAPIKey,
+		Permissions: permissions,
+		APIKeyID:    key.ID,
+	}, nil
+}
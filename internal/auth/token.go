@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"awesomeProject/internal/domain/model/user"
+	tokenService "awesomeProject/internal/domain/service/token"
+	"awesomeProject/internal/signing"
+	"fmt"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// UserFinder загружает пользователя, от имени которого выдан токен
+type UserFinder interface {
+	GetUserByID(id uint) (user.User, error)
+}
+
+// TokenVerifier проверяет access-токены: подпись, срок действия и отзыв.
+// Общий для bearer-заголовка и cookie.
+type TokenVerifier struct {
+	keys        *signing.KeySet
+	revocations *tokenService.RevocationService
+	users       UserFinder
+}
+
+func NewTokenVerifier(keys *signing.KeySet, revocations *tokenService.RevocationService, users UserFinder) *TokenVerifier {
+	return &TokenVerifier{keys: keys, revocations: revocations, users: users}
+}
+
+// Verify проверяет токен и возвращает участника с указанным способом аутентификации
+func (v *TokenVerifier) Verify(tokenString, method string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	if _, err := v.keys.Parse(tokenString, claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid user_id in token", ErrInvalidCredentials)
+	}
+	userID := uint(userIDFloat)
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return nil, fmt.Errorf("%w: invalid expiration time in token", ErrInvalidCredentials)
+	}
+	iat, err := claims.GetIssuedAt()
+	if err != nil || iat == nil {
+		return nil, fmt.Errorf("%w: invalid issue time in token", ErrInvalidCredentials)
+	}
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return nil, fmt.Errorf("%w: invalid token id", ErrInvalidCredentials)
+	}
+
+	// Проверяем, не отозван ли токен через logout
+	revoked, err := v.revocations.IsRevoked(jti, userID, iat.Time)
+	if err != nil {
+		return nil, fmt.Errorf("check token revocation: %w", err)
+	}
+	if revoked {
+		return nil, fmt.Errorf("%w: token has been revoked", ErrInvalidCredentials)
+	}
+
+	u, err := v.users.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: user not found", ErrInvalidCredentials)
+	}
+	if !u.IsActive {
+		return nil, fmt.Errorf("%w: account is deactivated", ErrInvalidCredentials)
+	}
+
+	impersonatorID, err := actorFromClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID, _ := claims["sid"].(string)
+	return &Principal{
+		User:           u,
+		Method:         method,
+		Permissions:    permissionsFromClaims(claims),
+		TokenID:        jti,
+		SessionID:      sessionID,
+		ExpiresAt:      exp.Time,
+		ImpersonatorID: impersonatorID,
+	}, nil
+}
+
+// actorFromClaims извлекает администратора из claim act (RFC 8693); без claim возвращает 0
+func actorFromClaims(claims jwt.MapClaims) (uint, error) {
+	raw, exists := claims["act"]
+	if !exists {
+		return 0, nil
+	}
+	act, ok := raw.(map[string]interface{})
+	if !ok {
+		return 0, fmt.Errorf("%w: invalid act claim", ErrInvalidCredentials)
+	}
+	sub, _ := act["sub"].(string)
+	actorID, err := strconv.ParseUint(sub, 10, 32)
+	if err != nil || actorID == 0 {
+		return 0, fmt.Errorf("%w: invalid act claim", ErrInvalidCredentials)
+	}
+	return uint(actorID), nil
+}
+
+// permissionsFromClaims извлекает claim perms; для токенов без него возвращает nil
+func permissionsFromClaims(claims jwt.MapClaims) []string {
+	raw, ok := claims["perms"].([]interface{})
+	if !ok {
+		return nil
+	}
+	permissions := make([]string, 0, len(raw))
+	for _, p := range raw {
+		if name, ok := p.(string); ok {
+			permissions = append(permissions, name)
+		}
+	}
+	return permissions
+}
@@ -51,6 +51,24 @@ func (c *Cache) Set(key string, value interface{}) {
 	c.items.Store(key, item)
 }
 
+// SetWithTTL сохраняет значение в кэш с собственным временем жизни
+func (c *Cache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	if !c.config.Cache.Enabled {
+		return
+	}
+
+	item := CacheItem{
+		Value:      value,
+		Expiration: time.Now().Add(ttl),
+	}
+	c.items.Store(key, item)
+}
+
+// Enabled сообщает, включен ли кэш
+func (c *Cache) Enabled() bool {
+	return c.config.Cache.Enabled
+}
+
 // Get получает значение из кэша
 func (c *Cache) Get(key string) (interface{}, bool) {
 	if !c.config.Cache.Enabled {
@@ -1,13 +1,21 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // Config содержит все настройки приложения
 type Config struct {
+	// Настройки HTTP-сервера
+	Server struct {
+		TrustedProxies []string
+	}
+
 	// Настройки таймаутов
 	Timeout struct {
 		NewsDetails    time.Duration
@@ -32,6 +40,111 @@ type Config struct {
 		Path        string
 		PushGateway string
 	}
+
+	// Настройки аутентификации
+	Auth struct {
+		AccessTokenTTL   time.Duration
+		RefreshTokenTTL  time.Duration
+		PasswordResetTTL time.Duration
+		InvitationTTL    time.Duration
+		ImpersonationTTL time.Duration
+		CookieEnabled    bool
+		CookieName       string
+		CookieSecure     bool
+
+		// ImpersonationSweepInterval - как часто истекшие имперсонации записываются в аудит
+		ImpersonationSweepInterval time.Duration
+	}
+
+	// Настройки подписи JWT
+	JWT struct {
+		SigningKeyFile       string
+		VerificationKeyFiles []string
+		AllowEphemeralKey    bool
+	}
+
+	// Настройки полнотекстового поиска
+	Search struct {
+		Language  string
+		Languages []string
+	}
+
+	// Настройки отправки почты
+	Mail struct {
+		Driver       string
+		From         string
+		SMTPHost     string
+		SMTPPort     int
+		SMTPUsername string
+		SMTPPassword string
+		Dir          string
+	}
+
+	// Настройки подтверждения email
+	Verification struct {
+		Secret   string
+		TTL      time.Duration
+		Required bool
+		BaseURL  string
+	}
+
+	// Настройки двухфакторной аутентификации
+	MFA struct {
+		Issuer          string
+		EncryptionKey   string
+		ChallengeSecret string
+		ChallengeTTL    time.Duration
+		RecoveryCodes   int
+	}
+
+	// Настройки защиты от перебора паролей
+	Lockout struct {
+		Enabled          bool
+		Store            string
+		AccountThreshold int
+		IPThreshold      int
+		BaseDelay        time.Duration
+		MaxDelay         time.Duration
+		Duration         time.Duration
+		Window           time.Duration
+	}
+
+	// Настройки комментариев
+	Comments struct {
+		EditWindow    time.Duration
+		Premoderation bool
+	}
+
+	// Настройки удаления пользователей
+	UserDeletion struct {
+		GracePeriod   time.Duration
+		SweepInterval time.Duration
+	}
+
+	// Настройки выгрузки персональных данных
+	Export struct {
+		Dir           string
+		TTL           time.Duration
+		Timeout       time.Duration
+		SweepInterval time.Duration
+		LinkSecret    string
+	}
+
+	// Настройки входа через внешних OIDC-провайдеров
+	OIDC struct {
+		Providers   []OIDCProvider
+		StateTTL    time.Duration
+		StateSecret string
+	}
+}
+
+// OIDCProvider - настройки одного OIDC-провайдера
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
 }
 
 var cfg *Config
@@ -47,6 +160,10 @@ func GetConfig() *Config {
 
 // loadFromEnv загружает настройки из переменных окружения
 func (c *Config) loadFromEnv() {
+	// Сервер: адреса или подсети прокси, которым разрешено передавать IP клиента
+	// в X-Forwarded-For. По умолчанию заголовок игнорируется и IP берется из соединения.
+	c.Server.TrustedProxies = getListEnv("TRUSTED_PROXIES", nil)
+
 	// Таймауты
 	c.Timeout.NewsDetails = getDurationEnv("NEWS_DETAILS_TIMEOUT", 500*time.Millisecond)
 	c.Timeout.UserDetails = getDurationEnv("USER_DETAILS_TIMEOUT", 500*time.Millisecond)
@@ -65,6 +182,128 @@ func (c *Config) loadFromEnv() {
 	c.Metrics.Port = getIntEnv("METRICS_PORT", 9090)
 	c.Metrics.Path = getStringEnv("METRICS_PATH", "/metrics")
 	c.Metrics.PushGateway = getStringEnv("METRICS_PUSH_GATEWAY", "")
+
+	// Аутентификация
+	c.Auth.AccessTokenTTL = getDurationEnv("ACCESS_TOKEN_TTL", 15*time.Minute)
+	c.Auth.RefreshTokenTTL = getDurationEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+	c.Auth.PasswordResetTTL = getDurationEnv("PASSWORD_RESET_TTL", 1*time.Hour)
+	// Приглашение импортированного пользователя - токен сброса пароля с более долгим сроком жизни
+	c.Auth.InvitationTTL = getDurationEnv("INVITATION_TTL", 7*24*time.Hour)
+	c.Auth.ImpersonationTTL = getDurationEnv("IMPERSONATION_TTL", 15*time.Minute)
+	c.Auth.ImpersonationSweepInterval = getDurationEnv("IMPERSONATION_SWEEP_INTERVAL", 1*time.Minute)
+	// Вход по cookie для браузерных клиентов: при включении токен доступа также выдается
+	// в HttpOnly cookie и принимается из нее
+	c.Auth.CookieEnabled = getBoolEnv("AUTH_COOKIE_ENABLED", false)
+	c.Auth.CookieName = getStringEnv("AUTH_COOKIE_NAME", "access_token")
+	c.Auth.CookieSecure = getBoolEnv("AUTH_COOKIE_SECURE", true)
+
+	// JWT: приватный ключ подписи (RSA или Ed25519 в PEM) и дополнительные ключи, которыми
+	// еще принимаются токены - например, предыдущий ключ во время ротации
+	c.JWT.SigningKeyFile = getStringEnv("JWT_SIGNING_KEY_FILE", "")
+	c.JWT.VerificationKeyFiles = getListEnv("JWT_VERIFICATION_KEY_FILES", nil)
+	// Временный ключ, который создается при запуске, если JWT_SIGNING_KEY_FILE не задан. Только для разработки.
+	c.JWT.AllowEphemeralKey = getBoolEnv("JWT_ALLOW_EPHEMERAL_KEY", false)
+
+	// Поиск: конфигурация text search Postgres по умолчанию и разрешенные конфигурации
+	c.Search.Language = getStringEnv("SEARCH_LANGUAGE", "russian")
+	c.Search.Languages = getListEnv("SEARCH_LANGUAGES", []string{"russian", "english", "simple"})
+
+	// Почта: smtp, file (письма складываются в MAIL_DIR) или memory
+	c.Mail.Driver = getStringEnv("MAIL_DRIVER", "file")
+	c.Mail.From = getStringEnv("MAIL_FROM", "no-reply@localhost")
+	c.Mail.SMTPHost = getStringEnv("SMTP_HOST", "localhost")
+	c.Mail.SMTPPort = getIntEnv("SMTP_PORT", 587)
+	c.Mail.SMTPUsername = getStringEnv("SMTP_USERNAME", "")
+	c.Mail.SMTPPassword = getStringEnv("SMTP_PASSWORD", "")
+	c.Mail.Dir = getStringEnv("MAIL_DIR", filepath.Join(os.TempDir(), "awesomeProject-mail"))
+
+	// Подтверждение email: секрет подписи токенов, их срок жизни и запрет входа без подтверждения
+	c.Verification.Secret = getStringEnv("VERIFICATION_SECRET", "")
+	c.Verification.TTL = getDurationEnv("VERIFICATION_TTL", 24*time.Hour)
+	c.Verification.Required = getBoolEnv("VERIFICATION_REQUIRED", false)
+	c.Verification.BaseURL = getStringEnv("APP_BASE_URL", "http://localhost:8080")
+
+	// Двухфакторная аутентификация: имя сервиса в приложении-аутентификаторе, ключ шифрования
+	// TOTP-секретов, секрет подписи и срок жизни mfa_token между шагами входа,
+	// количество кодов восстановления
+	c.MFA.Issuer = getStringEnv("MFA_ISSUER", "awesomeProject")
+	c.MFA.EncryptionKey = getStringEnv("MFA_ENCRYPTION_KEY", "")
+	c.MFA.ChallengeSecret = getStringEnv("MFA_CHALLENGE_SECRET", "")
+	c.MFA.ChallengeTTL = getDurationEnv("MFA_CHALLENGE_TTL", 5*time.Minute)
+	c.MFA.RecoveryCodes = getIntEnv("MFA_RECOVERY_CODES", 10)
+
+	// Защита от перебора: после каждой неудачи вход задерживается на BaseDelay*2^(n-1),
+	// а после порога неудач аккаунт или IP блокируется на Duration. Счетчики сбрасываются через Window без неудач.
+	c.Lockout.Enabled = getBoolEnv("LOCKOUT_ENABLED", true)
+	c.Lockout.Store = getStringEnv("LOCKOUT_STORE", "cache")
+	c.Lockout.AccountThreshold = getIntEnv("LOCKOUT_ACCOUNT_THRESHOLD", 5)
+	c.Lockout.IPThreshold = getIntEnv("LOCKOUT_IP_THRESHOLD", 20)
+	c.Lockout.BaseDelay = getDurationEnv("LOCKOUT_BASE_DELAY", 1*time.Second)
+	c.Lockout.MaxDelay = getDurationEnv("LOCKOUT_MAX_DELAY", 1*time.Minute)
+	c.Lockout.Duration = getDurationEnv("LOCKOUT_DURATION", 15*time.Minute)
+	c.Lockout.Window = getDurationEnv("LOCKOUT_WINDOW", 1*time.Hour)
+
+	// Комментарии: сколько автор может править комментарий и нужна ли премодерация
+	c.Comments.EditWindow = getDurationEnv("COMMENT_EDIT_WINDOW", 15*time.Minute)
+	c.Comments.Premoderation = getBoolEnv("COMMENT_PREMODERATION", false)
+
+	// Удаленные пользователи хранятся в архиве GracePeriod, затем их данные обезличиваются
+	c.UserDeletion.GracePeriod = getDurationEnv("USER_DELETION_GRACE_PERIOD", 30*24*time.Hour)
+	c.UserDeletion.SweepInterval = getDurationEnv("USER_DELETION_SWEEP_INTERVAL", 1*time.Hour)
+
+	// Выгрузка данных: каталог архивов, сколько архив доступен для скачивания,
+	// сколько может собираться и как часто удаляются просроченные архивы
+	c.Export.Dir = getStringEnv("EXPORT_DIR", filepath.Join(os.TempDir(), "awesomeProject-exports"))
+	c.Export.TTL = getDurationEnv("EXPORT_TTL", 7*24*time.Hour)
+	c.Export.Timeout = getDurationEnv("EXPORT_TIMEOUT", 10*time.Minute)
+	c.Export.SweepInterval = getDurationEnv("EXPORT_SWEEP_INTERVAL", 1*time.Hour)
+	c.Export.LinkSecret = getStringEnv("EXPORT_LINK_SECRET", "")
+
+	// OIDC: OIDC_PROVIDERS перечисляет имена провайдеров, настройки каждого читаются
+	// из OIDC_<ИМЯ>_ISSUER, OIDC_<ИМЯ>_CLIENT_ID, OIDC_<ИМЯ>_CLIENT_SECRET и OIDC_<ИМЯ>_SCOPES
+	c.OIDC.StateTTL = getDurationEnv("OIDC_STATE_TTL", 10*time.Minute)
+	c.OIDC.StateSecret = getStringEnv("OIDC_STATE_SECRET", "")
+	c.OIDC.Providers = nil
+	for _, name := range getListEnv("OIDC_PROVIDERS", nil) {
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		c.OIDC.Providers = append(c.OIDC.Providers, OIDCProvider{
+			Name:         strings.ToLower(name),
+			Issuer:       getStringEnv(prefix+"ISSUER", ""),
+			ClientID:     getStringEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getStringEnv(prefix+"CLIENT_SECRET", ""),
+			Scopes:       getListEnv(prefix+"SCOPES", []string{"openid", "email", "profile"}),
+		})
+	}
+}
+
+// Validate проверяет, что заданы обязательные секреты. Значений по умолчанию у них нет:
+// известный всем секрет позволил бы подделывать подписанные токены.
+func (c *Config) Validate() error {
+	type setting struct {
+		key   string
+		value string
+	}
+	required := []setting{
+		{"VERIFICATION_SECRET", c.Verification.Secret},
+		{"MFA_ENCRYPTION_KEY", c.MFA.EncryptionKey},
+		{"MFA_CHALLENGE_SECRET", c.MFA.ChallengeSecret},
+		{"EXPORT_LINK_SECRET", c.Export.LinkSecret},
+	}
+	// Секрет cookie состояния входа нужен, только если настроен хотя бы один провайдер
+	if len(c.OIDC.Providers) > 0 {
+		required = append(required, setting{"OIDC_STATE_SECRET", c.OIDC.StateSecret})
+	}
+
+	var missing []string
+	for _, r := range required {
+		if r.value == "" {
+			missing = append(missing, r.key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("required settings are not set: %s", strings.Join(missing, ", "))
+	}
+	return nil
 }
 
 // Вспомогательные функции для получения значений из переменных окружения
@@ -101,3 +340,28 @@ func getStringEnv(key string, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getListEnv(key string, defaultValue []string) []string {
+	if value := os.Getenv(key); value != "" {
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		if len(items) > 0 {
+			return items
+		}
+	}
+	return defaultValue
+}
+
+// IsSearchLanguage сообщает, разрешена ли конфигурация text search
+func (c *Config) IsSearchLanguage(language string) bool {
+	for _, l := range c.Search.Languages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		setup       func(c *Config)
+		wantMissing []string
+	}{
+		{
+			name:  "all secrets set",
+			setup: func(c *Config) {},
+		},
+		{
+			name:        "verification secret missing",
+			setup:       func(c *Config) { c.Verification.Secret = "" },
+			wantMissing: []string{"VERIFICATION_SECRET"},
+		},
+		{
+			name: "mfa keys missing",
+			setup: func(c *Config) {
+				c.MFA.EncryptionKey = ""
+				c.MFA.ChallengeSecret = ""
+			},
+			wantMissing: []string{"MFA_ENCRYPTION_KEY", "MFA_CHALLENGE_SECRET"},
+		},
+		{
+			name:        "export link secret missing",
+			setup:       func(c *Config) { c.Export.LinkSecret = "" },
+			wantMissing: []string{"EXPORT_LINK_SECRET"},
+		},
+		{
+			name:  "oidc state secret is optional without providers",
+			setup: func(c *Config) { c.OIDC.StateSecret = "" },
+		},
+		{
+			name: "oidc state secret missing",
+			setup: func(c *Config) {
+				c.OIDC.Providers = []OIDCProvider{{Name: "google"}}
+				c.OIDC.StateSecret = ""
+			},
+			wantMissing: []string{"OIDC_STATE_SECRET"},
+		},
+		{
+			name: "oidc provider with state secret",
+			setup: func(c *Config) {
+				c.OIDC.Providers = []OIDCProvider{{Name: "google"}}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{}
+			c.Verification.Secret = "secret"
+			c.MFA.EncryptionKey = "key"
+			c.MFA.ChallengeSecret = "challenge"
+			c.Export.LinkSecret = "link"
+			c.OIDC.StateSecret = "state"
+			tt.setup(c)
+
+			err := c.Validate()
+			if len(tt.wantMissing) == 0 {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Validate: want error")
+			}
+			for _, key := range tt.wantMissing {
+				if !strings.Contains(err.Error(), key) {
+					t.Errorf("error %q does not mention %s", err, key)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultsHaveNoSecrets(t *testing.T) {
+	for _, key := range []string{"VERIFICATION_SECRET", "MFA_ENCRYPTION_KEY", "MFA_CHALLENGE_SECRET", "EXPORT_LINK_SECRET"} {
+		t.Setenv(key, "")
+	}
+	c := &Config{}
+	c.loadFromEnv()
+	if err := c.Validate(); err == nil {
+		t.Fatal("configuration without secrets must be rejected")
+	}
+}
@@ -0,0 +1,13 @@
+package database
+
+import "errors"
+
+// uniqueViolation - код ошибки Postgres при нарушении уникального индекса
+const uniqueViolation = "23505"
+
+// IsUniqueViolation сообщает, что запись не сохранена из-за уникального индекса.
+// Так обнаруживается гонка, когда две транзакции одновременно создают одну и ту же запись.
+func IsUniqueViolation(err error) bool {
+	var pgErr interface{ SQLState() string }
+	return errors.As(err, &pgErr) && pgErr.SQLState() == uniqueViolation
+}
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsUniqueViolation(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "unique violation", err: &pgconn.PgError{Code: "23505"}, want: true},
+		{name: "wrapped unique violation", err: fmt.Errorf("create: %w", &pgconn.PgError{Code: "23505"}), want: true},
+		{name: "foreign key violation", err: &pgconn.PgError{Code: "23503"}, want: false},
+		{name: "other error", err: errors.New("boom"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsUniqueViolation(tt.err); got != tt.want {
+				t.Errorf("IsUniqueViolation(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package Api
+
+import (
+	"awesomeProject/internal/auth"
+	"awesomeProject/internal/domain/model/permission"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serve прогоняет запрос через middleware с заранее заданным участником
+func serve(principal *auth.Principal, middleware gin.HandlerFunc) int {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) {
+		if principal != nil {
+			auth.SetPrincipal(c, principal)
+		}
+		c.Next()
+	}, middleware, func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w.Code
+}
+
+func TestAccessMiddleware(t *testing.T) {
+	bearer := &auth.Principal{Method: auth.MethodBearer}
+	impersonated := &auth.Principal{Method: auth.MethodBearer, ImpersonatorID: 7}
+	readKey := &auth.Principal{Method: auth.MethodAPIKey, Permissions: []string{permission.UsersRead}}
+	newsKey := &auth.Principal{Method: auth.MethodAPIKey, Permissions: []string{permission.NewsRead}}
+	reactKey := &auth.Principal{Method: auth.MethodAPIKey, Permissions: []string{permission.NewsRead, permission.ReactionsWrite}}
+
+	tests := []struct {
+		name       string
+		principal  *auth.Principal
+		middleware gin.HandlerFunc
+		want       int
+	}{
+		{name: "deny api keys lets tokens through", principal: bearer, middleware: DenyAPIKeys(), want: http.StatusNoContent},
+		{name: "deny api keys blocks keys", principal: readKey, middleware: DenyAPIKeys(), want: http.StatusForbidden},
+		{name: "deny impersonation lets users through", principal: bearer, middleware: DenyImpersonation(), want: http.StatusNoContent},
+		{name: "deny impersonation blocks admins", principal: impersonated, middleware: DenyImpersonation(), want: http.StatusForbidden},
+		{name: "key scope is not checked for tokens", principal: bearer, middleware: RequireAPIKeyScope(permission.UsersRead), want: http.StatusNoContent},
+		{name: "key with the scope", principal: readKey, middleware: RequireAPIKeyScope(permission.UsersRead), want: http.StatusNoContent},
+		{name: "key without the scope", principal: newsKey, middleware: RequireAPIKeyScope(permission.UsersRead), want: http.StatusForbidden},
+		{name: "token permissions are enforced", principal: newsKey, middleware: RequirePermission(permission.UsersRead), want: http.StatusForbidden},
+		{name: "read-only key cannot react", principal: newsKey, middleware: RequirePermission(permission.ReactionsWrite), want: http.StatusForbidden},
+		{name: "key with reactions scope", principal: reactKey, middleware: RequirePermission(permission.ReactionsWrite), want: http.StatusNoContent},
+		{name: "no principal", principal: nil, middleware: RequirePermission(permission.UsersRead), want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serve(tt.principal, tt.middleware); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package Api
+
+import (
+	"awesomeProject/internal/auth"
+	roleService "awesomeProject/internal/domain/service/role"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	roles     *roleService.RoleService
+	rolesOnce sync.Once
+)
+
+// getRoleService лениво создает сервис, так как база инициализируется после загрузки пакета
+func getRoleService() *roleService.RoleService {
+	rolesOnce.Do(func() {
+		roles = roleService.NewRoleService()
+	})
+	return roles
+}
+
+// RequirePermission пропускает запрос, только если у пользователя есть указанное право.
+// Должен стоять после RequireAuth.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := auth.PrincipalFrom(c); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			c.Abort()
+			return
+		}
+
+		if !HasPermission(c, permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions", "required": permission})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAPIKeyScope пропускает запрос по ключу API, только если среди действующих scopes
+// ключа есть permission. Запросы с токеном и cookie проходят без проверки: для них
+// маршрут открыт любому аутентифицированному пользователю. Должен стоять после RequireAuth.
+func RequireAPIKeyScope(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, exists := auth.PrincipalFrom(c)
+		if exists && principal.Method == auth.MethodAPIKey && !containsPermission(principal.Permissions, permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API key scope is missing", "required": permission})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// HasPermission проверяет право текущего участника. Права берутся из токена, с которым он
+// вошел; если токен их не содержит, право проверяется по текущей роли пользователя в базе.
+func HasPermission(c *gin.Context, permission string) bool {
+	principal, exists := auth.PrincipalFrom(c)
+	if !exists {
+		return false
+	}
+	if principal.Permissions != nil {
+		return containsPermission(principal.Permissions, permission)
+	}
+
+	allowed, err := getRoleService().HasPermission(principal.User.Role, permission)
+	if err != nil {
+		log.Printf("Failed to check permission %s for user %d: %v", permission, principal.User.ID, err)
+		return false
+	}
+	return allowed
+}
+
+func containsPermission(permissions []string, permission string) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
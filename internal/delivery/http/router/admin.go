@@ -0,0 +1,439 @@
+package router
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	Api "awesomeProject/internal/delivery/http/middleware"
+	"awesomeProject/internal/domain/model/audit"
+	"awesomeProject/internal/domain/model/permission"
+	"awesomeProject/internal/domain/model/role"
+	"awesomeProject/internal/domain/model/user"
+	"awesomeProject/internal/domain/model/user_deleted"
+	roleService "awesomeProject/internal/domain/service/role"
+	service "awesomeProject/internal/domain/service/user"
+	"awesomeProject/internal/pagination"
+)
+
+// setupAdminRoutes регистрирует административные эндпоинты
+func setupAdminRoutes(r *gin.Engine, userService *service.UserService) {
+	roles := roleService.NewRoleService()
+
+	admin := r.Group("/admin",
+		Api.RequireAuth(),
+		Api.DenyImpersonation(),
+		Api.RequirePermission(permission.RolesManage))
+	{
+		admin.GET("/roles", func(c *gin.Context) {
+			allRoles, err := roles.ListRoles()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"message": "All roles",
+				"data":    allRoles,
+			})
+		})
+
+		admin.GET("/permissions", func(c *gin.Context) {
+			permissions, err := roles.ListPermissions()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"message": "All permissions",
+				"data":    permissions,
+			})
+		})
+
+		admin.POST("/roles", func(c *gin.Context) {
+			var req roleService.CreateRoleRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			created, err := roles.CreateRole(req)
+			if err != nil {
+				c.JSON(roleErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusCreated, gin.H{
+				"message": "Role created successfully",
+				"data":    created,
+			})
+		})
+
+		admin.PUT("/roles/:id", func(c *gin.Context) {
+			id, ok := parseIDParam(c, "id")
+			if !ok {
+				return
+			}
+			var req roleService.UpdateRoleRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			updated, err := roles.UpdateRole(id, req)
+			if err != nil {
+				c.JSON(roleErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"message": "Role updated successfully",
+				"data":    updated,
+			})
+		})
+
+		admin.DELETE("/roles/:id", func(c *gin.Context) {
+			id, ok := parseIDParam(c, "id")
+			if !ok {
+				return
+			}
+			if err := roles.DeleteRole(id); err != nil {
+				c.JSON(roleErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"message": "Role deleted successfully"})
+		})
+
+		admin.POST("/roles/:id/permissions", func(c *gin.Context) {
+			id, ok := parseIDParam(c, "id")
+			if !ok {
+				return
+			}
+			var req struct {
+				Permission string `json:"permission" binding:"required"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			updated, err := roles.AttachPermission(id, req.Permission)
+			if err != nil {
+				c.JSON(roleErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"message": "Permission attached",
+				"data":    updated,
+			})
+		})
+
+		admin.DELETE("/roles/:id/permissions/:permission", func(c *gin.Context) {
+			id, ok := parseIDParam(c, "id")
+			if !ok {
+				return
+			}
+			updated, err := roles.DetachPermission(id, c.Param("permission"))
+			if err != nil {
+				c.JSON(roleErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"message": "Permission detached",
+				"data":    updated,
+			})
+		})
+
+		admin.PUT("/users/:id/role", func(c *gin.Context) {
+			id, ok := parseIDParam(c, "id")
+			if !ok {
+				return
+			}
+			var req struct {
+				Role string `json:"role" binding:"required"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			updated, err := userService.AssignRole(id, req.Role)
+			if err != nil {
+				c.JSON(roleErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"message": "Role assigned successfully",
+				"data":    updated,
+			})
+		})
+
+		admin.GET("/users", Api.RequirePermission(permission.UsersRead), listUsersHandler(userService))
+
+		admin.POST("/users/bulk", Api.RequirePermission(permission.UsersManage), func(c *gin.Context) {
+			var req service.BulkRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			currentUser := c.MustGet("user").(user.User)
+			report, err := userService.BulkUpdate(currentUser.ID, req, clientInfo(c))
+			if err != nil {
+				c.JSON(bulkErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"message": "Bulk action completed",
+				"data":    report,
+			})
+		})
+
+		admin.POST("/users/import", Api.RequirePermission(permission.UsersManage), func(c *gin.Context) {
+			format := importFormat(c)
+			if format == "" {
+				c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": service.ErrUnsupportedImportFormat.Error()})
+				return
+			}
+			dryRun := false
+			if value := c.Query("dry_run"); value != "" {
+				parsed, err := strconv.ParseBool(value)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dry_run"})
+					return
+				}
+				dryRun = parsed
+			}
+
+			body := http.MaxBytesReader(c.Writer, c.Request.Body, importMaxBodySize)
+			currentUser := c.MustGet("user").(user.User)
+			report, err := userService.ImportUsers(currentUser.ID, format, body, dryRun, clientInfo(c))
+			if err != nil {
+				c.JSON(importErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			message := "Users imported"
+			if dryRun {
+				message = "Dry run completed, nothing was saved"
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"message": message,
+				"data":    report,
+			})
+		})
+
+		admin.POST("/users/:id/unlock", Api.RequirePermission(permission.UsersManage), func(c *gin.Context) {
+			id, ok := parseIDParam(c, "id")
+			if !ok {
+				return
+			}
+			unlocked, err := userService.UnlockUser(id)
+			if err != nil {
+				c.JSON(roleErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"message": "User unlocked successfully",
+				"data":    unlocked,
+			})
+		})
+
+		admin.GET("/users/deleted", Api.RequirePermission(permission.UsersManage), func(c *gin.Context) {
+			deleted, err := userService.ListDeletedUsers()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"message": "Deleted users",
+				"data":    deleted,
+			})
+		})
+
+		admin.POST("/users/:id/restore", Api.RequirePermission(permission.UsersManage), func(c *gin.Context) {
+			id, ok := parseIDParam(c, "id")
+			if !ok {
+				return
+			}
+			restored, err := userService.RestoreUser(id)
+			if err != nil {
+				c.JSON(restoreErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"message": "User restored successfully",
+				"data":    restored,
+			})
+		})
+
+		admin.POST("/users/:id/impersonate", Api.RequirePermission(permission.UsersManage), func(c *gin.Context) {
+			id, ok := parseIDParam(c, "id")
+			if !ok {
+				return
+			}
+			currentUser := c.MustGet("user").(user.User)
+			response, err := userService.Impersonate(currentUser.ID, id, clientInfo(c))
+			if err != nil {
+				c.JSON(impersonationErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, response)
+		})
+
+		admin.GET("/audit", func(c *gin.Context) {
+			params, err := pagination.Parse(c.Request.URL.Query())
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			filter := audit.Filter{Action: c.Query("action")}
+			if value := c.Query("actor_id"); value != "" {
+				id, err := strconv.ParseUint(value, 10, 32)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid actor_id"})
+					return
+				}
+				filter.ActorID = uint(id)
+			}
+			if value := c.Query("subject_id"); value != "" {
+				id, err := strconv.ParseUint(value, 10, 32)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subject_id"})
+					return
+				}
+				filter.SubjectID = uint(id)
+			}
+			page, err := userService.GetAuditLog(filter, params)
+			if err != nil {
+				c.JSON(pageErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, page)
+		})
+	}
+}
+
+// listUsersHandler отдает страницу пользователей с поиском, фильтрами и сортировкой
+func listUsersHandler(userService *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		params, err := pagination.Parse(c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filter, err := user.ParseFilter(c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		page, err := userService.GetPage(params, filter)
+		if err != nil {
+			c.JSON(pageErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+// importMaxBodySize - максимальный размер файла импорта пользователей
+const importMaxBodySize = 10 << 20
+
+// importFormat определяет формат файла импорта по параметру format или по Content-Type.
+// Пустая строка означает неподдерживаемый формат.
+func importFormat(c *gin.Context) string {
+	format := c.Query("format")
+	if format == "" {
+		switch c.ContentType() {
+		case "text/csv":
+			format = service.ImportFormatCSV
+		case "application/x-ndjson", "application/ndjson", "application/jsonl":
+			format = service.ImportFormatNDJSON
+		}
+	}
+	if format != service.ImportFormatCSV && format != service.ImportFormatNDJSON {
+		return ""
+	}
+	return format
+}
+
+// importErrorStatus сопоставляет ошибки импорта файла в целом с HTTP-статусами.
+// Ошибки отдельных строк возвращаются в отчете.
+func importErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	var csvErr *csv.ParseError
+	switch {
+	case errors.As(err, &maxBytesErr), errors.Is(err, service.ErrImportTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrUnsupportedImportFormat):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, service.ErrInvalidImportHeader),
+		errors.Is(err, bufio.ErrTooLong),
+		errors.As(err, &csvErr):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// bulkErrorStatus сопоставляет ошибки массового действия в целом с HTTP-статусами.
+// Ошибки по отдельным пользователям возвращаются в отчете.
+func bulkErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrBulkRoleRequired):
+		return http.StatusBadRequest
+	case errors.Is(err, role.ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// roleErrorStatus сопоставляет ошибки управления ролями с HTTP-статусами
+func roleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, role.ErrNotFound), errors.Is(err, user.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, permission.ErrNotFound):
+		return http.StatusBadRequest
+	case errors.Is(err, roleService.ErrRoleExists),
+		errors.Is(err, roleService.ErrRoleInUse),
+		errors.Is(err, roleService.ErrBuiltinRole),
+		errors.Is(err, roleService.ErrAdminLockout),
+		errors.Is(err, service.ErrLastAdmin):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// restoreErrorStatus сопоставляет ошибки восстановления пользователя с HTTP-статусами
+func restoreErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, user_deleted.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, user_deleted.ErrAnonymized):
+		return http.StatusGone
+	case errors.Is(err, user_deleted.ErrEmailTaken):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// impersonationErrorStatus сопоставляет ошибки имперсонации с HTTP-статусами
+func impersonationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, user.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrCannotImpersonate):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// parseIDParam разбирает числовой параметр пути и сам отвечает 400 при ошибке
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,76 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	Api "awesomeProject/internal/delivery/http/middleware"
+	"awesomeProject/internal/domain/model/api_key"
+	"awesomeProject/internal/domain/model/user"
+	apikeyService "awesomeProject/internal/domain/service/apikey"
+)
+
+// setupAPIKeyRoutes регистрирует управление персональными ключами API текущего пользователя
+func setupAPIKeyRoutes(protected *gin.RouterGroup) {
+	keys := apikeyService.NewAPIKeyService()
+	apiKeys := protected.Group("/me/api-keys", Api.DenyAPIKeys(), Api.DenyImpersonation())
+
+	apiKeys.GET("", func(c *gin.Context) {
+		currentUser := c.MustGet("user").(user.User)
+		list, err := keys.ListKeys(currentUser.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "API keys",
+			"data":    list,
+		})
+	})
+
+	apiKeys.POST("", func(c *gin.Context) {
+		var req apikeyService.CreateKeyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		currentUser := c.MustGet("user").(user.User)
+		created, err := keys.CreateKey(currentUser, req)
+		if err != nil {
+			c.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{
+			"message": "API key created, store it now: it will not be shown again",
+			"data":    created,
+		})
+	})
+
+	apiKeys.DELETE("/:keyId", func(c *gin.Context) {
+		keyID, ok := parseIDParam(c, "keyId")
+		if !ok {
+			return
+		}
+		currentUser := c.MustGet("user").(user.User)
+		if err := keys.RevokeKey(currentUser.ID, keyID); err != nil {
+			c.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+	})
+}
+
+// apiKeyErrorStatus сопоставляет ошибки сервиса ключей API с HTTP-статусами
+func apiKeyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, api_key.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, apikeyService.ErrInvalidScope),
+		errors.Is(err, apikeyService.ErrInvalidExpiry):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,143 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	Api "awesomeProject/internal/delivery/http/middleware"
+	"awesomeProject/internal/domain/model/comment"
+	"awesomeProject/internal/domain/model/news"
+	"awesomeProject/internal/domain/model/permission"
+	"awesomeProject/internal/domain/model/user"
+	commentService "awesomeProject/internal/domain/service/comment"
+)
+
+// setupCommentRoutes регистрирует эндпоинты комментариев внутри группы новостей
+func setupCommentRoutes(newsGroup *gin.RouterGroup) {
+	comments := commentService.NewCommentService()
+
+	newsGroup.GET("/:id/comments", func(c *gin.Context) {
+		newsID, ok := parseIDParam(c, "id")
+		if !ok {
+			return
+		}
+		currentUser := c.MustGet("user").(user.User)
+		tree, err := comments.ListComments(newsID, currentUser, Api.HasPermission(c, permission.CommentsModerate))
+		if err != nil {
+			c.JSON(commentErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Comments",
+			"data":    tree,
+		})
+	})
+
+	newsGroup.POST("/:id/comments", Api.RequirePermission(permission.CommentsWrite), func(c *gin.Context) {
+		newsID, ok := parseIDParam(c, "id")
+		if !ok {
+			return
+		}
+		var req commentService.CreateCommentRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		currentUser := c.MustGet("user").(user.User)
+		created, err := comments.CreateComment(newsID, currentUser, req)
+		if err != nil {
+			c.JSON(commentErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{
+			"message": "Comment created successfully",
+			"data":    created,
+		})
+	})
+
+	newsGroup.PATCH("/:id/comments/:commentId", Api.RequirePermission(permission.CommentsWrite), func(c *gin.Context) {
+		newsID, ok := parseIDParam(c, "id")
+		if !ok {
+			return
+		}
+		commentID, ok := parseIDParam(c, "commentId")
+		if !ok {
+			return
+		}
+		var req commentService.UpdateCommentRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		currentUser := c.MustGet("user").(user.User)
+		updated, err := comments.UpdateComment(newsID, commentID, currentUser, req)
+		if err != nil {
+			c.JSON(commentErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Comment updated successfully",
+			"data":    updated,
+		})
+	})
+
+	newsGroup.DELETE("/:id/comments/:commentId", Api.RequirePermission(permission.CommentsWrite), func(c *gin.Context) {
+		newsID, ok := parseIDParam(c, "id")
+		if !ok {
+			return
+		}
+		commentID, ok := parseIDParam(c, "commentId")
+		if !ok {
+			return
+		}
+		currentUser := c.MustGet("user").(user.User)
+		if err := comments.DeleteComment(newsID, commentID, currentUser, Api.HasPermission(c, permission.CommentsModerate)); err != nil {
+			c.JSON(commentErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+	})
+
+	newsGroup.PUT("/:id/comments/:commentId/status", Api.RequirePermission(permission.CommentsModerate), func(c *gin.Context) {
+		newsID, ok := parseIDParam(c, "id")
+		if !ok {
+			return
+		}
+		commentID, ok := parseIDParam(c, "commentId")
+		if !ok {
+			return
+		}
+		var req commentService.ModerateCommentRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		moderated, err := comments.ModerateComment(newsID, commentID, req)
+		if err != nil {
+			c.JSON(commentErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Comment status updated",
+			"data":    moderated,
+		})
+	})
+}
+
+// commentErrorStatus сопоставляет ошибки сервиса комментариев с HTTP-статусами
+func commentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, news.ErrNotFound), errors.Is(err, comment.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, commentService.ErrForbidden),
+		errors.Is(err, commentService.ErrEditWindowExpired):
+		return http.StatusForbidden
+	case errors.Is(err, commentService.ErrInvalidParent),
+		errors.Is(err, commentService.ErrInvalidStatus):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,90 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	Api "awesomeProject/internal/delivery/http/middleware"
+	"awesomeProject/internal/domain/model/upload"
+	"awesomeProject/internal/domain/model/user"
+	service "awesomeProject/internal/domain/service/user"
+)
+
+// setupExportRoutes регистрирует выгрузку персональных данных текущего пользователя
+// и скачивание архива по подписанной ссылке из письма
+func setupExportRoutes(r *gin.Engine, protected *gin.RouterGroup, userService *service.UserService) {
+	r.GET("/exports/download", func(c *gin.Context) {
+		token := c.Query("token")
+		if token == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+			return
+		}
+		archive, err := userService.GetExportByLink(token)
+		if err != nil {
+			c.JSON(exportErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Cache-Control", "no-store")
+		c.Header("Referrer-Policy", "no-referrer")
+		c.FileAttachment(archive.Path, archive.File)
+	})
+
+	exports := protected.Group("/me", Api.DenyAPIKeys(), Api.DenyImpersonation())
+
+	exports.POST("/export", func(c *gin.Context) {
+		currentUser := c.MustGet("user").(user.User)
+		if err := userService.RequestExport(currentUser.ID); err != nil {
+			c.JSON(exportErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{
+			"message": "Data export started, you will receive an email when the archive is ready",
+		})
+	})
+
+	exports.GET("/exports", func(c *gin.Context) {
+		currentUser := c.MustGet("user").(user.User)
+		list, err := userService.ListExports(currentUser.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Data exports",
+			"data":    list,
+		})
+	})
+
+	exports.GET("/exports/:exportId", func(c *gin.Context) {
+		exportID, ok := parseIDParam(c, "exportId")
+		if !ok {
+			return
+		}
+		currentUser := c.MustGet("user").(user.User)
+		archive, err := userService.GetExport(currentUser.ID, exportID)
+		if err != nil {
+			c.JSON(exportErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Cache-Control", "no-store")
+		c.FileAttachment(archive.Path, archive.File)
+	})
+}
+
+// exportErrorStatus сопоставляет ошибки выгрузки данных с HTTP-статусами
+func exportErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidExportLink):
+		return http.StatusBadRequest
+	case errors.Is(err, upload.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrExportExpired):
+		return http.StatusGone
+	case errors.Is(err, service.ErrExportInProgress):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
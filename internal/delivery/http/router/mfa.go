@@ -0,0 +1,162 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	Api "awesomeProject/internal/delivery/http/middleware"
+	"awesomeProject/internal/domain/model/user"
+	service "awesomeProject/internal/domain/service/user"
+)
+
+// setupMFARoutes регистрирует эндпоинты двухфакторной аутентификации: второй шаг входа
+// в /auth/mfa и управление своей 2FA в группе текущего пользователя
+func setupMFARoutes(r *gin.Engine, protected *gin.RouterGroup, userService *service.UserService) {
+	r.POST("/auth/mfa/verify", func(c *gin.Context) {
+		var req struct {
+			MFAToken     string `json:"mfa_token" binding:"required"`
+			Code         string `json:"code"`
+			RecoveryCode string `json:"recovery_code"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		response, err := userService.VerifyMFA(req.MFAToken, req.Code, req.RecoveryCode, clientInfo(c))
+		if err != nil {
+			if respondLocked(c, err) {
+				return
+			}
+			c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		setSessionCookie(c, response)
+		c.JSON(http.StatusOK, response)
+	})
+
+	r.POST("/auth/mfa/enroll", func(c *gin.Context) {
+		var req struct {
+			MFAToken string `json:"mfa_token" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		setup, err := userService.StartMFAEnrollment(req.MFAToken)
+		if err != nil {
+			c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, setup)
+	})
+
+	r.POST("/auth/mfa/enroll/confirm", func(c *gin.Context) {
+		var req struct {
+			MFAToken string `json:"mfa_token" binding:"required"`
+			Code     string `json:"code" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		confirmation, err := userService.ConfirmMFAEnrollment(req.MFAToken, req.Code, clientInfo(c))
+		if err != nil {
+			c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		if confirmation.Auth != nil {
+			setSessionCookie(c, *confirmation.Auth)
+		}
+		c.JSON(http.StatusOK, confirmation)
+	})
+
+	protected.POST("/me/mfa/setup", Api.DenyAPIKeys(), Api.DenyImpersonation(), func(c *gin.Context) {
+		currentUser := c.MustGet("user").(user.User)
+		setup, err := userService.SetupMFA(currentUser.ID)
+		if err != nil {
+			c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, setup)
+	})
+
+	protected.POST("/me/mfa/confirm", Api.DenyAPIKeys(), Api.DenyImpersonation(), func(c *gin.Context) {
+		var req struct {
+			Code string `json:"code" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		currentUser := c.MustGet("user").(user.User)
+		codes, err := userService.ConfirmMFA(currentUser.ID, req.Code)
+		if err != nil {
+			c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, service.MFAConfirmation{RecoveryCodes: codes})
+	})
+
+	protected.POST("/me/mfa/recovery-codes", Api.DenyAPIKeys(), Api.DenyImpersonation(), func(c *gin.Context) {
+		var req struct {
+			Code string `json:"code" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		currentUser := c.MustGet("user").(user.User)
+		codes, err := userService.RegenerateRecoveryCodes(currentUser.ID, req.Code)
+		if err != nil {
+			c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, service.MFAConfirmation{RecoveryCodes: codes})
+	})
+
+	protected.DELETE("/me/mfa", Api.DenyAPIKeys(), Api.DenyImpersonation(), func(c *gin.Context) {
+		var req struct {
+			Password     string `json:"password" binding:"required"`
+			Code         string `json:"code"`
+			RecoveryCode string `json:"recovery_code"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		currentUser := c.MustGet("user").(user.User)
+		if err := userService.DisableMFA(currentUser.ID, req.Password, req.Code, req.RecoveryCode); err != nil {
+			c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+	})
+}
+
+// clientInfo собирает данные клиента для истории входов и сессий
+func clientInfo(c *gin.Context) service.ClientInfo {
+	return service.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// mfaErrorStatus сопоставляет ошибки двухфакторной аутентификации с HTTP-статусами
+func mfaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidMFAToken),
+		errors.Is(err, service.ErrInvalidMFACode):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrWrongPassword),
+		errors.Is(err, service.ErrMFARequiredByRole):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrMFAAlreadyEnabled),
+		errors.Is(err, service.ErrMFANotEnabled),
+		errors.Is(err, service.ErrMFASetupMissing):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
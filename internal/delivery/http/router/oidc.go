@@ -0,0 +1,113 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"awesomeProject/internal/config"
+	service "awesomeProject/internal/domain/service/user"
+	"awesomeProject/internal/oidc"
+)
+
+// oidcStateCookie - cookie с подписанным состоянием незавершенного входа через провайдера
+const oidcStateCookie = "oidc_state"
+
+// setupOIDCRoutes регистрирует вход через внешних OIDC-провайдеров
+func setupOIDCRoutes(r *gin.Engine, userService *service.UserService) {
+	cfg := config.GetConfig()
+	providers := oidc.GetRegistry()
+
+	r.GET("/auth/oidc/:provider/login", func(c *gin.Context) {
+		provider, ok := providers.Provider(c.Param("provider"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+			return
+		}
+		state, err := oidc.NewState(provider.Name(), cfg.OIDC.StateTTL)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		authURL, err := provider.AuthCodeURL(c.Request.Context(), state.State, state.Nonce, state.CodeChallenge())
+		if err != nil {
+			c.JSON(oidcErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		encoded, err := state.Encode(cfg.OIDC.StateSecret)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// Lax: cookie должна прийти вместе с переходом браузера обратно от провайдера
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(oidcStateCookie, encoded, int(cfg.OIDC.StateTTL.Seconds()), "/auth/oidc/", "", cfg.Auth.CookieSecure, true)
+		c.Redirect(http.StatusFound, authURL)
+	})
+
+	r.GET("/auth/oidc/:provider/callback", func(c *gin.Context) {
+		provider, ok := providers.Provider(c.Param("provider"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+			return
+		}
+		if providerError := c.Query("error"); providerError != "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": providerError, "description": c.Query("error_description")})
+			return
+		}
+
+		// Состояние одноразовое: cookie удаляется при любом исходе
+		stateCookie, _ := c.Cookie(oidcStateCookie)
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(oidcStateCookie, "", -1, "/auth/oidc/", "", cfg.Auth.CookieSecure, true)
+
+		state, err := oidc.DecodeState(cfg.OIDC.StateSecret, stateCookie, provider.Name(), c.Query("state"), time.Now())
+		if err != nil {
+			c.JSON(oidcErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		code := c.Query("code")
+		if code == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Authorization code is required"})
+			return
+		}
+
+		ext, err := provider.Exchange(c.Request.Context(), code, state.CodeVerifier, state.Nonce)
+		if err != nil {
+			c.JSON(oidcErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		response, err := userService.LoginWithIdentity(service.ExternalIdentity{
+			Provider:      ext.Provider,
+			Subject:       ext.Subject,
+			Email:         ext.Email,
+			EmailVerified: ext.EmailVerified,
+			Name:          ext.Name,
+		}, clientInfo(c))
+		if err != nil {
+			c.JSON(oidcErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		setSessionCookie(c, response)
+		c.JSON(http.StatusOK, response)
+	})
+}
+
+// oidcErrorStatus сопоставляет ошибки входа через провайдера с HTTP-статусами
+func oidcErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, oidc.ErrInvalidState):
+		return http.StatusBadRequest
+	case errors.Is(err, oidc.ErrInvalidIDToken),
+		errors.Is(err, service.ErrExternalEmailNotVerified):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrAccountDisabled):
+		return http.StatusForbidden
+	case errors.Is(err, oidc.ErrProviderUnavailable):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,87 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	Api "awesomeProject/internal/delivery/http/middleware"
+	"awesomeProject/internal/domain/model/news"
+	"awesomeProject/internal/domain/model/permission"
+	"awesomeProject/internal/domain/model/user"
+	reactionService "awesomeProject/internal/domain/service/reaction"
+)
+
+// setupReactionRoutes регистрирует эндпоинты реакций внутри группы новостей
+func setupReactionRoutes(newsGroup *gin.RouterGroup) {
+	reactions := reactionService.NewReactionService()
+
+	newsGroup.GET("/:id/reactions", func(c *gin.Context) {
+		newsID, ok := parseIDParam(c, "id")
+		if !ok {
+			return
+		}
+		currentUser := c.MustGet("user").(user.User)
+		summary, err := reactions.GetSummary(c.Request.Context(), newsID, currentUser.ID)
+		if err != nil {
+			c.JSON(reactionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Reactions",
+			"data":    summary,
+		})
+	})
+
+	newsGroup.PUT("/:id/reactions", Api.RequirePermission(permission.ReactionsWrite), func(c *gin.Context) {
+		newsID, ok := parseIDParam(c, "id")
+		if !ok {
+			return
+		}
+		var req reactionService.SetReactionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		currentUser := c.MustGet("user").(user.User)
+		summary, err := reactions.SetReaction(c.Request.Context(), newsID, currentUser, req)
+		if err != nil {
+			c.JSON(reactionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Reaction saved",
+			"data":    summary,
+		})
+	})
+
+	newsGroup.DELETE("/:id/reactions", Api.RequirePermission(permission.ReactionsWrite), func(c *gin.Context) {
+		newsID, ok := parseIDParam(c, "id")
+		if !ok {
+			return
+		}
+		currentUser := c.MustGet("user").(user.User)
+		summary, err := reactions.RemoveReaction(c.Request.Context(), newsID, currentUser)
+		if err != nil {
+			c.JSON(reactionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Reaction removed",
+			"data":    summary,
+		})
+	})
+}
+
+// reactionErrorStatus сопоставляет ошибки сервиса реакций с HTTP-статусами
+func reactionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, news.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, reactionService.ErrInvalidType):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
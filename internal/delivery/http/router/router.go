@@ -2,28 +2,46 @@ package router
 
 import (
 	"context"
+	"errors"
+	"log"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"awesomeProject/internal/auth"
+	"awesomeProject/internal/config"
 	Api "awesomeProject/internal/delivery/http/middleware"
-	newsService "awesomeProject/internal/domain/service/news"
+	"awesomeProject/internal/domain/model/news"
+	"awesomeProject/internal/domain/model/permission"
+	"awesomeProject/internal/domain/model/user"
+	newsservice "awesomeProject/internal/domain/service/news"
 	service "awesomeProject/internal/domain/service/user"
+	"awesomeProject/internal/lockout"
 	"awesomeProject/internal/metrics"
+	"awesomeProject/internal/pagination"
+	"awesomeProject/internal/signing"
 )
 
 func SetupRouter() *gin.Engine {
 
 	userService := service.NewUserService()
-	newsService := newsService.NewNewsService()
+	userService.StartAnonymizer()
+	userService.StartExportCleanup()
+	userService.StartImpersonationExpiry()
+	newsService := newsservice.NewNewsService()
 
 	// Set release mode
 	gin.SetMode(gin.ReleaseMode)
 
 	r := gin.Default()
-	r.TrustedPlatform = "X-Forwarded-For"
+	// IP клиента нужен для блокировки перебора и журнала входов, поэтому X-Forwarded-For
+	// принимается только от доверенных прокси из TRUSTED_PROXIES
+	if err := r.SetTrustedProxies(config.GetConfig().Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	// Добавляем middleware для метрик
 	r.Use(metrics.MetricsMiddleware())
@@ -59,19 +77,200 @@ func SetupRouter() *gin.Engine {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 			return
 		}
-		response, err := userService.Login(creds.Email, creds.Password)
+		response, err := userService.Login(creds.Email, creds.Password, clientInfo(c))
 		if err != nil {
+			if respondLocked(c, err) {
+				return
+			}
+			if errors.Is(err, service.ErrEmailNotVerified) || errors.Is(err, service.ErrAccountDisabled) {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
+		setSessionCookie(c, response)
+		c.JSON(http.StatusOK, response)
+	})
+
+	r.POST("/auth/verify-email", func(c *gin.Context) {
+		var req struct {
+			Token string `json:"token" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		verified, err := userService.VerifyEmail(req.Token)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidVerificationToken) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Email verified",
+			"user":    verified,
+		})
+	})
+
+	r.POST("/auth/resend-verification", func(c *gin.Context) {
+		var req struct {
+			Email string `json:"email" binding:"required,email"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		userService.ResendVerification(req.Email)
+		c.JSON(http.StatusAccepted, gin.H{"message": "If the account exists and is not verified, a verification email has been sent"})
+	})
+
+	r.POST("/auth/forgot-password", func(c *gin.Context) {
+		var req struct {
+			Email string `json:"email" binding:"required,email"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		userService.ForgotPassword(req.Email)
+		c.JSON(http.StatusAccepted, gin.H{"message": "If the account exists, a password reset email has been sent"})
+	})
+
+	r.POST("/auth/reset-password", func(c *gin.Context) {
+		var req struct {
+			Token    string `json:"token" binding:"required"`
+			Password string `json:"password" binding:"required,min=6"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := userService.ResetPassword(req.Token, req.Password); err != nil {
+			if errors.Is(err, service.ErrInvalidResetToken) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Password has been reset"})
+	})
+
+	r.POST("/auth/refresh", func(c *gin.Context) {
+		var req struct {
+			RefreshToken string `json:"refresh_token" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		response, err := userService.RefreshToken(req.RefreshToken)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidRefreshToken) || errors.Is(err, service.ErrRefreshTokenReused) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		setSessionCookie(c, response)
 		c.JSON(http.StatusOK, response)
 	})
 
+	// Публичные ключи для проверки access-токенов другими сервисами. Кэш короткий,
+	// чтобы новый ключ после ротации быстро становился виден.
+	keys := signing.GetKeySet()
+	r.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, keys.JWKS())
+	})
+
+	r.POST("/logout", Api.RequireAuth(), Api.DenyAPIKeys(), func(c *gin.Context) {
+		principal, _ := auth.PrincipalFrom(c)
+		// Выход из имперсонации завершает только ее, не трогая сессии пользователя
+		if principal.IsImpersonated() {
+			if err := userService.StopImpersonation(principal.ImpersonatorID, principal.User.ID, principal.TokenID, principal.ExpiresAt, clientInfo(c)); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"message": "Impersonation stopped"})
+			return
+		}
+		if err := userService.Logout(principal.User.ID, principal.TokenID, principal.ExpiresAt, principal.SessionID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		auth.ClearSessionCookie(c)
+		c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+	})
+
+	r.POST("/logout/all", Api.RequireAuth(), Api.DenyAPIKeys(), Api.DenyImpersonation(), func(c *gin.Context) {
+		currentUser := c.MustGet("user").(user.User)
+		if err := userService.LogoutAll(currentUser.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		auth.ClearSessionCookie(c)
+		c.JSON(http.StatusOK, gin.H{"message": "Logged out from all sessions"})
+	})
+
 	//
 	protected := r.Group("/protected/user",
-		Api.TokenAuthMiddleware())
+		Api.RequireAuth())
 	{
-		protected.GET("/name/:id", func(c *gin.Context) {
+		protected.GET("/me", Api.DenyAPIKeys(), func(c *gin.Context) {
+			principal, _ := auth.PrincipalFrom(c)
+			response := gin.H{
+				"message":       "Current user",
+				"data":          principal.User,
+				"impersonation": nil,
+			}
+			if principal.IsImpersonated() {
+				response["impersonation"] = gin.H{
+					"impersonator_id": principal.ImpersonatorID,
+					"expires_at":      principal.ExpiresAt,
+				}
+			}
+			c.JSON(http.StatusOK, response)
+		})
+		protected.PATCH("/me", Api.DenyAPIKeys(), Api.DenyImpersonation(), func(c *gin.Context) {
+			var req service.UpdateProfileRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			currentUser := c.MustGet("user").(user.User)
+			updated, err := userService.UpdateProfile(currentUser.ID, req)
+			if err != nil {
+				c.JSON(profileErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"message": "Profile updated",
+				"data":    updated,
+			})
+		})
+		protected.DELETE("/me", Api.DenyAPIKeys(), Api.DenyImpersonation(), func(c *gin.Context) {
+			var req struct {
+				Password string `json:"password" binding:"required"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			currentUser := c.MustGet("user").(user.User)
+			if err := userService.DeleteAccount(currentUser.ID, req.Password); err != nil {
+				c.JSON(profileErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			auth.ClearSessionCookie(c)
+			c.JSON(http.StatusOK, gin.H{"message": "Account deleted"})
+		})
+		protected.GET("/name/:id", Api.RequireAPIKeyScope(permission.UsersRead), func(c *gin.Context) {
 			idParam := c.Param("id")
 			id, err := strconv.ParseUint(idParam, 10, 32)
 			if err != nil {
@@ -92,18 +291,44 @@ func SetupRouter() *gin.Engine {
 				"data":    user,
 			})
 		})
-		protected.GET("/all", func(c *gin.Context) {
-			users, err := userService.GetAll()
+		protected.GET("/all", Api.RequirePermission(permission.UsersRead), listUsersHandler(userService))
+		protected.POST("/me/password", Api.DenyAPIKeys(), Api.DenyImpersonation(), func(c *gin.Context) {
+			var req struct {
+				OldPassword string `json:"old_password" binding:"required"`
+				NewPassword string `json:"new_password" binding:"required,min=6"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			currentUser := c.MustGet("user").(user.User)
+			response, err := userService.ChangePassword(currentUser.ID, req.OldPassword, req.NewPassword, clientInfo(c))
 			if err != nil {
+				if errors.Is(err, service.ErrWrongPassword) {
+					c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+					return
+				}
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
 			}
-			c.JSON(http.StatusOK, gin.H{
-				"message": "All users",
-				"data":    users,
-			})
+			setSessionCookie(c, response)
+			c.JSON(http.StatusOK, response)
 		})
-		protected.GET("/all-with-details", func(c *gin.Context) {
+		protected.GET("/me/logins", Api.DenyAPIKeys(), func(c *gin.Context) {
+			params, err := pagination.Parse(c.Request.URL.Query())
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			currentUser := c.MustGet("user").(user.User)
+			page, err := userService.GetLoginHistory(currentUser.ID, params)
+			if err != nil {
+				c.JSON(pageErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, page)
+		})
+		protected.GET("/all-with-details", Api.RequirePermission(permission.UsersRead), func(c *gin.Context) {
 			// Создаем контекст с таймаутом
 			ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 			defer cancel()
@@ -126,18 +351,40 @@ func SetupRouter() *gin.Engine {
 	}
 
 	protectedNews := r.Group("/protected/news",
-		Api.TokenAuthMiddleware())
+		Api.RequireAuth(),
+		Api.RequirePermission(permission.NewsRead))
 	{
 		protectedNews.GET("/all", func(c *gin.Context) {
-			news, err := newsService.GetAllNews()
+			params, err := pagination.Parse(c.Request.URL.Query())
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 				return
 			}
-			c.JSON(http.StatusOK, gin.H{
-				"message": "All news",
-				"data":    news,
-			})
+			filter, err := news.ParseFilter(c.Request.URL.Query())
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			page, err := newsService.GetNewsPage(params, filter)
+			if err != nil {
+				c.JSON(pageErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, page)
+		})
+
+		protectedNews.GET("/search", func(c *gin.Context) {
+			params, err := pagination.Parse(c.Request.URL.Query())
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			page, err := newsService.SearchNews(c.Query("q"), c.Query("lang"), params)
+			if err != nil {
+				c.JSON(newsErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, page)
 		})
 
 		// Новый эндпоинт для получения новостей с деталями
@@ -146,7 +393,8 @@ func SetupRouter() *gin.Engine {
 			ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 			defer cancel()
 
-			news, err := newsService.GetAllNewsWithDetails(ctx)
+			currentUser := c.MustGet("user").(user.User)
+			news, err := newsService.GetAllNewsWithDetails(ctx, currentUser.ID)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
@@ -158,14 +406,13 @@ func SetupRouter() *gin.Engine {
 		})
 
 		protectedNews.GET("/:id", func(c *gin.Context) {
-			id := c.Param("id")
-			idParam, err := strconv.ParseUint(id, 10, 32)
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+			id, ok := parseIDParam(c, "id")
+			if !ok {
+				return
 			}
-			news, err := newsService.GetNewsByID(uint(idParam))
+			news, err := newsService.GetNewsByID(id)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				c.JSON(newsErrorStatus(err), gin.H{"error": err.Error()})
 				return
 			}
 			c.JSON(http.StatusOK, gin.H{
@@ -173,6 +420,149 @@ func SetupRouter() *gin.Engine {
 				"data":    news,
 			})
 		})
+
+		protectedNews.POST("", Api.RequirePermission(permission.NewsWrite), func(c *gin.Context) {
+			var req newsservice.CreateNewsRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			currentUser := c.MustGet("user").(user.User)
+			created, err := newsService.CreateNewsAs(currentUser, req)
+			if err != nil {
+				c.JSON(newsErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusCreated, gin.H{
+				"message": "News created successfully",
+				"data":    created,
+			})
+		})
+
+		protectedNews.PUT("/:id", Api.RequirePermission(permission.NewsWrite), func(c *gin.Context) {
+			id, ok := parseIDParam(c, "id")
+			if !ok {
+				return
+			}
+			var req newsservice.UpdateNewsRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			currentUser := c.MustGet("user").(user.User)
+			updated, err := newsService.ReplaceNewsAs(id, currentUser, Api.HasPermission(c, permission.NewsEditAny), req)
+			if err != nil {
+				c.JSON(newsErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"message": "News updated successfully",
+				"data":    updated,
+			})
+		})
+
+		protectedNews.PATCH("/:id", Api.RequirePermission(permission.NewsWrite), func(c *gin.Context) {
+			id, ok := parseIDParam(c, "id")
+			if !ok {
+				return
+			}
+			var req newsservice.PatchNewsRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			currentUser := c.MustGet("user").(user.User)
+			updated, err := newsService.PatchNewsAs(id, currentUser, Api.HasPermission(c, permission.NewsEditAny), req)
+			if err != nil {
+				c.JSON(newsErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"message": "News updated successfully",
+				"data":    updated,
+			})
+		})
+
+		protectedNews.DELETE("/:id", Api.RequirePermission(permission.NewsWrite), func(c *gin.Context) {
+			id, ok := parseIDParam(c, "id")
+			if !ok {
+				return
+			}
+			currentUser := c.MustGet("user").(user.User)
+			if err := newsService.DeleteNewsAs(id, currentUser, Api.HasPermission(c, permission.NewsEditAny)); err != nil {
+				c.JSON(newsErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"message": "News deleted successfully"})
+		})
+
+		setupCommentRoutes(protectedNews)
+		setupReactionRoutes(protectedNews)
 	}
+
+	setupMFARoutes(r, protected, userService)
+	setupAPIKeyRoutes(protected)
+	setupExportRoutes(r, protected, userService)
+	setupOIDCRoutes(r, userService)
+	setupAdminRoutes(r, userService)
 	return r
 }
+
+// profileErrorStatus сопоставляет ошибки изменения профиля с HTTP-статусами
+func profileErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, user.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrCurrentPasswordRequired):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrWrongPassword):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrEmailTaken),
+		errors.Is(err, service.ErrLastAdmin):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// setSessionCookie дублирует выданный access-токен в cookie сессии браузера
+func setSessionCookie(c *gin.Context, response service.AuthResponse) {
+	auth.SetSessionCookie(c, response.Token, time.Duration(response.ExpiresIn)*time.Second)
+}
+
+// respondLocked отвечает 429 с заголовком Retry-After, если вход временно заблокирован
+func respondLocked(c *gin.Context, err error) bool {
+	var locked *lockout.LockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	return true
+}
+
+// pageErrorStatus отличает неподходящий курсор от ошибок сервера
+func pageErrorStatus(err error) int {
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// newsErrorStatus сопоставляет ошибки сервиса новостей с HTTP-статусами
+func newsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, news.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, newsservice.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, newsservice.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, newsservice.ErrEmptyQuery),
+		errors.Is(err, newsservice.ErrInvalidLanguage),
+		errors.Is(err, newsservice.ErrCursorUnsupported):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,22 @@
+package api_key
+
+import (
+	"awesomeProject/internal/export"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	export.Register("api_keys", export.ExporterFunc(exportAPIKeys))
+}
+
+// exportAPIKeys выгружает API-ключи пользователя в api_keys.json. Хеш секрета
+// не сериализуется, поэтому в архив попадают только описания ключей.
+func exportAPIKeys(ctx context.Context, db *gorm.DB, userID uint, archive *export.Archive) error {
+	keys := []APIKey{}
+	if err := db.Where("user_id = ?", userID).Order("id").Find(&keys).Error; err != nil {
+		return err
+	}
+	return archive.WriteJSON("api_keys.json", keys)
+}
@@ -0,0 +1,32 @@
+package api_key
+
+import (
+	"awesomeProject/internal/domain/model/common"
+	"time"
+)
+
+// APIKey - персональный ключ для доступа сервисов от имени пользователя.
+// Ключ имеет вид ak_<prefix>_<secret>: по prefix ключ находится, а секрет хранится только как хеш.
+type APIKey struct {
+	common.Base
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"size:100;not null"`
+	Prefix     string     `json:"prefix" gorm:"size:16;not null;uniqueIndex"`
+	SecretHash string     `json:"-" gorm:"size:64;not null"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json;type:text;not null"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys_struct"
+}
+
+// IsActive сообщает, принимается ли ключ в момент now
+func (k *APIKey) IsActive(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
@@ -0,0 +1,76 @@
+package api_key
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrNotFound = errors.New("api key not found")
+
+type Repository interface {
+	Create(key *APIKey) error
+	FindByPrefix(prefix string) (APIKey, error)
+	FindByUser(userID uint) ([]APIKey, error)
+	Revoke(id, userID uint) error
+	RevokeAllForUser(userID uint) error
+	TouchLastUsed(id uint, at time.Time) error
+}
+
+type RepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &RepositoryImpl{db: db}
+}
+
+func (r *RepositoryImpl) Create(key *APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *RepositoryImpl) FindByPrefix(prefix string) (APIKey, error) {
+	var key APIKey
+	result := r.db.Where("prefix = ?", prefix).First(&key)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return key, ErrNotFound
+		}
+		return key, result.Error
+	}
+	return key, nil
+}
+
+// FindByUser возвращает все ключи пользователя, включая отозванные, новые первыми
+func (r *RepositoryImpl) FindByUser(userID uint) ([]APIKey, error) {
+	var keys []APIKey
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+// Revoke отзывает ключ пользователя. Повторный отзыв не считается ошибкой.
+func (r *RepositoryImpl) Revoke(id, userID uint) error {
+	var key APIKey
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return r.db.Model(&APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAllForUser отзывает все действующие ключи пользователя
+func (r *RepositoryImpl) RevokeAllForUser(userID uint) error {
+	return r.db.Model(&APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// TouchLastUsed обновляет время последнего использования без изменения updated_at
+func (r *RepositoryImpl) TouchLastUsed(id uint, at time.Time) error {
+	return r.db.Model(&APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
@@ -0,0 +1,33 @@
+package audit
+
+import (
+	"time"
+)
+
+// Действия, попадающие в журнал аудита
+const (
+	ActionImpersonationStart  = "impersonation.start"
+	ActionImpersonationStop   = "impersonation.stop"
+	ActionImpersonationExpire = "impersonation.expire"
+	ActionUserActivate        = "user.activate"
+	ActionUserDeactivate      = "user.deactivate"
+	ActionUserRoleChange      = "user.role_change"
+	ActionUserDelete          = "user.delete"
+	ActionUserImport          = "user.import"
+)
+
+// Event - запись журнала аудита: кто (ActorID) что сделал и с каким пользователем (SubjectID)
+type Event struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+	ActorID   uint      `json:"actor_id" gorm:"not null;index"`
+	SubjectID *uint     `json:"subject_id" gorm:"index"`
+	Action    string    `json:"action" gorm:"size:64;not null;index"`
+	IP        string    `json:"ip" gorm:"size:64"`
+	UserAgent string    `json:"user_agent" gorm:"size:512"`
+	Details   string    `json:"details,omitempty" gorm:"type:text"`
+}
+
+func (Event) TableName() string {
+	return "audit_events_struct"
+}
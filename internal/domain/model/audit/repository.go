@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"awesomeProject/internal/pagination"
+
+	"gorm.io/gorm"
+)
+
+// Filter сужает выборку журнала; нулевые поля не фильтруют
+type Filter struct {
+	ActorID   uint
+	SubjectID uint
+	Action    string
+}
+
+type Repository interface {
+	Create(event *Event) error
+	FindPage(filter Filter, params pagination.Params) ([]Event, int64, error)
+}
+
+type RepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &RepositoryImpl{db: db}
+}
+
+func (r *RepositoryImpl) Create(event *Event) error {
+	return r.db.Create(event).Error
+}
+
+// PageOrder - порядок страниц журнала: новые записи первыми
+var PageOrder = pagination.Order{Desc: true}
+
+// FindPage возвращает страницу журнала, новые записи первыми
+func (r *RepositoryImpl) FindPage(filter Filter, params pagination.Params) ([]Event, int64, error) {
+	var total int64
+	if err := r.db.Model(&Event{}).Scopes(filter.scope).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []Event
+	result := r.db.Scopes(filter.scope, params.Scope(PageOrder)).Find(&events)
+	return events, total, result.Error
+}
+
+func (f Filter) scope(db *gorm.DB) *gorm.DB {
+	if f.ActorID != 0 {
+		db = db.Where("actor_id = ?", f.ActorID)
+	}
+	if f.SubjectID != 0 {
+		db = db.Where("subject_id = ?", f.SubjectID)
+	}
+	if f.Action != "" {
+		db = db.Where("action = ?", f.Action)
+	}
+	return db
+}
@@ -0,0 +1,21 @@
+package comment
+
+import (
+	"awesomeProject/internal/export"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	export.Register("comments", export.ExporterFunc(exportComments))
+}
+
+// exportComments выгружает комментарии пользователя в comments.json
+func exportComments(ctx context.Context, db *gorm.DB, userID uint, archive *export.Archive) error {
+	comments := []Comment{}
+	if err := db.Where("user_id = ?", userID).Order("id").Find(&comments).Error; err != nil {
+		return err
+	}
+	return archive.WriteJSON("comments.json", comments)
+}
@@ -0,0 +1,34 @@
+package comment
+
+import (
+	"awesomeProject/internal/domain/model/common"
+	"time"
+)
+
+// Статусы модерации комментария
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// Comment - комментарий к новости. ParentID указывает на комментарий,
+// на который дан ответ; у комментариев верхнего уровня он пустой.
+type Comment struct {
+	common.Base
+	NewsID   uint       `json:"news_id" gorm:"not null;index"`
+	UserID   uint       `json:"user_id" gorm:"not null;index"`
+	ParentID *uint      `json:"parent_id" gorm:"index"`
+	Body     string     `json:"body" gorm:"type:text;not null"`
+	Status   string     `json:"status" gorm:"size:20;not null;index"`
+	EditedAt *time.Time `json:"edited_at"`
+}
+
+func (Comment) TableName() string {
+	return "comments_struct"
+}
+
+// IsValidStatus проверяет, что статус модерации известен
+func IsValidStatus(status string) bool {
+	return status == StatusPending || status == StatusApproved || status == StatusRejected
+}
@@ -0,0 +1,110 @@
+package comment
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrNotFound = errors.New("comment not found")
+
+type Repository interface {
+	Create(comment *Comment) error
+	FindByID(id uint) (Comment, error)
+	FindByNews(newsID uint, viewerID uint, includeHidden bool) ([]Comment, error)
+	UpdateBody(id uint, body, status string, editedAt time.Time) error
+	UpdateStatus(id uint, status string) error
+	Delete(id uint) error
+	DeleteByNews(newsID uint) error
+	CountByNewsIDs(ctx context.Context, newsIDs []uint) (map[uint]int64, error)
+}
+
+type RepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &RepositoryImpl{db: db}
+}
+
+func (r *RepositoryImpl) Create(comment *Comment) error {
+	return r.db.Create(comment).Error
+}
+
+func (r *RepositoryImpl) FindByID(id uint) (Comment, error) {
+	var comment Comment
+	result := r.db.First(&comment, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return comment, ErrNotFound
+		}
+		return comment, result.Error
+	}
+	return comment, nil
+}
+
+// FindByNews возвращает комментарии новости в порядке создания, включая удаленные:
+// они нужны, чтобы не рвать ветки ответов. Неодобренные комментарии видны
+// только их автору, а при includeHidden (модератору) - все.
+func (r *RepositoryImpl) FindByNews(newsID uint, viewerID uint, includeHidden bool) ([]Comment, error) {
+	query := r.db.Unscoped().Where("news_id = ?", newsID)
+	if !includeHidden {
+		query = query.Where("status = ? OR user_id = ?", StatusApproved, viewerID)
+	}
+
+	var comments []Comment
+	if err := query.Order("created_at, id").Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// UpdateBody сохраняет новый текст комментария вместе со статусом модерации,
+// который мог измениться из-за правки
+func (r *RepositoryImpl) UpdateBody(id uint, body, status string, editedAt time.Time) error {
+	return r.db.Model(&Comment{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"body":      body,
+		"status":    status,
+		"edited_at": editedAt,
+	}).Error
+}
+
+func (r *RepositoryImpl) UpdateStatus(id uint, status string) error {
+	return r.db.Model(&Comment{}).Where("id = ?", id).Update("status", status).Error
+}
+
+func (r *RepositoryImpl) Delete(id uint) error {
+	return r.db.Delete(&Comment{}, id).Error
+}
+
+// DeleteByNews удаляет все комментарии новости, например вместе с самой новостью
+func (r *RepositoryImpl) DeleteByNews(newsID uint) error {
+	return r.db.Where("news_id = ?", newsID).Delete(&Comment{}).Error
+}
+
+// CountByNewsIDs одним запросом считает одобренные неудаленные комментарии для списка новостей
+func (r *RepositoryImpl) CountByNewsIDs(ctx context.Context, newsIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(newsIDs))
+	if len(newsIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		NewsID uint
+		Count  int64
+	}
+	if err := r.db.WithContext(ctx).Model(&Comment{}).
+		Select("news_id, COUNT(*) AS count").
+		Where("news_id IN ? AND status = ?", newsIDs, StatusApproved).
+		Group("news_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.NewsID] = row.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,32 @@
+package identity
+
+import (
+	"awesomeProject/internal/export"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	export.Register("identities", export.ExporterFunc(exportIdentities))
+}
+
+// exportedIdentity - привязка к провайдеру в выгрузке. В API Subject скрыт,
+// но это данные самого пользователя, поэтому в архив он попадает.
+type exportedIdentity struct {
+	Identity
+	Subject string `json:"subject"`
+}
+
+// exportIdentities выгружает привязанные аккаунты внешних провайдеров в identities.json
+func exportIdentities(ctx context.Context, db *gorm.DB, userID uint, archive *export.Archive) error {
+	identities := []Identity{}
+	if err := db.Where("user_id = ?", userID).Order("id").Find(&identities).Error; err != nil {
+		return err
+	}
+	exported := make([]exportedIdentity, 0, len(identities))
+	for _, identity := range identities {
+		exported = append(exported, exportedIdentity{Identity: identity, Subject: identity.Subject})
+	}
+	return archive.WriteJSON("identities.json", exported)
+}
@@ -0,0 +1,18 @@
+package identity
+
+import (
+	"awesomeProject/internal/domain/model/common"
+)
+
+// Identity связывает пользователя с аккаунтом у внешнего OIDC-провайдера
+type Identity struct {
+	common.Base
+	UserID   uint   `json:"user_id" gorm:"not null;index"`
+	Provider string `json:"provider" gorm:"size:50;not null;uniqueIndex:idx_identity_provider_subject"`
+	Subject  string `json:"-" gorm:"size:255;not null;uniqueIndex:idx_identity_provider_subject"`
+	Email    string `json:"email" gorm:"size:255"`
+}
+
+func (Identity) TableName() string {
+	return "identities_struct"
+}
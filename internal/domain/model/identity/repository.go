@@ -0,0 +1,60 @@
+package identity
+
+import (
+	"awesomeProject/internal/database"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound  = errors.New("identity not found")
+	ErrDuplicate = errors.New("identity is already linked")
+)
+
+type Repository interface {
+	Create(identity *Identity) error
+	FindByProviderSubject(provider, subject string) (Identity, error)
+	FindByUser(userID uint) ([]Identity, error)
+	DeleteByUser(userID uint) error
+}
+
+type RepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &RepositoryImpl{db: db}
+}
+
+// Create связывает внешнюю учетную запись. Если ее уже связал параллельный вход, возвращает ErrDuplicate.
+func (r *RepositoryImpl) Create(identity *Identity) error {
+	err := r.db.Create(identity).Error
+	if database.IsUniqueViolation(err) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+func (r *RepositoryImpl) FindByProviderSubject(provider, subject string) (Identity, error) {
+	var identity Identity
+	result := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return identity, ErrNotFound
+		}
+		return identity, result.Error
+	}
+	return identity, nil
+}
+
+func (r *RepositoryImpl) FindByUser(userID uint) ([]Identity, error) {
+	var identities []Identity
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error
+	return identities, err
+}
+
+// DeleteByUser отвязывает все внешние учетные записи пользователя
+func (r *RepositoryImpl) DeleteByUser(userID uint) error {
+	return r.db.Unscoped().Where("user_id = ?", userID).Delete(&Identity{}).Error
+}
@@ -0,0 +1,22 @@
+package impersonation
+
+import (
+	"awesomeProject/internal/domain/model/common"
+	"time"
+)
+
+// Impersonation - выданный администратору токен работы от имени пользователя.
+// По записи токен отзывается при выходе администратора из всех сессий,
+// а по истечении срока в журнал аудита попадает окончание имперсонации.
+type Impersonation struct {
+	common.Base
+	JTI       string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	ActorID   uint       `json:"actor_id" gorm:"not null;index"`
+	SubjectID uint       `json:"subject_id" gorm:"not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null;index"`
+	EndedAt   *time.Time `json:"ended_at"`
+}
+
+func (Impersonation) TableName() string {
+	return "impersonations_struct"
+}
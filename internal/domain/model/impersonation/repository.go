@@ -0,0 +1,71 @@
+package impersonation
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	Create(impersonation *Impersonation) error
+	End(jti string, at time.Time) (bool, error)
+	EndAllForActor(actorID uint, at time.Time) ([]Impersonation, error)
+	EndExpired(now time.Time, limit int) ([]Impersonation, error)
+}
+
+type RepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &RepositoryImpl{db: db}
+}
+
+func (r *RepositoryImpl) Create(impersonation *Impersonation) error {
+	return r.db.Create(impersonation).Error
+}
+
+// End завершает имперсонацию по jti токена. false означает, что она уже была завершена.
+func (r *RepositoryImpl) End(jti string, at time.Time) (bool, error) {
+	result := r.db.Model(&Impersonation{}).
+		Where("jti = ? AND ended_at IS NULL", jti).
+		Update("ended_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+// EndAllForActor завершает незавершенные имперсонации администратора, срок которых еще не истек,
+// и возвращает их, чтобы отозвать токены
+func (r *RepositoryImpl) EndAllForActor(actorID uint, at time.Time) ([]Impersonation, error) {
+	var ended []Impersonation
+	err := r.db.Model(&ended).
+		Clauses(clause.Returning{}).
+		Where("actor_id = ? AND ended_at IS NULL AND expires_at > ?", actorID, at).
+		Update("ended_at", at).Error
+	return ended, err
+}
+
+// EndExpired завершает не больше limit имперсонаций, срок которых истек к now, и возвращает их.
+// Строки, которые параллельно обрабатывает другой экземпляр, пропускаются.
+func (r *RepositoryImpl) EndExpired(now time.Time, limit int) ([]Impersonation, error) {
+	var ended []Impersonation
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Model(&Impersonation{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("ended_at IS NULL AND expires_at <= ?", now).
+			Order("expires_at").
+			Limit(limit).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Model(&ended).
+			Clauses(clause.Returning{}).
+			Where("id IN ?", ids).
+			Update("ended_at", now).Error
+	})
+	return ended, err
+}
@@ -0,0 +1,19 @@
+package login_attempt
+
+import (
+	"time"
+)
+
+// LoginAttempt - счетчик неудачных входов по ключу (аккаунт или IP).
+// Используется как хранилище блокировок, общее для нескольких экземпляров приложения.
+type LoginAttempt struct {
+	Key         string     `json:"key" gorm:"primaryKey;size:320"`
+	Failures    int        `json:"failures" gorm:"not null"`
+	LockedUntil *time.Time `json:"locked_until"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null;index"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (LoginAttempt) TableName() string {
+	return "login_attempts_struct"
+}
@@ -0,0 +1,68 @@
+package login_attempt
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrNotFound = errors.New("login attempt not found")
+
+type Repository interface {
+	FindActive(key string, now time.Time) (LoginAttempt, error)
+	Increment(key string, now time.Time, ttl time.Duration) (LoginAttempt, error)
+	SetLockedUntil(key string, until time.Time) error
+	Delete(key string) error
+}
+
+type RepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &RepositoryImpl{db: db}
+}
+
+// FindActive возвращает непросроченный счетчик по ключу
+func (r *RepositoryImpl) FindActive(key string, now time.Time) (LoginAttempt, error) {
+	var attempt LoginAttempt
+	result := r.db.Where("key = ? AND expires_at > ?", key, now).First(&attempt)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return attempt, ErrNotFound
+		}
+		return attempt, result.Error
+	}
+	return attempt, nil
+}
+
+// Increment атомарно увеличивает счетчик неудач и продлевает его жизнь на ttl.
+// Просроченный счетчик начинается заново вместе со снятием блокировки.
+func (r *RepositoryImpl) Increment(key string, now time.Time, ttl time.Duration) (LoginAttempt, error) {
+	var attempt LoginAttempt
+	result := r.db.Raw(`
+		INSERT INTO login_attempts_struct (key, failures, expires_at, updated_at)
+		VALUES (?, 1, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_attempts_struct.expires_at > EXCLUDED.updated_at
+				THEN login_attempts_struct.failures + 1 ELSE 1 END,
+			locked_until = CASE WHEN login_attempts_struct.expires_at > EXCLUDED.updated_at
+				THEN login_attempts_struct.locked_until ELSE NULL END,
+			expires_at = EXCLUDED.expires_at,
+			updated_at = EXCLUDED.updated_at
+		RETURNING key, failures, locked_until, expires_at, updated_at`,
+		key, now.Add(ttl), now).Scan(&attempt)
+	return attempt, result.Error
+}
+
+func (r *RepositoryImpl) SetLockedUntil(key string, until time.Time) error {
+	return r.db.Model(&LoginAttempt{}).Where("key = ?", key).Updates(map[string]interface{}{
+		"locked_until": until,
+		"updated_at":   time.Now(),
+	}).Error
+}
+
+func (r *RepositoryImpl) Delete(key string) error {
+	return r.db.Where("key = ?", key).Delete(&LoginAttempt{}).Error
+}
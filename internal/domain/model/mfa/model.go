@@ -0,0 +1,51 @@
+package mfa
+
+import (
+	"awesomeProject/internal/domain/model/common"
+	"time"
+)
+
+// Factor - TOTP-фактор пользователя. Секрет хранится зашифрованным, фактор
+// включается только после подтверждения кодом из приложения (ConfirmedAt).
+type Factor struct {
+	common.Base
+	UserID          uint       `json:"user_id" gorm:"not null;uniqueIndex"`
+	SecretEncrypted string     `json:"-" gorm:"type:text;not null"`
+	ConfirmedAt     *time.Time `json:"confirmed_at"`
+	LastUsedCounter int64      `json:"-" gorm:"not null;default:0"`
+}
+
+func (Factor) TableName() string {
+	return "mfa_factors_struct"
+}
+
+// IsEnabled сообщает, подтвержден ли фактор
+func (f *Factor) IsEnabled() bool {
+	return f.ConfirmedAt != nil
+}
+
+// RecoveryCode - одноразовый код восстановления, хранится только хеш
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "mfa_recovery_codes_struct"
+}
+
+// UsedChallenge - погашенный mfa_token, хранится только хеш. Уникальный индекс не дает
+// завершить вход одним challenge дважды. Запись нужна только до истечения срока challenge.
+type UsedChallenge struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	TokenHash string    `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+}
+
+func (UsedChallenge) TableName() string {
+	return "mfa_used_challenges_struct"
+}
@@ -0,0 +1,136 @@
+package mfa
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrNotFound = errors.New("mfa factor not found")
+
+type Repository interface {
+	FindByUserID(userID uint) (Factor, error)
+	SaveFactor(factor *Factor) error
+	Confirm(userID uint, counter int64, at time.Time) error
+	AdvanceCounter(userID uint, counter int64) (bool, error)
+	Delete(userID uint) error
+	ReplaceRecoveryCodes(userID uint, hashes []string) error
+	UseRecoveryCode(userID uint, hash string) (bool, error)
+	CountUnusedRecoveryCodes(userID uint) (int64, error)
+	IsChallengeUsed(hash string) (bool, error)
+	UseChallenge(hash string, expiresAt time.Time) (bool, error)
+}
+
+type RepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &RepositoryImpl{db: db}
+}
+
+func (r *RepositoryImpl) FindByUserID(userID uint) (Factor, error) {
+	var factor Factor
+	result := r.db.Where("user_id = ?", userID).First(&factor)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return factor, ErrNotFound
+		}
+		return factor, result.Error
+	}
+	return factor, nil
+}
+
+// SaveFactor создает новый неподтвержденный фактор, заменяя прежний фактор пользователя
+func (r *RepositoryImpl) SaveFactor(factor *Factor) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", factor.UserID).Delete(&Factor{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(factor).Error
+	})
+}
+
+// Confirm включает фактор и запоминает шаг кода, которым он подтвержден
+func (r *RepositoryImpl) Confirm(userID uint, counter int64, at time.Time) error {
+	return r.db.Model(&Factor{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+		"confirmed_at":      at,
+		"last_used_counter": counter,
+	}).Error
+}
+
+// AdvanceCounter атомарно запоминает использованный шаг кода. Возвращает false,
+// если этот или более поздний шаг уже использован, то есть код предъявлен повторно.
+func (r *RepositoryImpl) AdvanceCounter(userID uint, counter int64) (bool, error) {
+	result := r.db.Model(&Factor{}).
+		Where("user_id = ? AND last_used_counter < ?", userID, counter).
+		Update("last_used_counter", counter)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Delete отключает фактор и удаляет коды восстановления
+func (r *RepositoryImpl) Delete(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&Factor{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error
+	})
+}
+
+// ReplaceRecoveryCodes заменяет все коды восстановления пользователя новыми
+func (r *RepositoryImpl) ReplaceRecoveryCodes(userID uint, hashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]RecoveryCode, len(hashes))
+		for i, hash := range hashes {
+			codes[i] = RecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&codes).Error
+	})
+}
+
+// UseRecoveryCode гасит код восстановления. Возвращает false, если кода нет или он уже использован.
+func (r *RepositoryImpl) UseRecoveryCode(userID uint, hash string) (bool, error) {
+	result := r.db.Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *RepositoryImpl) CountUnusedRecoveryCodes(userID uint) (int64, error) {
+	var count int64
+	result := r.db.Model(&RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count)
+	return count, result.Error
+}
+
+func (r *RepositoryImpl) IsChallengeUsed(hash string) (bool, error) {
+	var count int64
+	if err := r.db.Model(&UsedChallenge{}).Where("token_hash = ?", hash).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// UseChallenge атомарно гасит mfa_token. Возвращает false, если он уже погашен,
+// в том числе параллельным запросом к другому экземпляру приложения.
+func (r *RepositoryImpl) UseChallenge(hash string, expiresAt time.Time) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token_hash"}},
+		DoNothing: true,
+	}).Create(&UsedChallenge{TokenHash: hash, ExpiresAt: expiresAt})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -1,17 +1,35 @@
 package migrate
 
 import (
+	"awesomeProject/internal/config"
 	"awesomeProject/internal/database"
+	"awesomeProject/internal/domain/model/api_key"
+	"awesomeProject/internal/domain/model/audit"
+	"awesomeProject/internal/domain/model/comment"
+	"awesomeProject/internal/domain/model/identity"
+	"awesomeProject/internal/domain/model/impersonation"
+	"awesomeProject/internal/domain/model/login_attempt"
+	"awesomeProject/internal/domain/model/mfa"
 	"awesomeProject/internal/domain/model/news"
+	"awesomeProject/internal/domain/model/password_reset"
+	"awesomeProject/internal/domain/model/reaction"
+	"awesomeProject/internal/domain/model/refresh_token"
+	"awesomeProject/internal/domain/model/revoked_token"
 	"awesomeProject/internal/domain/model/role"
 	"awesomeProject/internal/domain/model/seeder"
+	"awesomeProject/internal/domain/model/session"
 	"awesomeProject/internal/domain/model/upload"
 	"awesomeProject/internal/domain/model/user"
 	"awesomeProject/internal/domain/model/user_deleted"
+	"fmt"
 	"log"
+	"regexp"
 	"sync"
 )
 
+// searchLanguagePattern ограничивает имя конфигурации text search, так как оно подставляется в DDL
+var searchLanguagePattern = regexp.MustCompile(`^[a-z_]+$`)
+
 func Migrate() {
 	var wg sync.WaitGroup
 	var count int64
@@ -28,7 +46,7 @@ func Migrate() {
 	}
 
 	// Запускаем миграции параллельно
-	wg.Add(5)
+	wg.Add(17)
 
 	// Миграция пользователей
 	go migrateWithError(func() error {
@@ -41,9 +59,6 @@ func Migrate() {
 
 	// Миграция ролей
 	go migrateWithError(func() error {
-		if err := database.DB.AutoMigrate(&role.Role{}); err != nil {
-			return err
-		}
 		MigrateRole(count)
 		return nil
 	}, "role")
@@ -75,6 +90,66 @@ func Migrate() {
 		return nil
 	}, "upload")
 
+	// Миграция refresh-токенов
+	go migrateWithError(func() error {
+		return MigrateRefreshToken()
+	}, "refresh_token")
+
+	// Миграция отозванных токенов
+	go migrateWithError(func() error {
+		return MigrateRevokedToken()
+	}, "revoked_token")
+
+	// Миграция комментариев
+	go migrateWithError(func() error {
+		return MigrateComment()
+	}, "comment")
+
+	// Миграция реакций
+	go migrateWithError(func() error {
+		return MigrateReaction()
+	}, "reaction")
+
+	// Миграция сессий и истории входов
+	go migrateWithError(func() error {
+		return MigrateSession()
+	}, "session")
+
+	// Миграция счетчиков неудачных входов
+	go migrateWithError(func() error {
+		return MigrateLoginAttempt()
+	}, "login_attempt")
+
+	// Миграция токенов сброса пароля
+	go migrateWithError(func() error {
+		return MigratePasswordReset()
+	}, "password_reset")
+
+	// Миграция двухфакторной аутентификации
+	go migrateWithError(func() error {
+		return MigrateMFA()
+	}, "mfa")
+
+	// Миграция ключей API
+	go migrateWithError(func() error {
+		return MigrateAPIKeys()
+	}, "api_key")
+
+	// Миграция внешних учетных записей
+	go migrateWithError(func() error {
+		return MigrateIdentities()
+	}, "identity")
+
+	// Миграция журнала аудита
+	go migrateWithError(func() error {
+		return MigrateAudit()
+	}, "audit")
+
+	// Миграция выданных токенов имперсонации
+	go migrateWithError(func() error {
+		return MigrateImpersonations()
+	}, "impersonation")
+
 	// Ожидаем завершения всех миграций
 	wg.Wait()
 	log.Println("All migrations completed")
@@ -91,8 +166,8 @@ func MigrateUser(count int64) {
 
 // /
 func MigrateRole(count int64) {
-	//eng: Drop roles table //ru: Полностью удаляем таблицу ролей
-	if err := database.DB.Exec("DROP TABLE IF EXISTS roles_struct CASCADE;").Error; err != nil {
+	//eng: Drop roles and permissions tables //ru: Полностью удаляем таблицы ролей и прав
+	if err := database.DB.Exec("DROP TABLE IF EXISTS role_permissions_struct, permissions_struct, roles_struct CASCADE;").Error; err != nil {
 		log.Printf("Failed to drop roles table: %v", err)
 	}
 
@@ -104,15 +179,36 @@ func MigrateRole(count int64) {
 			updated_at TIMESTAMP WITH TIME ZONE,
 			deleted_at TIMESTAMP WITH TIME ZONE,
 			role_name VARCHAR(255) NOT NULL UNIQUE,
-			description TEXT NOT NULL
+			description TEXT NOT NULL,
+			require_mfa BOOLEAN NOT NULL DEFAULT FALSE
 		);
 	`).Error; err != nil {
 		log.Fatalf("Failed to create roles table: %v", err)
 	}
 
+	//eng: Create permissions and the role-permission link table //ru: Создаем таблицу прав и таблицу связи ролей с правами
+	if err := database.DB.Exec(`
+		CREATE TABLE permissions_struct (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMP WITH TIME ZONE,
+			updated_at TIMESTAMP WITH TIME ZONE,
+			deleted_at TIMESTAMP WITH TIME ZONE,
+			name VARCHAR(255) NOT NULL UNIQUE,
+			description TEXT NOT NULL
+		);
+		CREATE TABLE role_permissions_struct (
+			role_id INTEGER NOT NULL REFERENCES roles_struct(id) ON DELETE CASCADE,
+			permission_id INTEGER NOT NULL REFERENCES permissions_struct(id) ON DELETE CASCADE,
+			PRIMARY KEY (role_id, permission_id)
+		);
+	`).Error; err != nil {
+		log.Fatalf("Failed to create permissions tables: %v", err)
+	}
+
 	database.DB.Model(&role.Role{}).Count(&count)
 	log.Println("Database models Role migrated successfully")
-	seeder.SeedRoles(3)
+	seeder.SeedPermissions()
+	seeder.SeedRoles()
 }
 
 // /
@@ -120,11 +216,41 @@ func MigrateNews(count int64) {
 	if err := database.DB.AutoMigrate(&news.News{}); err != nil {
 		log.Fatalf("Failed to migrate news model: %v", err)
 	}
+	if err := MigrateNewsSearch(); err != nil {
+		log.Fatalf("Failed to migrate news search: %v", err)
+	}
 	database.DB.Model(&news.News{}).Count(&count)
 	log.Println("Database models News migrated successfully")
 	seeder.SeedNews(5)
 }
 
+// eng: MigrateNewsSearch adds the text search language, the generated tsvector column and its GIN index
+// ru: MigrateNewsSearch добавляет язык поиска, генерируемую колонку tsvector и GIN-индекс по ней
+func MigrateNewsSearch() error {
+	language := config.GetConfig().Search.Language
+	if !searchLanguagePattern.MatchString(language) {
+		return fmt.Errorf("invalid SEARCH_LANGUAGE %q", language)
+	}
+
+	statements := []string{
+		fmt.Sprintf(`ALTER TABLE news_struct ADD COLUMN IF NOT EXISTS language regconfig NOT NULL DEFAULT '%s'`, language),
+		fmt.Sprintf(`ALTER TABLE news_struct ALTER COLUMN language SET DEFAULT '%s'`, language),
+		`ALTER TABLE news_struct ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+			setweight(to_tsvector(language, coalesce(title, '')), 'A') ||
+			setweight(to_tsvector(language, coalesce(description, '')), 'B') ||
+			setweight(to_tsvector(language, coalesce(content, '')), 'C')
+		) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_news_struct_search_vector ON news_struct USING GIN (search_vector)`,
+	}
+	for _, statement := range statements {
+		if err := database.DB.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	log.Println("News full-text search migrated successfully")
+	return nil
+}
+
 // /
 func MigrateUserDeleted(count int64) {
 	if err := database.DB.AutoMigrate(&user_deleted.UserDeleted{}); err != nil {
@@ -144,12 +270,108 @@ func MigrateUpload(count int64) {
 	seeder.SeedUpload(5)
 }
 
+func MigrateRefreshToken() error {
+	if err := database.DB.AutoMigrate(&refresh_token.RefreshToken{}); err != nil {
+		return err
+	}
+	log.Println("Database models RefreshToken migrated successfully")
+	return nil
+}
+
+func MigrateRevokedToken() error {
+	if err := database.DB.AutoMigrate(&revoked_token.RevokedToken{}, &revoked_token.UserRevocation{}); err != nil {
+		return err
+	}
+	log.Println("Database models RevokedToken migrated successfully")
+	return nil
+}
+
+func MigrateComment() error {
+	if err := database.DB.AutoMigrate(&comment.Comment{}); err != nil {
+		return err
+	}
+	log.Println("Database models Comment migrated successfully")
+	return nil
+}
+
+func MigrateReaction() error {
+	if err := database.DB.AutoMigrate(&reaction.Reaction{}); err != nil {
+		return err
+	}
+	log.Println("Database models Reaction migrated successfully")
+	return nil
+}
+
+func MigrateSession() error {
+	if err := database.DB.AutoMigrate(&session.Session{}, &session.LoginEvent{}); err != nil {
+		return err
+	}
+	log.Println("Database models Session migrated successfully")
+	return nil
+}
+
+func MigrateLoginAttempt() error {
+	if err := database.DB.AutoMigrate(&login_attempt.LoginAttempt{}); err != nil {
+		return err
+	}
+	log.Println("Database models LoginAttempt migrated successfully")
+	return nil
+}
+
+func MigratePasswordReset() error {
+	if err := database.DB.AutoMigrate(&password_reset.PasswordReset{}); err != nil {
+		return err
+	}
+	log.Println("Database models PasswordReset migrated successfully")
+	return nil
+}
+
+func MigrateMFA() error {
+	if err := database.DB.AutoMigrate(&mfa.Factor{}, &mfa.RecoveryCode{}, &mfa.UsedChallenge{}); err != nil {
+		return err
+	}
+	log.Println("Database models MFA migrated successfully")
+	return nil
+}
+
+func MigrateAPIKeys() error {
+	if err := database.DB.AutoMigrate(&api_key.APIKey{}); err != nil {
+		return err
+	}
+	log.Println("Database models APIKey migrated successfully")
+	return nil
+}
+
+func MigrateIdentities() error {
+	if err := database.DB.AutoMigrate(&identity.Identity{}); err != nil {
+		return err
+	}
+	log.Println("Database models Identity migrated successfully")
+	return nil
+}
+
+func MigrateAudit() error {
+	if err := database.DB.AutoMigrate(&audit.Event{}); err != nil {
+		return err
+	}
+	log.Println("Database models Audit migrated successfully")
+	return nil
+}
+
+func MigrateImpersonations() error {
+	if err := database.DB.AutoMigrate(&impersonation.Impersonation{}); err != nil {
+		return err
+	}
+	log.Println("Database models Impersonation migrated successfully")
+	return nil
+}
+
 // eng: TruncateTables clears all tables in the database //ru: TruncateTables очищает все таблицы в базе данных
 func TruncateTables() {
 	log.Println("Truncating all tables...")
 
 	//eng: For PostgreSQL we use TRUNCATE //ru: Для PostgreSQL используем TRUNCATE
-	if err := database.DB.Exec("TRUNCATE TABLE users_struct, roles_struct, news_struct, users_deleted_struct, uploads_struct CASCADE;").Error; err != nil {
+	if err := database.DB.Exec("TRUNCATE TABLE users_struct, roles_struct, permissions_struct, role_permissions_struct, news_struct, users_deleted_struct, uploads_struct, refresh_tokens_struct, revoked_tokens_struct, user_revocations_struct, comments_struct, reactions_struct, sessions_struct, login_events_struct, login_attempts_struct, password_resets_struct, mfa_factors_struct, mfa_recovery_codes_struct, mfa_used_challenges_struct, api_keys_struct, identities_struct, audit_events_struct, impersonations_struct CASCADE;").Error; err != nil {
 		log.Printf("Failed to truncate tables: %v", err)
 	}
 	log.Println("All tables truncated successfully")
@@ -0,0 +1,128 @@
+package migrate
+
+import (
+	"awesomeProject/internal/domain/model/api_key"
+	"awesomeProject/internal/domain/model/audit"
+	"awesomeProject/internal/domain/model/comment"
+	"awesomeProject/internal/domain/model/identity"
+	"awesomeProject/internal/domain/model/impersonation"
+	"awesomeProject/internal/domain/model/login_attempt"
+	"awesomeProject/internal/domain/model/mfa"
+	"awesomeProject/internal/domain/model/news"
+	"awesomeProject/internal/domain/model/password_reset"
+	"awesomeProject/internal/domain/model/reaction"
+	"awesomeProject/internal/domain/model/refresh_token"
+	"awesomeProject/internal/domain/model/revoked_token"
+	"awesomeProject/internal/domain/model/session"
+	"awesomeProject/internal/domain/model/upload"
+	"awesomeProject/internal/domain/model/user"
+	"awesomeProject/internal/domain/model/user_deleted"
+	"awesomeProject/internal/export"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm/schema"
+)
+
+// userColumns - колонки, по которым строка таблицы принадлежит пользователю
+var userColumns = []string{"user_id", "author_id", "actor_id", "subject_id"}
+
+// exportCoverage - каждая мигрируемая модель и экспортер, который выгружает ее строки.
+// Модель с данными пользователя без экспортера должна явно объяснить, почему ее нет в архиве.
+var exportCoverage = map[string]struct {
+	model    any
+	exporter string
+	skip     string
+}{
+	"user.User":                    {model: &user.User{}, exporter: "profile"},
+	"news.News":                    {model: &news.News{}, exporter: "news"},
+	"upload.Upload":                {model: &upload.Upload{}, exporter: "uploads"},
+	"session.Session":              {model: &session.Session{}, exporter: "login_history"},
+	"session.LoginEvent":           {model: &session.LoginEvent{}, exporter: "login_history"},
+	"comment.Comment":              {model: &comment.Comment{}, exporter: "comments"},
+	"reaction.Reaction":            {model: &reaction.Reaction{}, exporter: "reactions"},
+	"identity.Identity":            {model: &identity.Identity{}, exporter: "identities"},
+	"api_key.APIKey":               {model: &api_key.APIKey{}, exporter: "api_keys"},
+	"user_deleted.UserDeleted":     {model: &user_deleted.UserDeleted{}, skip: "архив удаленных аккаунтов, владельца уже нет"},
+	"refresh_token.RefreshToken":   {model: &refresh_token.RefreshToken{}, skip: "хеши учетных данных; активные входы выгружаются как sessions"},
+	"revoked_token.RevokedToken":   {model: &revoked_token.RevokedToken{}, skip: "служебный список отозванных токенов"},
+	"revoked_token.UserRevocation": {model: &revoked_token.UserRevocation{}, skip: "служебная отметка отзыва токенов"},
+	"password_reset.PasswordReset": {model: &password_reset.PasswordReset{}, skip: "хеши одноразовых токенов сброса пароля"},
+	"mfa.Factor":                   {model: &mfa.Factor{}, skip: "зашифрованный секрет второго фактора"},
+	"mfa.RecoveryCode":             {model: &mfa.RecoveryCode{}, skip: "хеши кодов восстановления"},
+	"mfa.UsedChallenge":            {model: &mfa.UsedChallenge{}, skip: "хеши погашенных mfa_token без владельца"},
+	"login_attempt.LoginAttempt":   {model: &login_attempt.LoginAttempt{}, skip: "счетчики блокировок без владельца"},
+	"audit.Event":                  {model: &audit.Event{}, skip: "журнал действий администраторов"},
+	"impersonation.Impersonation":  {model: &impersonation.Impersonation{}, skip: "журнал действий администраторов"},
+}
+
+// migratedModels возвращает модели, которые передаются в AutoMigrate, в виде "пакет.Тип"
+func migratedModels(t *testing.T) []string {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "database_struture.go", nil, 0)
+	if err != nil {
+		t.Fatalf("parse migrations: %v", err)
+	}
+	var models []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if sel, ok := call.Fun.(*ast.SelectorExpr); !ok || sel.Sel.Name != "AutoMigrate" {
+			return true
+		}
+		for _, arg := range call.Args {
+			lit, ok := arg.(*ast.UnaryExpr)
+			if !ok {
+				continue
+			}
+			composite, ok := lit.X.(*ast.CompositeLit)
+			if !ok {
+				continue
+			}
+			if typ, ok := composite.Type.(*ast.SelectorExpr); ok {
+				models = append(models, typ.X.(*ast.Ident).Name+"."+typ.Sel.Name)
+			}
+		}
+		return true
+	})
+	return models
+}
+
+func TestUserDataIsExported(t *testing.T) {
+	models := migratedModels(t)
+	if len(models) == 0 {
+		t.Fatal("no AutoMigrate calls found")
+	}
+	registered := export.Names()
+
+	for _, name := range models {
+		t.Run(name, func(t *testing.T) {
+			coverage, ok := exportCoverage[name]
+			if !ok {
+				t.Fatalf("%s is migrated but missing from exportCoverage: register an exporter or explain the skip", name)
+			}
+			if coverage.exporter != "" {
+				if !slices.Contains(registered, coverage.exporter) {
+					t.Errorf("exporter %q is not registered, have %v", coverage.exporter, registered)
+				}
+				return
+			}
+
+			parsed, err := schema.Parse(coverage.model, &sync.Map{}, schema.NamingStrategy{})
+			if err != nil {
+				t.Fatalf("parse schema: %v", err)
+			}
+			for _, column := range userColumns {
+				if slices.Contains(parsed.DBNames, column) && coverage.skip == "" {
+					t.Errorf("%s has column %s but no exporter", name, column)
+				}
+			}
+		})
+	}
+}
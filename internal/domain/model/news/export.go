@@ -0,0 +1,21 @@
+package news
+
+import (
+	"awesomeProject/internal/export"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	export.Register("news", export.ExporterFunc(exportNews))
+}
+
+// exportNews выгружает новости, автором которых является пользователь, в news.json
+func exportNews(ctx context.Context, db *gorm.DB, userID uint, archive *export.Archive) error {
+	news := []News{}
+	if err := db.Where("author_id = ?", userID).Order("id").Find(&news).Error; err != nil {
+		return err
+	}
+	return archive.WriteJSON("news.json", news)
+}
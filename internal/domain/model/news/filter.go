@@ -0,0 +1,131 @@
+package news
+
+import (
+	"awesomeProject/internal/pagination"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownFilter = errors.New("unknown query parameter")
+	ErrInvalidFilter = errors.New("invalid filter value")
+)
+
+// filterKeys - белый список параметров фильтрации списка новостей
+var filterKeys = map[string]bool{
+	"category":       true,
+	"author":         true,
+	"author_id":      true,
+	"created_after":  true,
+	"created_before": true,
+	"sort":           true,
+}
+
+// sortFields - поля, по которым разрешена сортировка
+var sortFields = map[string]pagination.Order{
+	"id":         {Column: "id"},
+	"title":      {Column: "title"},
+	"created_at": {Column: "created_at", Cast: "timestamptz"},
+	"updated_at": {Column: "updated_at", Cast: "timestamptz"},
+}
+
+// Filter - условия выборки списка новостей
+type Filter struct {
+	Category      string
+	Author        string
+	AuthorID      uint
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Order         pagination.Order
+}
+
+// ParseFilter разбирает query-параметры списка новостей.
+// Параметры не из белого списка (кроме параметров пагинации) считаются ошибкой.
+func ParseFilter(query url.Values) (Filter, error) {
+	for key := range query {
+		if !filterKeys[key] && !pagination.IsKey(key) {
+			return Filter{}, fmt.Errorf("%w: %s", ErrUnknownFilter, key)
+		}
+	}
+
+	f := Filter{
+		Category: query.Get("category"),
+		Author:   query.Get("author"),
+	}
+
+	if raw := query.Get("author_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			return Filter{}, fmt.Errorf("%w: author_id", ErrInvalidFilter)
+		}
+		f.AuthorID = uint(id)
+	}
+
+	var err error
+	if f.CreatedAfter, err = parseTimeParam(query, "created_after"); err != nil {
+		return Filter{}, err
+	}
+	if f.CreatedBefore, err = parseTimeParam(query, "created_before"); err != nil {
+		return Filter{}, err
+	}
+
+	if f.Order, err = pagination.ParseSort(query.Get("sort"), sortFields, pagination.Order{}); err != nil {
+		return Filter{}, err
+	}
+	return f, nil
+}
+
+// Scope переводит фильтр в условия GORM
+func (f Filter) Scope() func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if f.Category != "" {
+			db = db.Where("category = ?", f.Category)
+		}
+		if f.Author != "" {
+			db = db.Where("author = ?", f.Author)
+		}
+		if f.AuthorID != 0 {
+			db = db.Where("author_id = ?", f.AuthorID)
+		}
+		if f.CreatedAfter != nil {
+			db = db.Where("created_at >= ?", *f.CreatedAfter)
+		}
+		if f.CreatedBefore != nil {
+			db = db.Where("created_at < ?", *f.CreatedBefore)
+		}
+		return db
+	}
+}
+
+// CursorOf возвращает курсор новости для выбранной сортировки
+func (f Filter) CursorOf(n News) pagination.Cursor {
+	cursor := pagination.Cursor{ID: n.ID, Sort: f.Order.Key()}
+	switch f.Order.Column {
+	case "title":
+		cursor.Value = n.Title
+	case "created_at":
+		cursor.Value = n.CreatedAt.Format(time.RFC3339Nano)
+	case "updated_at":
+		cursor.Value = n.UpdatedAt.Format(time.RFC3339Nano)
+	}
+	return cursor
+}
+
+// parseTimeParam принимает дату в формате RFC 3339 или YYYY-MM-DD
+func parseTimeParam(query url.Values, key string) (*time.Time, error) {
+	raw := query.Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s must be RFC 3339 or YYYY-MM-DD", ErrInvalidFilter, key)
+}
@@ -7,6 +7,8 @@ import (
 	"gorm.io/gorm"
 )
 
+// News - новость. Language задает конфигурацию text search Postgres (russian, english, ...);
+// колонка, её значение по умолчанию и поисковый индекс создаются в migrate.MigrateNews.
 type News struct {
 	common.Base
 	ID          uint      `json:"id" gorm:"primaryKey"`
@@ -14,8 +16,10 @@ type News struct {
 	Description string    `json:"description" gorm:"size:255;not null"`
 	Content     string    `json:"content" gorm:"size:255;not null"`
 	Author      string    `json:"author" gorm:"size:255;not null"`
+	AuthorID    uint      `json:"author_id" gorm:"index"`
 	Category    string    `json:"category" gorm:"size:255;not null"`
 	Image       string    `json:"image" gorm:"size:255;not null"`
+	Language    string    `json:"language" gorm:"-:migration;type:regconfig;default:(-)"`
 	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 }
@@ -1,18 +1,25 @@
 package news
 
 import (
+	"awesomeProject/internal/pagination"
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+var ErrNotFound = errors.New("news not found")
+
 type Repository interface {
 	Create(news *News) error
 	FindAll() ([]News, error)
+	FindPage(params pagination.Params, filter Filter) ([]News, int64, error)
 	FindByID(id uint) (News, error)
+	Search(query SearchQuery, params pagination.Params) ([]SearchResult, int64, error)
 	Update(news *News) error
+	UpdateIfUnmodified(news *News, updatedAt time.Time) (bool, error)
 	Delete(id uint) error
 }
 
@@ -36,6 +43,21 @@ func (r *RepositoryImpl) FindAll() ([]News, error) {
 	return news, nil
 }
 
+// FindPage возвращает страницу новостей, подходящих под фильтр
+// (с одной лишней строкой, см. pagination.Scope), и общее количество таких новостей
+func (r *RepositoryImpl) FindPage(params pagination.Params, filter Filter) ([]News, int64, error) {
+	var total int64
+	if err := r.db.Model(&News{}).Scopes(filter.Scope()).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var news []News
+	if err := r.db.Scopes(filter.Scope(), params.Scope(filter.Order)).Find(&news).Error; err != nil {
+		return nil, 0, err
+	}
+	return news, total, nil
+}
+
 func (r *RepositoryImpl) FindByID(id uint) (News, error) {
 	var news News
 	log.Printf("Attempting to find news with ID: %d", id)
@@ -49,7 +71,7 @@ func (r *RepositoryImpl) FindByID(id uint) (News, error) {
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			log.Printf("News not found with ID: %d", id)
-			return news, errors.New("news not found")
+			return news, ErrNotFound
 		}
 		log.Printf("Database error while finding news: %v", result.Error)
 		return News{}, result.Error
@@ -57,8 +79,33 @@ func (r *RepositoryImpl) FindByID(id uint) (News, error) {
 	return news, nil
 }
 
+// Update сохраняет редактируемые поля новости, в том числе пустые значения
 func (r *RepositoryImpl) Update(news *News) error {
-	return r.db.Model(&News{}).Where("id = ?", news.ID).Updates(news).Error
+	return r.db.Model(&News{}).Where("id = ?", news.ID).Select(updatableColumns(news)).Updates(news).Error
+}
+
+// UpdateIfUnmodified обновляет новость, только если она не менялась с момента updatedAt.
+// Возвращает false, если новость успели изменить.
+func (r *RepositoryImpl) UpdateIfUnmodified(news *News, updatedAt time.Time) (bool, error) {
+	result := r.db.Model(&News{}).
+		Where("id = ? AND updated_at = ?", news.ID, updatedAt).
+		Select(updatableColumns(news)).
+		Updates(news)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// updatableColumns - колонки, которые записывает обновление. Без Select GORM пропускает
+// нулевые значения структуры, и поле нельзя очистить. Пустой язык не записывается:
+// он означает конфигурацию по умолчанию, и текущее значение сохраняется.
+func updatableColumns(news *News) []string {
+	columns := []string{"title", "description", "content", "category", "image", "updated_at"}
+	if news.Language != "" {
+		columns = append(columns, "language")
+	}
+	return columns
 }
 
 func (r *RepositoryImpl) Delete(id uint) error {
@@ -0,0 +1,87 @@
+package news
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRunDB возвращает GORM без подключения к базе: запросы только строятся
+// и попадают в captured, а не выполняются
+func dryRunDB(t *testing.T, captured *[]string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=test sslmode=disable"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	err = db.Callback().Update().After("gorm:update").Register("test:capture", func(tx *gorm.DB) {
+		*captured = append(*captured, tx.Statement.SQL.String())
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	return db
+}
+
+func TestUpdateWritesZeroValues(t *testing.T) {
+	tests := []struct {
+		name        string
+		news        News
+		wantColumns []string
+		skipColumns []string
+	}{
+		{
+			name:        "cleared image is written",
+			news:        News{ID: 1, Title: "t", Description: "d", Content: "c", Category: "sport", Image: ""},
+			wantColumns: []string{`"title"`, `"description"`, `"content"`, `"category"`, `"image"`, `"updated_at"`},
+			skipColumns: []string{`"language"`, `"author_id"`, `"created_at"`, `"id"=`},
+		},
+		{
+			name:        "all fields empty",
+			news:        News{ID: 2},
+			wantColumns: []string{`"title"`, `"description"`, `"content"`, `"category"`, `"image"`},
+			skipColumns: []string{`"language"`},
+		},
+		{
+			name:        "language is written when set",
+			news:        News{ID: 3, Language: "english"},
+			wantColumns: []string{`"language"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var captured []string
+			repo := NewsRepository(dryRunDB(t, &captured))
+			if err := repo.Update(&tt.news); err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			if _, err := repo.UpdateIfUnmodified(&tt.news, time.Now()); err != nil {
+				t.Fatalf("UpdateIfUnmodified: %v", err)
+			}
+			if len(captured) != 2 {
+				t.Fatalf("captured %d statements, want 2", len(captured))
+			}
+			for _, sql := range captured {
+				set := sql[:strings.Index(sql, "WHERE")]
+				for _, column := range tt.wantColumns {
+					if !strings.Contains(set, column) {
+						t.Errorf("%s: column %s is not updated", sql, column)
+					}
+				}
+				for _, column := range tt.skipColumns {
+					if strings.Contains(set, column) {
+						t.Errorf("%s: column %s must not be updated", sql, column)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package news
+
+import (
+	"awesomeProject/internal/pagination"
+	"html"
+	"strings"
+)
+
+// SearchQuery - полнотекстовый запрос. Language - конфигурация text search Postgres,
+// по правилам которой разбирается запрос (стемминг, стоп-слова).
+type SearchQuery struct {
+	Text     string
+	Language string
+}
+
+// SearchResult - найденная новость с релевантностью и фрагментом текста,
+// в котором совпадения выделены тегами <mark>. Остальной текст фрагмента экранирован
+// и может вставляться в HTML как есть.
+type SearchResult struct {
+	News
+	Rank    float64 `json:"rank" gorm:"column:rank"`
+	Snippet string  `json:"snippet" gorm:"column:snippet"`
+}
+
+// Маркеры совпадений в выводе ts_headline. Теги <mark> подставляются только после
+// экранирования текста (см. highlight), иначе HTML из новости попал бы в ответ как есть.
+const (
+	startMarker     = "{{mark}}"
+	stopMarker      = "{{/mark}}"
+	headlineOptions = `StartSel="` + startMarker + `", StopSel="` + stopMarker + `", MaxWords=35, MinWords=15, MaxFragments=2`
+)
+
+// Search ищет новости по индексу search_vector и сортирует их по ts_rank
+func (r *RepositoryImpl) Search(query SearchQuery, params pagination.Params) ([]SearchResult, int64, error) {
+	var total int64
+	if err := r.db.Raw(`
+		SELECT COUNT(*)
+		FROM news_struct
+		WHERE deleted_at IS NULL
+		  AND search_vector @@ websearch_to_tsquery(CAST(? AS regconfig), ?)
+	`, query.Language, query.Text).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var results []SearchResult
+	if err := r.db.Raw(`
+		SELECT n.*,
+		       ts_rank(n.search_vector, q.query) AS rank,
+		       ts_headline(n.language, n.description || ' ' || n.content, q.query, ?) AS snippet
+		FROM news_struct n,
+		     websearch_to_tsquery(CAST(? AS regconfig), ?) AS q(query)
+		WHERE n.deleted_at IS NULL
+		  AND n.search_vector @@ q.query
+		ORDER BY rank DESC, n.id DESC
+		LIMIT ? OFFSET ?
+	`, headlineOptions, query.Language, query.Text, params.Limit, params.Offset).Scan(&results).Error; err != nil {
+		return nil, 0, err
+	}
+	for i := range results {
+		results[i].Snippet = highlight(results[i].Snippet)
+	}
+	return results, total, nil
+}
+
+// highlight экранирует фрагмент ts_headline и заменяет маркеры совпадений тегами <mark>
+func highlight(snippet string) string {
+	return strings.NewReplacer(startMarker, "<mark>", stopMarker, "</mark>").Replace(html.EscapeString(snippet))
+}
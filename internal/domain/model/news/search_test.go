@@ -0,0 +1,40 @@
+package news
+
+import "testing"
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		want    string
+	}{
+		{
+			name:    "plain text",
+			snippet: "city {{mark}}council{{/mark}} meeting",
+			want:    "city <mark>council</mark> meeting",
+		},
+		{
+			name:    "html in content is escaped",
+			snippet: `<img src=x onerror="alert(1)"> {{mark}}news{{/mark}}`,
+			want:    `&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>news</mark>`,
+		},
+		{
+			name:    "literal mark tags are escaped",
+			snippet: "<mark>fake</mark> & {{mark}}real{{/mark}}",
+			want:    "&lt;mark&gt;fake&lt;/mark&gt; &amp; <mark>real</mark>",
+		},
+		{
+			name:    "no matches",
+			snippet: "",
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlight(tt.snippet); got != tt.want {
+				t.Errorf("highlight(%q) = %q, want %q", tt.snippet, got, tt.want)
+			}
+		})
+	}
+}
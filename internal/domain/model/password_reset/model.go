@@ -0,0 +1,24 @@
+package password_reset
+
+import (
+	"awesomeProject/internal/domain/model/common"
+	"time"
+)
+
+// PasswordReset хранит хеш одноразового токена сброса пароля
+type PasswordReset struct {
+	common.Base
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+func (PasswordReset) TableName() string {
+	return "password_resets_struct"
+}
+
+// IsUsable сообщает, можно ли еще сбросить пароль этим токеном
+func (p *PasswordReset) IsUsable(now time.Time) bool {
+	return p.UsedAt == nil && now.Before(p.ExpiresAt)
+}
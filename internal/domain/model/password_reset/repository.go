@@ -0,0 +1,70 @@
+package password_reset
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrNotFound = errors.New("password reset not found")
+
+type Repository interface {
+	Create(reset *PasswordReset) error
+	FindByHash(hash string) (PasswordReset, error)
+	MarkUsed(id uint) (bool, error)
+	InvalidateForUser(userID uint) error
+	HasUnusedSince(userID uint, since time.Time) (bool, error)
+}
+
+type RepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &RepositoryImpl{db: db}
+}
+
+func (r *RepositoryImpl) Create(reset *PasswordReset) error {
+	return r.db.Create(reset).Error
+}
+
+func (r *RepositoryImpl) FindByHash(hash string) (PasswordReset, error) {
+	var reset PasswordReset
+	result := r.db.Where("token_hash = ?", hash).First(&reset)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return reset, ErrNotFound
+		}
+		return reset, result.Error
+	}
+	return reset, nil
+}
+
+// MarkUsed помечает токен использованным. Возвращает false, если его уже использовал
+// параллельный запрос.
+func (r *RepositoryImpl) MarkUsed(id uint) (bool, error) {
+	result := r.db.Model(&PasswordReset{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// InvalidateForUser гасит все неиспользованные токены пользователя
+func (r *RepositoryImpl) InvalidateForUser(userID uint) error {
+	return r.db.Model(&PasswordReset{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
+
+// HasUnusedSince сообщает, выдан ли пользователю неиспользованный токен позже since
+func (r *RepositoryImpl) HasUnusedSince(userID uint, since time.Time) (bool, error) {
+	var count int64
+	result := r.db.Model(&PasswordReset{}).
+		Where("user_id = ? AND used_at IS NULL AND created_at > ?", userID, since).
+		Count(&count)
+	return count > 0, result.Error
+}
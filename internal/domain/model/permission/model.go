@@ -0,0 +1,46 @@
+package permission
+
+import (
+	"awesomeProject/internal/domain/model/common"
+)
+
+// Permission - право на действие над ресурсом в формате "ресурс:действие"
+type Permission struct {
+	common.Base
+	Name        string `json:"name" gorm:"type:varchar(255);not null;unique"`
+	Description string `json:"description" gorm:"type:text;not null"`
+}
+
+func (Permission) TableName() string {
+	return "permissions_struct"
+}
+
+// Известные приложению права
+const (
+	NewsRead    = "news:read"
+	NewsWrite   = "news:write"
+	NewsEditAny = "news:edit_any"
+	UsersRead   = "users:read"
+	UsersManage = "users:manage"
+	RolesManage = "roles:manage"
+
+	CommentsWrite    = "comments:write"
+	CommentsModerate = "comments:moderate"
+
+	ReactionsWrite = "reactions:write"
+)
+
+// Catalog содержит все права с описаниями, используется при сидировании
+var Catalog = map[string]string{
+	NewsRead:    "Read news",
+	NewsWrite:   "Create news and edit own articles",
+	NewsEditAny: "Edit and delete articles of other authors",
+	UsersRead:   "View other users",
+	UsersManage: "Manage user accounts",
+	RolesManage: "Manage roles and permissions",
+
+	CommentsWrite:    "Post, edit and delete own comments",
+	CommentsModerate: "Approve, reject and delete any comments",
+
+	ReactionsWrite: "React to news and remove own reactions",
+}
@@ -0,0 +1,56 @@
+package permission
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var ErrNotFound = errors.New("permission not found")
+
+type Repository interface {
+	FindAll() ([]Permission, error)
+	FindByName(name string) (Permission, error)
+	FindByNames(names []string) ([]Permission, error)
+	Create(permission *Permission) error
+}
+
+type RepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &RepositoryImpl{db: db}
+}
+
+func (r *RepositoryImpl) FindAll() ([]Permission, error) {
+	var permissions []Permission
+	if err := r.db.Order("name").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+func (r *RepositoryImpl) FindByName(name string) (Permission, error) {
+	var permission Permission
+	result := r.db.Where("name = ?", name).First(&permission)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return Permission{}, ErrNotFound
+		}
+		return Permission{}, result.Error
+	}
+	return permission, nil
+}
+
+func (r *RepositoryImpl) FindByNames(names []string) ([]Permission, error) {
+	var permissions []Permission
+	if err := r.db.Where("name IN ?", names).Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+func (r *RepositoryImpl) Create(permission *Permission) error {
+	return r.db.Create(permission).Error
+}
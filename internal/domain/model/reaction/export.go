@@ -0,0 +1,21 @@
+package reaction
+
+import (
+	"awesomeProject/internal/export"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	export.Register("reactions", export.ExporterFunc(exportReactions))
+}
+
+// exportReactions выгружает реакции пользователя на новости в reactions.json
+func exportReactions(ctx context.Context, db *gorm.DB, userID uint, archive *export.Archive) error {
+	reactions := []Reaction{}
+	if err := db.Where("user_id = ?", userID).Order("id").Find(&reactions).Error; err != nil {
+		return err
+	}
+	return archive.WriteJSON("reactions.json", reactions)
+}
@@ -0,0 +1,44 @@
+package reaction
+
+import (
+	"time"
+)
+
+// Типы реакций на новость
+const (
+	Like  = "like"
+	Love  = "love"
+	Laugh = "laugh"
+	Wow   = "wow"
+	Sad   = "sad"
+	Angry = "angry"
+)
+
+// Types перечисляет допустимые типы реакций
+var Types = []string{Like, Love, Laugh, Wow, Sad, Angry}
+
+// Reaction - реакция пользователя на новость. У пользователя может быть только одна
+// реакция на статью, поэтому смена реакции перезаписывает запись, а снятие удаляет её
+// физически (без soft delete, иначе уникальный индекс не даст поставить реакцию снова).
+type Reaction struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_reactions_user_news"`
+	NewsID    uint      `json:"news_id" gorm:"not null;uniqueIndex:idx_reactions_user_news;index"`
+	Type      string    `json:"type" gorm:"size:20;not null"`
+}
+
+func (Reaction) TableName() string {
+	return "reactions_struct"
+}
+
+// IsValidType проверяет, что тип реакции известен
+func IsValidType(reactionType string) bool {
+	for _, t := range Types {
+		if t == reactionType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package reaction
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	Upsert(reaction *Reaction) error
+	Delete(userID, newsID uint) (bool, error)
+	DeleteByNews(newsID uint) error
+	CountsByNewsIDs(ctx context.Context, newsIDs []uint) (map[uint]map[string]int64, error)
+	TypesByUser(ctx context.Context, userID uint, newsIDs []uint) (map[uint]string, error)
+}
+
+type RepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &RepositoryImpl{db: db}
+}
+
+// Upsert ставит реакцию или меняет тип уже поставленной
+func (r *RepositoryImpl) Upsert(reaction *Reaction) error {
+	now := time.Now()
+	reaction.CreatedAt = now
+	reaction.UpdatedAt = now
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "news_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"type", "updated_at"}),
+	}).Create(reaction).Error
+}
+
+// Delete снимает реакцию пользователя; false означает, что реакции не было
+func (r *RepositoryImpl) Delete(userID, newsID uint) (bool, error) {
+	result := r.db.Where("user_id = ? AND news_id = ?", userID, newsID).Delete(&Reaction{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteByNews снимает все реакции на новость, например вместе с самой новостью
+func (r *RepositoryImpl) DeleteByNews(newsID uint) error {
+	return r.db.Where("news_id = ?", newsID).Delete(&Reaction{}).Error
+}
+
+// CountsByNewsIDs одним запросом считает реакции каждого типа для списка новостей
+func (r *RepositoryImpl) CountsByNewsIDs(ctx context.Context, newsIDs []uint) (map[uint]map[string]int64, error) {
+	counts := make(map[uint]map[string]int64, len(newsIDs))
+	if len(newsIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		NewsID uint
+		Type   string
+		Count  int64
+	}
+	if err := r.db.WithContext(ctx).Model(&Reaction{}).
+		Select("news_id, type, COUNT(*) AS count").
+		Where("news_id IN ?", newsIDs).
+		Group("news_id, type").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		if counts[row.NewsID] == nil {
+			counts[row.NewsID] = make(map[string]int64)
+		}
+		counts[row.NewsID][row.Type] = row.Count
+	}
+	return counts, nil
+}
+
+// TypesByUser возвращает реакции пользователя на новости из списка
+func (r *RepositoryImpl) TypesByUser(ctx context.Context, userID uint, newsIDs []uint) (map[uint]string, error) {
+	types := make(map[uint]string)
+	if len(newsIDs) == 0 {
+		return types, nil
+	}
+
+	var reactions []Reaction
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND news_id IN ?", userID, newsIDs).
+		Find(&reactions).Error; err != nil {
+		return nil, err
+	}
+
+	for _, reaction := range reactions {
+		types[reaction.NewsID] = reaction.Type
+	}
+	return types, nil
+}
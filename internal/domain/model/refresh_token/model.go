@@ -0,0 +1,27 @@
+package refresh_token
+
+import (
+	"awesomeProject/internal/domain/model/common"
+	"time"
+)
+
+// RefreshToken хранит хеш выданного refresh-токена.
+// Все токены, полученные ротацией от одного логина, имеют общий FamilyID.
+type RefreshToken struct {
+	common.Base
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	FamilyID  string     `json:"family_id" gorm:"size:64;not null;index"`
+	TokenHash string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	RotatedAt *time.Time `json:"rotated_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens_struct"
+}
+
+// IsActive сообщает, можно ли использовать токен для обновления
+func (t *RefreshToken) IsActive(now time.Time) bool {
+	return t.RotatedAt == nil && t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,68 @@
+package refresh_token
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrNotFound = errors.New("refresh token not found")
+
+type Repository interface {
+	Create(token *RefreshToken) error
+	FindByHash(hash string) (RefreshToken, error)
+	MarkRotated(id uint) (bool, error)
+	RevokeFamily(familyID string) error
+	RevokeAllForUser(userID uint) error
+}
+
+type RepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &RepositoryImpl{db: db}
+}
+
+func (r *RepositoryImpl) Create(token *RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *RepositoryImpl) FindByHash(hash string) (RefreshToken, error) {
+	var token RefreshToken
+	result := r.db.Where("token_hash = ?", hash).First(&token)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return token, ErrNotFound
+		}
+		return token, result.Error
+	}
+	return token, nil
+}
+
+// MarkRotated помечает токен использованным. Возвращает false, если токен
+// уже был использован или отозван параллельным запросом.
+func (r *RepositoryImpl) MarkRotated(id uint) (bool, error) {
+	result := r.db.Model(&RefreshToken{}).
+		Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", id).
+		Update("rotated_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeFamily отзывает все токены, выданные в рамках одного логина
+func (r *RepositoryImpl) RevokeFamily(familyID string) error {
+	return r.db.Model(&RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAllForUser отзывает все refresh-токены пользователя
+func (r *RepositoryImpl) RevokeAllForUser(userID uint) error {
+	return r.db.Model(&RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
@@ -0,0 +1,30 @@
+package revoked_token
+
+import (
+	"awesomeProject/internal/domain/model/common"
+	"time"
+)
+
+// RevokedToken - отозванный access-токен, идентифицируемый по claim jti.
+// Запись нужна только до истечения срока действия самого токена.
+type RevokedToken struct {
+	common.Base
+	JTI       string    `json:"jti" gorm:"size:64;not null;uniqueIndex"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+}
+
+func (RevokedToken) TableName() string {
+	return "revoked_tokens_struct"
+}
+
+// UserRevocation отзывает сразу все токены пользователя, выданные раньше RevokedBefore
+type UserRevocation struct {
+	common.Base
+	UserID        uint      `json:"user_id" gorm:"not null;uniqueIndex"`
+	RevokedBefore time.Time `json:"revoked_before" gorm:"not null"`
+}
+
+func (UserRevocation) TableName() string {
+	return "user_revocations_struct"
+}
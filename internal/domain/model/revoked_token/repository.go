@@ -0,0 +1,60 @@
+package revoked_token
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	Create(token *RevokedToken) error
+	ExistsByJTI(jti string) (bool, error)
+	RevokeAllBefore(userID uint, before time.Time) error
+	FindRevokedBefore(userID uint) (time.Time, error)
+}
+
+type RepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &RepositoryImpl{db: db}
+}
+
+func (r *RepositoryImpl) Create(token *RevokedToken) error {
+	// Повторный logout тем же токеном не считается ошибкой
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "jti"}},
+		DoNothing: true,
+	}).Create(token).Error
+}
+
+func (r *RepositoryImpl) ExistsByJTI(jti string) (bool, error) {
+	var count int64
+	if err := r.db.Model(&RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *RepositoryImpl) RevokeAllBefore(userID uint, before time.Time) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revoked_before", "updated_at"}),
+	}).Create(&UserRevocation{UserID: userID, RevokedBefore: before}).Error
+}
+
+// FindRevokedBefore возвращает нулевое время, если токены пользователя не отзывались
+func (r *RepositoryImpl) FindRevokedBefore(userID uint) (time.Time, error) {
+	var revocation UserRevocation
+	result := r.db.Where("user_id = ?", userID).First(&revocation)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, result.Error
+	}
+	return revocation.RevokedBefore, nil
+}
@@ -3,20 +3,37 @@ package role
 import (
 	"gorm.io/gorm"
 	"awesomeProject/internal/domain/model/common"
-	"awesomeProject/internal/domain/model/user"
+	"awesomeProject/internal/domain/model/permission"
+)
+
+// Встроенные роли. Имя роли хранится в поле user.User.Role
+const (
+	Admin  = "admin"
+	Editor = "editor"
+	Reader = "user"
 )
 
 type Role struct {
 	common.Base
 	RoleName string `json:"role_name" gorm:"type:varchar(255);not null;unique"`
-	User user.User `json:"user" gorm:"foreignKey:RoleName"`
 	Description string `json:"description" gorm:"type:text;not null"`
+	RequireMFA bool `json:"require_mfa" gorm:"not null;default:false"`
+	Permissions []permission.Permission `json:"permissions" gorm:"many2many:role_permissions_struct;joinForeignKey:RoleID;joinReferences:PermissionID"`
 }
 
 func (Role) TableName() string {
 	return "roles_struct"
 }
 
+// PermissionNames возвращает имена прав роли
+func (u *Role) PermissionNames() []string {
+	names := make([]string, 0, len(u.Permissions))
+	for _, p := range u.Permissions {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
 func (u *Role) BeforeSave(tx *gorm.DB) error {
 	return nil
 }
@@ -1,14 +1,23 @@
 package role
 
 import (
+	"awesomeProject/internal/domain/model/permission"
+	"errors"
+
 	"gorm.io/gorm"
 )
 
+var ErrNotFound = errors.New("role not found")
+
 type Repository interface {
+	FindAll() ([]Role, error)
 	FindByID(id uint) (Role, error)
+	FindByName(name string) (Role, error)
 	Create(role *Role) error
 	Update(role *Role) error
 	Delete(id uint) error
+	AddPermission(role *Role, p *permission.Permission) error
+	RemovePermission(role *Role, p *permission.Permission) error
 }
 
 type RepositoryImpl struct {
@@ -19,10 +28,35 @@ func NewRepository(db *gorm.DB) Repository {
 	return &RepositoryImpl{db: db}
 }
 
+func (r *RepositoryImpl) FindAll() ([]Role, error) {
+	var roles []Role
+	if err := r.db.Preload("Permissions").Order("id").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
 func (r *RepositoryImpl) FindByID(id uint) (Role, error) {
 	var role Role
-	if err := r.db.First(&role, id).Error; err != nil {
-		return Role{}, err
+	result := r.db.Preload("Permissions").First(&role, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return Role{}, ErrNotFound
+		}
+		return Role{}, result.Error
+	}
+	return role, nil
+}
+
+// FindByName загружает роль вместе с её правами
+func (r *RepositoryImpl) FindByName(name string) (Role, error) {
+	var role Role
+	result := r.db.Preload("Permissions").Where("role_name = ?", name).First(&role)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return Role{}, ErrNotFound
+		}
+		return Role{}, result.Error
 	}
 	return role, nil
 }
@@ -32,9 +66,17 @@ func (r *RepositoryImpl) Create(role *Role) error {
 }
 
 func (r *RepositoryImpl) Update(role *Role) error {
-	return r.db.Save(role).Error
+	return r.db.Omit("Permissions").Save(role).Error
 }
 
 func (r *RepositoryImpl) Delete(id uint) error {
 	return r.db.Delete(&Role{}, id).Error
-}
\ No newline at end of file
+}
+
+func (r *RepositoryImpl) AddPermission(role *Role, p *permission.Permission) error {
+	return r.db.Model(role).Association("Permissions").Append(p)
+}
+
+func (r *RepositoryImpl) RemovePermission(role *Role, p *permission.Permission) error {
+	return r.db.Model(role).Association("Permissions").Delete(p)
+}
@@ -3,6 +3,7 @@ package seeder
 import (
 	"awesomeProject/internal/database"
 	"awesomeProject/internal/domain/model/news"
+	"awesomeProject/internal/domain/model/permission"
 	"awesomeProject/internal/domain/model/role"
 	"awesomeProject/internal/domain/model/upload"
 	"awesomeProject/internal/domain/model/user"
@@ -28,13 +29,22 @@ func SeedUsers(count int) {
 		go func(index int) {
 			defer wg.Done()
 
+			// Первые два пользователя получают роли администратора и редактора
+			userRole := role.Reader
+			switch index {
+			case 0:
+				userRole = role.Admin
+			case 1:
+				userRole = role.Editor
+			}
+
 			// Создаем нового пользователя
 			users[index] = user.User{
 				Name:       faker.Name(),
 				Age:        int(faker.RandomUnixTime() % 100),
 				City:       faker.Word(),
 				Email:      fmt.Sprintf("user%d@example.com", index+1),
-				Role:       "user",
+				Role:       userRole,
 				IsActive:   true,
 				IsVerified: true,
 				IsDeleted:  false,
@@ -70,19 +80,47 @@ func SeedUsers(count int) {
 	log.Printf("Successfully created users")
 }
 
-func SeedRoles(count int) {
-	log.Printf("Creating %d additional roles...", count)
+// DefaultRolePermissions описывает встроенные роли и их права
+var DefaultRolePermissions = map[string][]string{
+	role.Admin: {
+		permission.NewsRead, permission.NewsWrite, permission.NewsEditAny,
+		permission.UsersRead, permission.UsersManage, permission.RolesManage,
+		permission.CommentsWrite, permission.CommentsModerate, permission.ReactionsWrite,
+	},
+	role.Editor: {permission.NewsRead, permission.NewsWrite, permission.CommentsWrite, permission.CommentsModerate, permission.ReactionsWrite},
+	role.Reader: {permission.NewsRead, permission.CommentsWrite, permission.ReactionsWrite},
+}
 
-	roles := make([]role.Role, count)
+func SeedPermissions() {
+	log.Printf("Creating %d permissions...", len(permission.Catalog))
 
-	for i := 0; i < count; i++ {
-		roles[i] = role.Role{
-			RoleName:    fmt.Sprintf("role%d", i+1),
-			Description: faker.Sentence(),
+	for name, description := range permission.Catalog {
+		p := permission.Permission{Name: name, Description: description}
+		if err := database.DB.Create(&p).Error; err != nil {
+			log.Printf("Failed to create permission %s: %v", name, err)
+		}
+	}
+
+	log.Printf("Successfully created permissions")
+}
+
+func SeedRoles() {
+	log.Printf("Creating %d roles...", len(DefaultRolePermissions))
+
+	for name, names := range DefaultRolePermissions {
+		var permissions []permission.Permission
+		if err := database.DB.Where("name IN ?", names).Find(&permissions).Error; err != nil {
+			log.Printf("Failed to load permissions for role %s: %v", name, err)
+			continue
 		}
 
-		if err := database.DB.Create(&roles[i]).Error; err != nil {
-			log.Printf("Failed to create role %s: %v", roles[i].RoleName, err)
+		r := role.Role{
+			RoleName:    name,
+			Description: faker.Sentence(),
+			Permissions: permissions,
+		}
+		if err := database.DB.Create(&r).Error; err != nil {
+			log.Printf("Failed to create role %s: %v", r.RoleName, err)
 		}
 	}
 
@@ -160,6 +198,7 @@ func SeedUserDeleted(count int) {
 		userDeleted[i] = user_deleted.UserDeleted{
 			UserID:    userID,
 			DeletedAt: time.Now(),
+			Email:     fmt.Sprintf("deleted_user_%d@example.com", i+1),
 			User: user.User{
 				Name:          faker.Name(),
 				Age:           int(faker.RandomUnixTime() % 100),
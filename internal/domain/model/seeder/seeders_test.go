@@ -0,0 +1,44 @@
+package seeder
+
+import (
+	"awesomeProject/internal/domain/model/permission"
+	"awesomeProject/internal/domain/model/role"
+	"slices"
+	"testing"
+)
+
+func TestDefaultRolePermissions(t *testing.T) {
+	tests := []struct {
+		role string
+		want []string
+		deny []string
+	}{
+		{role: role.Admin, want: []string{permission.RolesManage, permission.UsersManage, permission.ReactionsWrite}},
+		{role: role.Editor, want: []string{permission.NewsWrite, permission.CommentsWrite, permission.ReactionsWrite}, deny: []string{permission.UsersManage}},
+		{role: role.Reader, want: []string{permission.NewsRead, permission.CommentsWrite, permission.ReactionsWrite}, deny: []string{permission.NewsWrite}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role, func(t *testing.T) {
+			granted, ok := DefaultRolePermissions[tt.role]
+			if !ok {
+				t.Fatalf("role %s is not seeded", tt.role)
+			}
+			for _, name := range granted {
+				if _, known := permission.Catalog[name]; !known {
+					t.Errorf("%s is granted but missing from permission.Catalog", name)
+				}
+			}
+			for _, name := range tt.want {
+				if !slices.Contains(granted, name) {
+					t.Errorf("%s is not granted", name)
+				}
+			}
+			for _, name := range tt.deny {
+				if slices.Contains(granted, name) {
+					t.Errorf("%s must not be granted", name)
+				}
+			}
+		})
+	}
+}
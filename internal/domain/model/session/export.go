@@ -0,0 +1,29 @@
+package session
+
+import (
+	"awesomeProject/internal/export"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	export.Register("login_history", export.ExporterFunc(exportLoginHistory))
+}
+
+// exportLoginHistory выгружает историю входов в login_history.json и сессии в sessions.json
+func exportLoginHistory(ctx context.Context, db *gorm.DB, userID uint, archive *export.Archive) error {
+	events := []LoginEvent{}
+	if err := db.Where("user_id = ?", userID).Order("id").Find(&events).Error; err != nil {
+		return err
+	}
+	if err := archive.WriteJSON("login_history.json", events); err != nil {
+		return err
+	}
+
+	sessions := []Session{}
+	if err := db.Where("user_id = ?", userID).Order("id").Find(&sessions).Error; err != nil {
+		return err
+	}
+	return archive.WriteJSON("sessions.json", sessions)
+}
@@ -0,0 +1,57 @@
+package session
+
+import (
+	"awesomeProject/internal/domain/model/common"
+	"time"
+)
+
+// Причины неудачного входа
+const (
+	FailureUnknownEmail  = "unknown_email"
+	FailureWrongPassword = "wrong_password"
+	FailureLocked        = "locked"
+	FailureDisabled      = "account_disabled"
+	FailureNotVerified   = "email_not_verified"
+	FailureMFAPending    = "mfa_pending"
+	FailureInvalidMFA    = "invalid_mfa_code"
+)
+
+// Session - сессия пользователя, открытая одним логином. FamilyID совпадает с семейством
+// refresh-токенов и с claim sid, поэтому сессия живет, пока живут её refresh-токены.
+type Session struct {
+	common.Base
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	FamilyID   string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	IP         string     `json:"ip" gorm:"size:64"`
+	UserAgent  string     `json:"user_agent" gorm:"size:512"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+func (Session) TableName() string {
+	return "sessions_struct"
+}
+
+// LoginEvent - попытка входа. Для неизвестного email UserID пустой.
+type LoginEvent struct {
+	ID            uint      `json:"id" gorm:"primarykey"`
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
+	UserID        *uint     `json:"user_id" gorm:"index"`
+	Email         string    `json:"email" gorm:"size:255;not null"`
+	IP            string    `json:"ip" gorm:"size:64"`
+	UserAgent     string    `json:"user_agent" gorm:"size:512"`
+	Success       bool      `json:"success" gorm:"not null"`
+	FailureReason string    `json:"failure_reason,omitempty" gorm:"size:50"`
+}
+
+func (LoginEvent) TableName() string {
+	return "login_events_struct"
+}
+
+// Stats - сводка по входам и сессиям пользователя
+type Stats struct {
+	LastLoginAt    *time.Time
+	LoginCount     int64
+	ActiveSessions int64
+}
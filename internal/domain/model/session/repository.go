@@ -0,0 +1,135 @@
+package session
+
+import (
+	"awesomeProject/internal/pagination"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(session *Session) error
+	Touch(familyID string, expiresAt time.Time) error
+	RevokeFamily(familyID string) error
+	RevokeAllForUser(userID uint) error
+	CreateLoginEvent(event *LoginEvent) error
+	FindLoginEventsPage(userID uint, params pagination.Params) ([]LoginEvent, int64, error)
+	StatsByUserIDs(ctx context.Context, userIDs []uint) (map[uint]Stats, error)
+	AnonymizeForUser(userID uint) error
+}
+
+type RepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &RepositoryImpl{db: db}
+}
+
+func (r *RepositoryImpl) Create(session *Session) error {
+	return r.db.Create(session).Error
+}
+
+// Touch продлевает сессию при ротации refresh-токена
+func (r *RepositoryImpl) Touch(familyID string, expiresAt time.Time) error {
+	return r.db.Model(&Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Updates(map[string]interface{}{
+			"last_seen_at": time.Now(),
+			"expires_at":   expiresAt,
+		}).Error
+}
+
+// RevokeFamily закрывает сессию одного логина
+func (r *RepositoryImpl) RevokeFamily(familyID string) error {
+	return r.db.Model(&Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAllForUser закрывает все сессии пользователя
+func (r *RepositoryImpl) RevokeAllForUser(userID uint) error {
+	return r.db.Model(&Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *RepositoryImpl) CreateLoginEvent(event *LoginEvent) error {
+	return r.db.Create(event).Error
+}
+
+// LoginEventsOrder - порядок страниц истории входов: новые события первыми
+var LoginEventsOrder = pagination.Order{Desc: true}
+
+// FindLoginEventsPage возвращает историю входов пользователя, новые события первыми
+func (r *RepositoryImpl) FindLoginEventsPage(userID uint, params pagination.Params) ([]LoginEvent, int64, error) {
+	var total int64
+	if err := r.db.Model(&LoginEvent{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []LoginEvent
+	result := r.db.Where("user_id = ?", userID).
+		Scopes(params.Scope(LoginEventsOrder)).
+		Find(&events)
+	return events, total, result.Error
+}
+
+// StatsByUserIDs одним запросом считает для каждого пользователя время последнего
+// успешного входа, количество успешных входов и число активных сессий
+func (r *RepositoryImpl) StatsByUserIDs(ctx context.Context, userIDs []uint) (map[uint]Stats, error) {
+	stats := make(map[uint]Stats, len(userIDs))
+	if len(userIDs) == 0 {
+		return stats, nil
+	}
+
+	var rows []struct {
+		UserID         uint
+		LastLoginAt    *time.Time
+		LoginCount     int64
+		ActiveSessions int64
+	}
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT COALESCE(l.user_id, s.user_id) AS user_id,
+			l.last_login_at,
+			COALESCE(l.login_count, 0) AS login_count,
+			COALESCE(s.active_sessions, 0) AS active_sessions
+		FROM (
+			SELECT user_id, MAX(created_at) AS last_login_at, COUNT(*) AS login_count
+			FROM login_events_struct
+			WHERE success AND user_id IN ?
+			GROUP BY user_id
+		) l
+		FULL JOIN (
+			SELECT user_id, COUNT(*) AS active_sessions
+			FROM sessions_struct
+			WHERE revoked_at IS NULL AND expires_at > ? AND deleted_at IS NULL AND user_id IN ?
+			GROUP BY user_id
+		) s ON s.user_id = l.user_id`,
+		userIDs, time.Now(), userIDs).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		stats[row.UserID] = Stats{
+			LastLoginAt:    row.LastLoginAt,
+			LoginCount:     row.LoginCount,
+			ActiveSessions: row.ActiveSessions,
+		}
+	}
+	return stats, nil
+}
+
+// AnonymizeForUser стирает IP и user agent из сессий и истории входов пользователя
+func (r *RepositoryImpl) AnonymizeForUser(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Session{}).Where("user_id = ?", userID).
+			Updates(map[string]interface{}{"ip": "", "user_agent": ""}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&LoginEvent{}).Where("user_id = ?", userID).
+			Updates(map[string]interface{}{"ip": "", "user_agent": "", "email": ""}).Error
+	})
+}
@@ -0,0 +1,55 @@
+package upload
+
+import (
+	"awesomeProject/internal/export"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	export.Register("uploads", export.ExporterFunc(exportUploads))
+}
+
+// exportedUpload - метаданные загрузки в выгрузке. ArchivePath указывает на копию файла
+// внутри архива и пуст, если файла на диске уже нет.
+type exportedUpload struct {
+	Upload
+	ArchivePath string `json:"archive_path,omitempty"`
+}
+
+// exportUploads выгружает метаданные загрузок пользователя в uploads.json, а сами файлы - в каталог uploads/.
+// Архивы прошлых выгрузок в новую выгрузку не попадают.
+func exportUploads(ctx context.Context, db *gorm.DB, userID uint, archive *export.Archive) error {
+	uploads, err := NewRepository(db).FindByUser(userID, "")
+	if err != nil {
+		return err
+	}
+
+	exported := []exportedUpload{}
+	for _, u := range uploads {
+		if u.Type == TypeExport {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		item := exportedUpload{Upload: u}
+		if u.Path != "" {
+			name := fmt.Sprintf("uploads/%d-%s", u.ID, filepath.Base(u.Path))
+			err := archive.CopyFile(name, u.Path)
+			switch {
+			case err == nil:
+				item.ArchivePath = name
+			case !errors.Is(err, fs.ErrNotExist):
+				return err
+			}
+		}
+		exported = append(exported, item)
+	}
+	return archive.WriteJSON("uploads.json", exported)
+}
@@ -2,26 +2,39 @@ package upload
 
 import (
 	"awesomeProject/internal/domain/model/common"
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// TypeExport - тип загрузки с архивом выгрузки персональных данных
+const TypeExport = "export"
+
+// Upload - загруженный файл. Загрузки с ExpiresAt (например, архивы выгрузки данных)
+// удаляются после истечения срока.
 type Upload struct {
 	common.Base
-	ID          uint   `json:"id" gorm:"primaryKey"`
-	UserID      uint   `json:"user_id" gorm:"not null"`
-	Title       string `json:"title" gorm:"size:255;not null"`
-	Author      string `json:"author" gorm:"size:255;not null"`
-	File        string `json:"file" gorm:"size:255;not null"`
-	Description string `json:"description" gorm:"size:255;not null"`
-	Content     string `json:"content" gorm:"size:255;not null"`
-	Type        string `json:"type" gorm:"size:255;not null"`
-	Path        string `json:"path" gorm:"size:255;not null"`
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"user_id" gorm:"not null"`
+	Title       string     `json:"title" gorm:"size:255;not null"`
+	Author      string     `json:"author" gorm:"size:255;not null"`
+	File        string     `json:"file" gorm:"size:255;not null"`
+	Description string     `json:"description" gorm:"size:255;not null"`
+	Content     string     `json:"content" gorm:"size:255;not null"`
+	Type        string     `json:"type" gorm:"size:255;not null"`
+	Path        string     `json:"path" gorm:"size:255;not null"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" gorm:"index"`
 }
 
 func (Upload) TableName() string {
 	return "uploads_struct"
 }
 
+// IsExpired сообщает, истек ли срок хранения загрузки
+func (u Upload) IsExpired(now time.Time) bool {
+	return u.ExpiresAt != nil && !u.ExpiresAt.After(now)
+}
+
 func (u *Upload) BeforeSave(tx *gorm.DB) error {
 	return nil
 }
@@ -37,4 +50,3 @@ func (u *Upload) BeforeUpdate(tx *gorm.DB) error {
 func (u *Upload) BeforeDelete(tx *gorm.DB) error {
 	return nil
 }
-
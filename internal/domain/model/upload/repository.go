@@ -1,13 +1,22 @@
 package upload
 
 import (
+	"awesomeProject/internal/pagination"
+	"errors"
+	"time"
+
 	"gorm.io/gorm"
 )
 
+var ErrNotFound = errors.New("upload not found")
+
 type Repository interface {
 	Create(upload *Upload) error
 	FindAll() ([]Upload, error)
+	FindPage(params pagination.Params) ([]Upload, int64, error)
 	FindByID(id uint) (Upload, error)
+	FindByUser(userID uint, uploadType string) ([]Upload, error)
+	FindExpired(now time.Time, limit int) ([]Upload, error)
 	Update(upload *Upload) error
 	Delete(id uint) error
 }
@@ -16,6 +25,10 @@ type RepositoryImpl struct {
 	db *gorm.DB
 }
 
+func NewRepository(db *gorm.DB) Repository {
+	return &RepositoryImpl{db: db}
+}
+
 func (r *RepositoryImpl) Create(upload *Upload) error {
 	return r.db.Create(upload).Error
 }
@@ -28,14 +41,53 @@ func (r *RepositoryImpl) FindAll() ([]Upload, error) {
 	return uploads, nil
 }
 
-func (r *RepositoryImpl) FindById(id uint) (Upload, error) {
+// FindPage возвращает страницу загрузок (с одной лишней строкой, см. pagination.Scope) и общее количество
+func (r *RepositoryImpl) FindPage(params pagination.Params) ([]Upload, int64, error) {
+	var total int64
+	if err := r.db.Model(&Upload{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var uploads []Upload
+	if err := r.db.Scopes(params.Scope(pagination.Order{})).Find(&uploads).Error; err != nil {
+		return nil, 0, err
+	}
+	return uploads, total, nil
+}
+
+func (r *RepositoryImpl) FindByID(id uint) (Upload, error) {
 	var upload Upload
 	if err := r.db.First(&upload, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Upload{}, ErrNotFound
+		}
 		return Upload{}, err
 	}
 	return upload, nil
 }
 
+// FindByUser возвращает загрузки пользователя, новые первыми. Пустой uploadType - загрузки любого типа.
+func (r *RepositoryImpl) FindByUser(userID uint, uploadType string) ([]Upload, error) {
+	query := r.db.Where("user_id = ?", userID)
+	if uploadType != "" {
+		query = query.Where("type = ?", uploadType)
+	}
+	var uploads []Upload
+	if err := query.Order("id DESC").Find(&uploads).Error; err != nil {
+		return nil, err
+	}
+	return uploads, nil
+}
+
+// FindExpired возвращает до limit загрузок, срок хранения которых истек к now
+func (r *RepositoryImpl) FindExpired(now time.Time, limit int) ([]Upload, error) {
+	var uploads []Upload
+	if err := r.db.Where("expires_at <= ?", now).Order("expires_at").Limit(limit).Find(&uploads).Error; err != nil {
+		return nil, err
+	}
+	return uploads, nil
+}
+
 func (r *RepositoryImpl) Update(upload *Upload) error {
 	return r.db.Model(&Upload{}).Where("id = ?", upload.ID).Updates(upload).Error
 }
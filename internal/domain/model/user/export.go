@@ -0,0 +1,22 @@
+package user
+
+import (
+	"awesomeProject/internal/export"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	export.Register("profile", export.ExporterFunc(exportProfile))
+}
+
+// exportProfile выгружает профиль пользователя в profile.json
+func exportProfile(ctx context.Context, db *gorm.DB, userID uint, archive *export.Archive) error {
+	u, err := NewRepository(db).FindByID(userID)
+	if err != nil {
+		return err
+	}
+	u.RefreshToken = ""
+	return archive.WriteJSON("profile.json", u)
+}
@@ -0,0 +1,128 @@
+package user
+
+import (
+	"awesomeProject/internal/pagination"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownFilter = errors.New("unknown query parameter")
+	ErrInvalidFilter = errors.New("invalid filter value")
+)
+
+// filterKeys - белый список параметров фильтрации списка пользователей
+var filterKeys = map[string]bool{
+	"q":           true,
+	"role":        true,
+	"is_active":   true,
+	"is_verified": true,
+	"sort":        true,
+}
+
+// sortFields - поля, по которым разрешена сортировка
+var sortFields = map[string]pagination.Order{
+	"id":         {Column: "id"},
+	"name":       {Column: "name"},
+	"email":      {Column: "email"},
+	"city":       {Column: "city"},
+	"created_at": {Column: "created_at", Cast: "timestamptz"},
+}
+
+// Filter - условия выборки списка пользователей. Query ищет подстроку
+// в имени, email и городе без учета регистра.
+type Filter struct {
+	Query      string
+	Role       string
+	IsActive   *bool
+	IsVerified *bool
+	Order      pagination.Order
+}
+
+// ParseFilter разбирает query-параметры списка пользователей.
+// Параметры не из белого списка (кроме параметров пагинации) считаются ошибкой.
+func ParseFilter(query url.Values) (Filter, error) {
+	for key := range query {
+		if !filterKeys[key] && !pagination.IsKey(key) {
+			return Filter{}, fmt.Errorf("%w: %s", ErrUnknownFilter, key)
+		}
+	}
+
+	f := Filter{
+		Query: strings.TrimSpace(query.Get("q")),
+		Role:  query.Get("role"),
+	}
+
+	var err error
+	if f.IsActive, err = parseBoolParam(query, "is_active"); err != nil {
+		return Filter{}, err
+	}
+	if f.IsVerified, err = parseBoolParam(query, "is_verified"); err != nil {
+		return Filter{}, err
+	}
+
+	if f.Order, err = pagination.ParseSort(query.Get("sort"), sortFields, pagination.Order{}); err != nil {
+		return Filter{}, err
+	}
+	return f, nil
+}
+
+// Scope переводит фильтр в условия GORM
+func (f Filter) Scope() func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if f.Query != "" {
+			pattern := "%" + escapeLike(f.Query) + "%"
+			db = db.Where("(name ILIKE ? OR email ILIKE ? OR city ILIKE ?)", pattern, pattern, pattern)
+		}
+		if f.Role != "" {
+			db = db.Where("role = ?", f.Role)
+		}
+		if f.IsActive != nil {
+			db = db.Where("is_active = ?", *f.IsActive)
+		}
+		if f.IsVerified != nil {
+			db = db.Where("is_verified = ?", *f.IsVerified)
+		}
+		return db
+	}
+}
+
+// CursorOf возвращает курсор пользователя для выбранной сортировки
+func (f Filter) CursorOf(u User) pagination.Cursor {
+	cursor := pagination.Cursor{ID: u.ID, Sort: f.Order.Key()}
+	switch f.Order.Column {
+	case "name":
+		cursor.Value = u.Name
+	case "email":
+		cursor.Value = u.Email
+	case "city":
+		cursor.Value = u.City
+	case "created_at":
+		cursor.Value = u.CreatedAt.Format(time.RFC3339Nano)
+	}
+	return cursor
+}
+
+// parseBoolParam принимает true/false, 1/0 и другие значения strconv.ParseBool
+func parseBoolParam(query url.Values, key string) (*bool, error) {
+	raw := query.Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be true or false", ErrInvalidFilter, key)
+	}
+	return &value, nil
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE, чтобы они искались как обычные символы
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
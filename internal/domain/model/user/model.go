@@ -23,6 +23,8 @@ type User struct {
 	IsVerified    bool      `json:"is_verified" gorm:"null" gorm:"default:false"`
 	IsVerified_at time.Time `json:"is_verified_at" gorm:"null" gorm:"default:false"`
 	IsDeleted     bool      `json:"is_deleted" gorm:"null" gorm:"default:false"`
+	// VerificationSentAt - когда отправлено последнее письмо подтверждения; ограничивает повторные письма
+	VerificationSentAt *time.Time `json:"-"`
 }
 
 // TableName указывает имя таблицы в базе данных
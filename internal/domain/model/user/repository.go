@@ -1,20 +1,38 @@
 package user
 
 import (
+	"awesomeProject/internal/database"
+	"awesomeProject/internal/pagination"
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNotFound    = errors.New("user not found")
+	ErrEmailExists = errors.New("user with this email already exists")
 )
 
 type Repository interface {
 	FindByEmail(email string) (User, error)
 	FindByID(id uint) (User, error)
 	FindAll() ([]User, error)
+	FindPage(params pagination.Params, filter Filter) ([]User, int64, error)
 	Create(user *User) error
 	UpdateToken(id uint, token string) error
 	UpdateRefreshToken(id uint, refreshToken string) error
+	UpdateRole(id uint, role string) error
+	MarkVerified(id uint, at time.Time) error
+	ClaimVerificationSend(id uint, now time.Time, interval time.Duration) (bool, error)
+	UpdatePassword(id uint, hashedPassword string) error
+	UpdateFields(id uint, fields map[string]interface{}) error
+	CountByRole(role string) (int64, error)
+	LockIDsByRole(role string) ([]uint, error)
+	FindIDsByRole(role string) ([]uint, error)
 }
 
 type RepositoryImpl struct {
@@ -30,7 +48,7 @@ func (r *RepositoryImpl) FindByEmail(email string) (User, error) {
 	result := r.db.Where("email = ?", email).First(&user)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return user, errors.New("user not found")
+			return user, ErrNotFound
 		}
 		return user, result.Error
 	}
@@ -50,7 +68,7 @@ func (r *RepositoryImpl) FindByID(id uint) (User, error) {
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			log.Printf("User not found with ID: %d", id)
-			return user, errors.New("user not found")
+			return user, ErrNotFound
 		}
 		log.Printf("Database error while finding user: %v", result.Error)
 		return user, result.Error
@@ -66,6 +84,19 @@ func (r *RepositoryImpl) FindAll() ([]User, error) {
 	return users, result.Error
 }
 
+// FindPage возвращает страницу пользователей, подходящих под фильтр
+// (с одной лишней строкой, см. pagination.Scope), и общее количество таких пользователей
+func (r *RepositoryImpl) FindPage(params pagination.Params, filter Filter) ([]User, int64, error) {
+	var total int64
+	if err := r.db.Model(&User{}).Scopes(filter.Scope()).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []User
+	result := r.db.Scopes(filter.Scope(), params.Scope(filter.Order)).Find(&users)
+	return users, total, result.Error
+}
+
 func (r *RepositoryImpl) UpdateToken(id uint, token string) error {
 	result := r.db.Model(&User{}).Where("id = ?", id).Update("token", token)
 	return result.Error
@@ -76,17 +107,96 @@ func (r *RepositoryImpl) UpdateRefreshToken(id uint, refreshToken string) error
 	return result.Error
 }
 
+func (r *RepositoryImpl) UpdateRole(id uint, role string) error {
+	result := r.db.Model(&User{}).Where("id = ?", id).Update("role", role)
+	return result.Error
+}
+
+// MarkVerified отмечает email пользователя подтвержденным
+func (r *RepositoryImpl) MarkVerified(id uint, at time.Time) error {
+	result := r.db.Model(&User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"is_verified":    true,
+		"is_verified_at": at,
+	})
+	return result.Error
+}
+
+// ClaimVerificationSend отмечает отправку письма подтверждения, если предыдущее ушло
+// не позже чем interval назад. Проверка и отметка - один UPDATE, поэтому параллельные
+// запросы и разные экземпляры приложения не отправят два письма. false - письмо отправлять рано.
+func (r *RepositoryImpl) ClaimVerificationSend(id uint, now time.Time, interval time.Duration) (bool, error) {
+	result := r.db.Model(&User{}).
+		Where("id = ? AND (verification_sent_at IS NULL OR verification_sent_at <= ?)", id, now.Add(-interval)).
+		Update("verification_sent_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// UpdatePassword сохраняет уже захешированный пароль. Обновление идет по колонке,
+// поэтому хук BeforeSave не хеширует значение повторно.
+func (r *RepositoryImpl) UpdatePassword(id uint, hashedPassword string) error {
+	result := r.db.Model(&User{}).Where("id = ?", id).Update("password", hashedPassword)
+	return result.Error
+}
+
+// UpdateFields обновляет только переданные колонки. Пароль так менять нельзя: для него есть UpdatePassword.
+func (r *RepositoryImpl) UpdateFields(id uint, fields map[string]interface{}) error {
+	if _, ok := fields["password"]; ok {
+		return errors.New("password must be updated with UpdatePassword")
+	}
+	result := r.db.Model(&User{}).Where("id = ?", id).Updates(fields)
+	if database.IsUniqueViolation(result.Error) {
+		return ErrEmailExists
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *RepositoryImpl) CountByRole(role string) (int64, error) {
+	var count int64
+	result := r.db.Model(&User{}).Where("role = ?", role).Count(&count)
+	return count, result.Error
+}
+
+// LockIDsByRole возвращает ID пользователей с ролью и блокирует их строки до конца транзакции
+func (r *RepositoryImpl) LockIDsByRole(role string) ([]uint, error) {
+	var ids []uint
+	result := r.db.Model(&User{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("role = ?", role).
+		Pluck("id", &ids)
+	return ids, result.Error
+}
+
+// FindIDsByRole возвращает ID пользователей с ролью без блокировки строк
+func (r *RepositoryImpl) FindIDsByRole(role string) ([]uint, error) {
+	var ids []uint
+	result := r.db.Model(&User{}).Where("role = ?", role).Pluck("id", &ids)
+	return ids, result.Error
+}
+
 func (r *RepositoryImpl) Create(user *User) error {
 	// Проверяем, существует ли пользователь с таким email
 	var existingUser User
 	result := r.db.Where("email = ?", user.Email).First(&existingUser)
 	if result.Error == nil {
-		return errors.New("user with this email already exists")
+		return ErrEmailExists
 	} else if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return result.Error
 	}
 
-	// Создаем нового пользователя
+	// Создаем нового пользователя. Проверка выше не защищает от параллельной регистрации,
+	// поэтому нарушение уникального индекса тоже означает занятый email.
 	result = r.db.Create(user)
+	if database.IsUniqueViolation(result.Error) {
+		return ErrEmailExists
+	}
 	return result.Error
 }
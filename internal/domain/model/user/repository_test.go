@@ -0,0 +1,49 @@
+package user
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRunDB возвращает GORM без подключения к базе: UPDATE только строится и попадает в captured
+func dryRunDB(t *testing.T, captured *[]string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=test sslmode=disable"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	err = db.Callback().Update().After("gorm:update").Register("test:capture", func(tx *gorm.DB) {
+		*captured = append(*captured, tx.Statement.SQL.String())
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	return db
+}
+
+func TestClaimVerificationSendIsOneConditionalUpdate(t *testing.T) {
+	var captured []string
+	repo := NewRepository(dryRunDB(t, &captured))
+	if _, err := repo.ClaimVerificationSend(1, time.Now(), time.Minute); err != nil {
+		t.Fatalf("ClaimVerificationSend: %v", err)
+	}
+	if len(captured) != 1 {
+		t.Fatalf("captured %d statements, want 1", len(captured))
+	}
+	for _, want := range []string{
+		`SET "verification_sent_at"=`,
+		`verification_sent_at IS NULL OR verification_sent_at <=`,
+	} {
+		if !strings.Contains(captured[0], want) {
+			t.Errorf("%s: want it to contain %s", captured[0], want)
+		}
+	}
+}
@@ -6,16 +6,22 @@ import (
 	"time"
 )
 
+// UserDeleted - архивный снимок удаленного пользователя. UserID - ID исходной записи,
+// под которым пользователь восстанавливается. После PurgeAfter персональные данные обезличиваются.
 type UserDeleted struct {
 	common.Base
 	user.User
-	ID uint `json:"id" gorm:"primaryKey"`
-	UserID uint `json:"user_id" gorm:"not null"`
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
 	DeletedAt time.Time `json:"deleted_at" gorm:"not null"`
+	// Email перекрывает поле user.User без уникального индекса: один адрес может
+	// оказаться в архиве несколько раз
+	Email         string     `json:"email" gorm:"size:255;not null;default:'';index"`
+	UserCreatedAt time.Time  `json:"user_created_at"`
+	PurgeAfter    time.Time  `json:"purge_after" gorm:"index"`
+	AnonymizedAt  *time.Time `json:"anonymized_at"`
 }
 
 func (UserDeleted) TableName() string {
 	return "users_deleted_struct"
 }
-
-
@@ -0,0 +1,41 @@
+package user_deleted
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Колонки, добавленные к существующей таблице с NOT NULL, должны иметь значение по умолчанию,
+// иначе AutoMigrate не сможет добавить их к уже архивированным строкам
+func TestAddedColumnsHaveDefaults(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=test sslmode=disable"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&UserDeleted{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		column string
+		want   string
+	}{
+		{column: "email", want: "NOT NULL DEFAULT ''"},
+	}
+	for _, tt := range tests {
+		field := stmt.Schema.LookUpField(tt.column)
+		if field == nil {
+			t.Fatalf("column %s not found", tt.column)
+		}
+		if ddl := db.Migrator().FullDataTypeOf(field).SQL; !strings.Contains(ddl, tt.want) {
+			t.Errorf("%s: column type %q, want it to contain %q", tt.column, ddl, tt.want)
+		}
+	}
+}
@@ -1,7 +1,19 @@
 package user_deleted
 
 import (
+	"awesomeProject/internal/domain/model/user"
+	"errors"
+	"fmt"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNotFound   = errors.New("deleted user not found")
+	ErrAnonymized = errors.New("deleted user has already been anonymized and cannot be restored")
+	ErrEmailTaken = errors.New("email of the deleted user is used by another account")
 )
 
 type Repository interface {
@@ -9,7 +21,10 @@ type Repository interface {
 	FindAll() ([]UserDeleted, error)
 	FindByID(id uint) (UserDeleted, error)
 	Update(userDeleted *UserDeleted) error
-	Restore(id uint) error
+	Archive(userID uint, purgeAfter time.Time) (UserDeleted, error)
+	Restore(userID uint) (user.User, error)
+	FindDueForAnonymization(now time.Time, limit int) ([]UserDeleted, error)
+	Anonymize(id uint, at time.Time) error
 }
 type RepositoryImpl struct {
 	db *gorm.DB
@@ -25,7 +40,7 @@ func (r *RepositoryImpl) Create(userDeleted *UserDeleted) error {
 
 func (r *RepositoryImpl) FindAll() ([]UserDeleted, error) {
 	var userDeleted []UserDeleted
-	if err := r.db.Find(&userDeleted).Error; err != nil {
+	if err := r.db.Order("deleted_at DESC").Find(&userDeleted).Error; err != nil {
 		return nil, err
 	}
 	return userDeleted, nil
@@ -34,6 +49,9 @@ func (r *RepositoryImpl) FindAll() ([]UserDeleted, error) {
 func (r *RepositoryImpl) FindByID(id uint) (UserDeleted, error) {
 	var userDeleted UserDeleted
 	if err := r.db.First(&userDeleted, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return UserDeleted{}, ErrNotFound
+		}
 		return UserDeleted{}, err
 	}
 	return userDeleted, nil
@@ -43,7 +61,104 @@ func (r *RepositoryImpl) Update(userDeleted *UserDeleted) error {
 	return r.db.Model(&UserDeleted{}).Where("id = ?", userDeleted.ID).Updates(userDeleted).Error
 }
 
-func (r *RepositoryImpl) Restore(id uint) error {
-	return r.db.Model(&UserDeleted{}).Where("id = ?", id).Update("is_deleted", false).Error
+// Archive в одной транзакции копирует пользователя в архив и удаляет его из users_struct.
+// Хуки пропускаются, чтобы BeforeSave из user.User не захешировал хеш пароля повторно.
+func (r *RepositoryImpl) Archive(userID uint, purgeAfter time.Time) (UserDeleted, error) {
+	var archived UserDeleted
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var u user.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&u, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return user.ErrNotFound
+			}
+			return err
+		}
+
+		u.IsDeleted = true
+		u.IsActive = false
+		archived = UserDeleted{
+			User:          u,
+			UserID:        u.ID,
+			Email:         u.Email,
+			UserCreatedAt: u.CreatedAt,
+			DeletedAt:     time.Now(),
+			PurgeAfter:    purgeAfter,
+		}
+		if err := tx.Session(&gorm.Session{SkipHooks: true}).Create(&archived).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&user.User{}, u.ID).Error
+	})
+	return archived, err
 }
 
+// Restore восстанавливает пользователя под прежним ID из последнего архивного снимка
+// и удаляет снимок. Обезличенный снимок восстановить нельзя.
+func (r *RepositoryImpl) Restore(userID uint) (user.User, error) {
+	var restored user.User
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var archived UserDeleted
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", userID).
+			Order("deleted_at DESC").
+			First(&archived).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if archived.AnonymizedAt != nil {
+			return ErrAnonymized
+		}
+
+		var taken int64
+		if err := tx.Unscoped().Model(&user.User{}).
+			Where("id = ? OR email = ?", archived.UserID, archived.Email).
+			Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrEmailTaken
+		}
+
+		restored = archived.User
+		restored.ID = archived.UserID
+		restored.Email = archived.Email
+		restored.CreatedAt = archived.UserCreatedAt
+		restored.UpdatedAt = time.Now()
+		restored.DeletedAt = gorm.DeletedAt{}
+		restored.IsDeleted = false
+		restored.IsActive = true
+		restored.IsActive_at = time.Now()
+		if err := tx.Session(&gorm.Session{SkipHooks: true}).Create(&restored).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&UserDeleted{}, archived.ID).Error
+	})
+	return restored, err
+}
+
+// FindDueForAnonymization возвращает снимки, срок хранения персональных данных которых истек
+func (r *RepositoryImpl) FindDueForAnonymization(now time.Time, limit int) ([]UserDeleted, error) {
+	var due []UserDeleted
+	err := r.db.Where("anonymized_at IS NULL AND purge_after <= ?", now).
+		Order("purge_after").
+		Limit(limit).
+		Find(&due).Error
+	return due, err
+}
+
+// Anonymize стирает персональные данные снимка, оставляя ID и даты для статистики
+func (r *RepositoryImpl) Anonymize(id uint, at time.Time) error {
+	return r.db.Model(&UserDeleted{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"name":          "Deleted user",
+		"age":           0,
+		"city":          "",
+		"email":         fmt.Sprintf("deleted-%d@anonymized.invalid", id),
+		"password":      "",
+		"token":         "",
+		"refresh_token": "",
+		"anonymized_at": at,
+	}).Error
+}
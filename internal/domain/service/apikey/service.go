@@ -0,0 +1,184 @@
+package apikey
+
+import (
+	"awesomeProject/internal/database"
+	"awesomeProject/internal/domain/model/api_key"
+	"awesomeProject/internal/domain/model/user"
+	roleService "awesomeProject/internal/domain/service/role"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// KeyPrefix - начало каждого ключа, по которому его легко узнать в логах и сканерах секретов
+const KeyPrefix = "ak"
+
+// prefixBytes - длина prefix в байтах. По prefix ключ ищется в уникальном индексе,
+// поэтому 64 бит хватает, чтобы столкновения на практике не возникали.
+const prefixBytes = 8
+
+// lastUsedPrecision - не чаще этого интервала обновляется время последнего использования
+const lastUsedPrecision = time.Minute
+
+var (
+	ErrInvalidKey    = errors.New("invalid api key")
+	ErrInvalidScope  = errors.New("scope is not granted to your role")
+	ErrInvalidExpiry = errors.New("expires_at must be in the future")
+)
+
+type APIKeyService struct {
+	keyRepo api_key.Repository
+	roles   *roleService.RoleService
+}
+
+// CreateKeyRequest - тело запроса на создание ключа
+type CreateKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreatedKey - созданный ключ. Key показывается только один раз.
+type CreatedKey struct {
+	api_key.APIKey
+	Key string `json:"key"`
+}
+
+func NewAPIKeyService() *APIKeyService {
+	return &APIKeyService{
+		keyRepo: api_key.NewRepository(database.GetDB()),
+		roles:   roleService.NewRoleService(),
+	}
+}
+
+// CreateKey выпускает ключ для пользователя. Область действия ключа не может быть шире прав его роли.
+func (s *APIKeyService) CreateKey(u user.User, req CreateKeyRequest) (CreatedKey, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return CreatedKey{}, ErrInvalidExpiry
+	}
+	granted, err := s.roles.PermissionsForRole(u.Role)
+	if err != nil {
+		return CreatedKey{}, err
+	}
+	scopes := make([]string, 0, len(req.Scopes))
+	seen := make(map[string]bool, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		if !contains(granted, scope) {
+			return CreatedKey{}, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	prefix, err := randomHex(prefixBytes)
+	if err != nil {
+		return CreatedKey{}, err
+	}
+	secret, err := randomHex(24)
+	if err != nil {
+		return CreatedKey{}, err
+	}
+
+	key := api_key.APIKey{
+		UserID:     u.ID,
+		Name:       req.Name,
+		Prefix:     prefix,
+		SecretHash: hashSecret(secret),
+		Scopes:     scopes,
+		ExpiresAt:  req.ExpiresAt,
+	}
+	if err := s.keyRepo.Create(&key); err != nil {
+		return CreatedKey{}, err
+	}
+	return CreatedKey{
+		APIKey: key,
+		Key:    fmt.Sprintf("%s_%s_%s", KeyPrefix, prefix, secret),
+	}, nil
+}
+
+// ListKeys возвращает ключи пользователя без секретов
+func (s *APIKeyService) ListKeys(userID uint) ([]api_key.APIKey, error) {
+	return s.keyRepo.FindByUser(userID)
+}
+
+// RevokeKey отзывает ключ пользователя
+func (s *APIKeyService) RevokeKey(userID, keyID uint) error {
+	return s.keyRepo.Revoke(keyID, userID)
+}
+
+// Authenticate находит действующий ключ по его полному значению
+func (s *APIKeyService) Authenticate(raw string) (api_key.APIKey, error) {
+	parts := strings.SplitN(raw, "_", 3)
+	if len(parts) != 3 || parts[0] != KeyPrefix || parts[1] == "" || parts[2] == "" {
+		return api_key.APIKey{}, ErrInvalidKey
+	}
+
+	key, err := s.keyRepo.FindByPrefix(parts[1])
+	if err != nil {
+		if errors.Is(err, api_key.ErrNotFound) {
+			return api_key.APIKey{}, ErrInvalidKey
+		}
+		return api_key.APIKey{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(key.SecretHash), []byte(hashSecret(parts[2]))) != 1 {
+		return api_key.APIKey{}, ErrInvalidKey
+	}
+	now := time.Now()
+	if !key.IsActive(now) {
+		return api_key.APIKey{}, ErrInvalidKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedPrecision {
+		if err := s.keyRepo.TouchLastUsed(key.ID, now); err != nil {
+			log.Printf("Failed to update last use of api key %d: %v", key.ID, err)
+		}
+		key.LastUsedAt = &now
+	}
+	return key, nil
+}
+
+// EffectivePermissions - права запроса по ключу: его scopes, которые еще входят в права роли.
+// Если роль лишилась права, ключ теряет его сразу.
+func (s *APIKeyService) EffectivePermissions(key api_key.APIKey, role string) ([]string, error) {
+	granted, err := s.roles.PermissionsForRole(role)
+	if err != nil {
+		return nil, err
+	}
+	permissions := make([]string, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		if contains(granted, scope) {
+			permissions = append(permissions, scope)
+		}
+	}
+	return permissions, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
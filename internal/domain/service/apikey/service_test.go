@@ -0,0 +1,221 @@
+package apikey
+
+import (
+	"awesomeProject/internal/cache"
+	"awesomeProject/internal/domain/model/api_key"
+	"awesomeProject/internal/domain/model/common"
+	"awesomeProject/internal/domain/model/permission"
+	"awesomeProject/internal/domain/model/role"
+	"awesomeProject/internal/domain/model/user"
+	roleService "awesomeProject/internal/domain/service/role"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// fakeKeys хранит ключи в памяти
+type fakeKeys struct {
+	api_key.Repository
+	items []api_key.APIKey
+}
+
+func (f *fakeKeys) Create(key *api_key.APIKey) error {
+	key.ID = uint(len(f.items) + 1)
+	f.items = append(f.items, *key)
+	return nil
+}
+
+func (f *fakeKeys) FindByPrefix(prefix string) (api_key.APIKey, error) {
+	for _, key := range f.items {
+		if key.Prefix == prefix {
+			return key, nil
+		}
+	}
+	return api_key.APIKey{}, api_key.ErrNotFound
+}
+
+func (f *fakeKeys) TouchLastUsed(id uint, at time.Time) error {
+	f.items[id-1].LastUsedAt = &at
+	return nil
+}
+
+// fakeRoles отдает роли с заданными правами
+type fakeRoles struct {
+	role.Repository
+	permissions map[string][]string
+}
+
+func (f fakeRoles) FindByName(name string) (role.Role, error) {
+	names, ok := f.permissions[name]
+	if !ok {
+		return role.Role{}, role.ErrNotFound
+	}
+	r := role.Role{RoleName: name}
+	for _, n := range names {
+		r.Permissions = append(r.Permissions, permission.Permission{Name: n})
+	}
+	return r, nil
+}
+
+func newTestService(permissions map[string][]string) (*APIKeyService, *fakeKeys) {
+	c := cache.GetCache()
+	c.Clear()
+	keys := &fakeKeys{}
+	return &APIKeyService{
+		keyRepo: keys,
+		roles:   roleService.NewRoleServiceWith(fakeRoles{permissions: permissions}, nil, nil, nil, c),
+	}, keys
+}
+
+var editor = user.User{Base: common.Base{ID: 1}, Role: role.Editor}
+
+var keyFormat = regexp.MustCompile(`^ak_[0-9a-f]{16}_[0-9a-f]{48}$`)
+
+func TestCreateKey(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		req        CreateKeyRequest
+		wantScopes []string
+		wantErr    error
+	}{
+		{
+			name:       "scopes of the role",
+			req:        CreateKeyRequest{Name: "ci", Scopes: []string{"news:read", "news:write"}, ExpiresAt: &future},
+			wantScopes: []string{"news:read", "news:write"},
+		},
+		{
+			name:       "duplicate scopes are collapsed",
+			req:        CreateKeyRequest{Name: "ci", Scopes: []string{"news:read", "news:read"}},
+			wantScopes: []string{"news:read"},
+		},
+		{
+			name:    "scope outside the role",
+			req:     CreateKeyRequest{Name: "ci", Scopes: []string{"users:manage"}},
+			wantErr: ErrInvalidScope,
+		},
+		{
+			name:    "expiry in the past",
+			req:     CreateKeyRequest{Name: "ci", Scopes: []string{"news:read"}, ExpiresAt: &past},
+			wantErr: ErrInvalidExpiry,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(map[string][]string{role.Editor: {"news:read", "news:write"}})
+			created, err := s.CreateKey(editor, tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if !keyFormat.MatchString(created.Key) {
+				t.Errorf("key %q does not match ak_<16 hex>_<48 hex>", created.Key)
+			}
+			if len(created.Scopes) != len(tt.wantScopes) {
+				t.Fatalf("scopes = %v, want %v", created.Scopes, tt.wantScopes)
+			}
+			for i := range tt.wantScopes {
+				if created.Scopes[i] != tt.wantScopes[i] {
+					t.Errorf("scopes = %v, want %v", created.Scopes, tt.wantScopes)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateKeyPrefixesAreUnique(t *testing.T) {
+	s, _ := newTestService(map[string][]string{role.Editor: {"news:read"}})
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		created, err := s.CreateKey(editor, CreateKeyRequest{Name: "ci", Scopes: []string{"news:read"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(created.Prefix) != 2*prefixBytes || seen[created.Prefix] {
+			t.Fatalf("prefix %q is too short or repeated", created.Prefix)
+		}
+		seen[created.Prefix] = true
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	s, keys := newTestService(map[string][]string{role.Editor: {"news:read"}})
+	create := func() CreatedKey {
+		created, err := s.CreateKey(editor, CreateKeyRequest{Name: "ci", Scopes: []string{"news:read"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return created
+	}
+	valid := create()
+	revoked := create()
+	now := time.Now()
+	keys.items[revoked.ID-1].RevokedAt = &now
+	expired := create()
+	keys.items[expired.ID-1].ExpiresAt = &now
+
+	tests := []struct {
+		name    string
+		raw     string
+		wantID  uint
+		wantErr error
+	}{
+		{name: "valid", raw: valid.Key, wantID: valid.ID},
+		{name: "wrong secret", raw: valid.Key[:len(valid.Key)-1] + "0", wantErr: ErrInvalidKey},
+		{name: "unknown prefix", raw: "ak_0000000000000000_" + valid.Key[len(valid.Key)-48:], wantErr: ErrInvalidKey},
+		{name: "other scheme", raw: "xx" + valid.Key[2:], wantErr: ErrInvalidKey},
+		{name: "no secret", raw: "ak_" + valid.Prefix + "_", wantErr: ErrInvalidKey},
+		{name: "empty", raw: "", wantErr: ErrInvalidKey},
+		{name: "revoked", raw: revoked.Key, wantErr: ErrInvalidKey},
+		{name: "expired", raw: expired.Key, wantErr: ErrInvalidKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := s.Authenticate(tt.raw)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (key.ID != tt.wantID || key.LastUsedAt == nil) {
+				t.Errorf("key %d last used %v, want key %d with last use", key.ID, key.LastUsedAt, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestEffectivePermissions(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		role   string
+		want   []string
+	}{
+		{name: "all scopes still granted", scopes: []string{"news:read", "news:write"}, role: role.Editor, want: []string{"news:read", "news:write"}},
+		{name: "role lost a permission", scopes: []string{"news:read", "comments:moderate"}, role: role.Editor, want: []string{"news:read"}},
+		{name: "unknown role", scopes: []string{"news:read"}, role: "ghost", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(map[string][]string{role.Editor: {"news:read", "news:write"}})
+			got, err := s.EffectivePermissions(api_key.APIKey{Scopes: tt.scopes}, tt.role)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("permissions = %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("permissions = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package comment
+
+import (
+	"awesomeProject/internal/config"
+	"awesomeProject/internal/database"
+	"awesomeProject/internal/domain/model/comment"
+	"awesomeProject/internal/domain/model/news"
+	"awesomeProject/internal/domain/model/user"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrForbidden         = errors.New("you can only modify your own comments")
+	ErrEditWindowExpired = errors.New("comment can no longer be edited")
+	ErrInvalidParent     = errors.New("parent comment does not belong to this news")
+	ErrInvalidStatus     = errors.New("unknown comment status")
+)
+
+type CommentService struct {
+	commentRepo comment.Repository
+	newsRepo    news.Repository
+	config      *config.Config
+}
+
+// CommentNode - комментарий в дереве обсуждения. Удаленные комментарии с ответами
+// остаются в дереве без текста, чтобы ветка не разрывалась.
+type CommentNode struct {
+	comment.Comment
+	Deleted bool           `json:"deleted"`
+	Replies []*CommentNode `json:"replies"`
+}
+
+// CreateCommentRequest - тело запроса на создание комментария или ответа
+type CreateCommentRequest struct {
+	Body     string `json:"body" binding:"required,max=5000"`
+	ParentID *uint  `json:"parent_id"`
+}
+
+// UpdateCommentRequest - тело запроса на изменение текста комментария
+type UpdateCommentRequest struct {
+	Body string `json:"body" binding:"required,max=5000"`
+}
+
+// ModerateCommentRequest - тело запроса на смену статуса модерации
+type ModerateCommentRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+func NewCommentService() *CommentService {
+	db := database.GetDB()
+	return &CommentService{
+		commentRepo: comment.NewRepository(db),
+		newsRepo:    news.NewsRepository(db),
+		config:      config.GetConfig(),
+	}
+}
+
+// ListComments возвращает дерево комментариев новости. Модератор видит все комментарии,
+// остальные - одобренные и свои собственные.
+func (s *CommentService) ListComments(newsID uint, viewer user.User, canModerate bool) ([]*CommentNode, error) {
+	if _, err := s.newsRepo.FindByID(newsID); err != nil {
+		return nil, err
+	}
+	comments, err := s.commentRepo.FindByNews(newsID, viewer.ID, canModerate)
+	if err != nil {
+		return nil, err
+	}
+	return buildTree(comments), nil
+}
+
+// CreateComment добавляет комментарий от имени автора. При включенной премодерации
+// комментарий скрыт от остальных, пока его не одобрят.
+func (s *CommentService) CreateComment(newsID uint, author user.User, req CreateCommentRequest) (comment.Comment, error) {
+	if _, err := s.newsRepo.FindByID(newsID); err != nil {
+		return comment.Comment{}, err
+	}
+	if req.ParentID != nil {
+		parent, err := s.commentRepo.FindByID(*req.ParentID)
+		if err != nil {
+			if errors.Is(err, comment.ErrNotFound) {
+				return comment.Comment{}, ErrInvalidParent
+			}
+			return comment.Comment{}, err
+		}
+		if parent.NewsID != newsID {
+			return comment.Comment{}, ErrInvalidParent
+		}
+	}
+
+	status := comment.StatusApproved
+	if s.config.Comments.Premoderation {
+		status = comment.StatusPending
+	}
+
+	c := comment.Comment{
+		NewsID:   newsID,
+		UserID:   author.ID,
+		ParentID: req.ParentID,
+		Body:     req.Body,
+		Status:   status,
+	}
+	if err := s.commentRepo.Create(&c); err != nil {
+		return comment.Comment{}, err
+	}
+	return c, nil
+}
+
+// UpdateComment меняет текст комментария. Править может только автор и только
+// в течение окна редактирования после публикации. При включенной премодерации
+// измененный одобренный комментарий снова уходит на модерацию.
+func (s *CommentService) UpdateComment(newsID, commentID uint, editor user.User, req UpdateCommentRequest) (comment.Comment, error) {
+	c, err := s.findInNews(newsID, commentID)
+	if err != nil {
+		return comment.Comment{}, err
+	}
+	if c.UserID != editor.ID {
+		return comment.Comment{}, ErrForbidden
+	}
+	now := time.Now()
+	if now.Sub(c.CreatedAt) > s.config.Comments.EditWindow {
+		return comment.Comment{}, ErrEditWindowExpired
+	}
+
+	if req.Body == c.Body {
+		return c, nil
+	}
+
+	status := c.Status
+	if s.config.Comments.Premoderation && status == comment.StatusApproved {
+		status = comment.StatusPending
+	}
+	if err := s.commentRepo.UpdateBody(c.ID, req.Body, status, now); err != nil {
+		return comment.Comment{}, err
+	}
+	return s.commentRepo.FindByID(c.ID)
+}
+
+// DeleteComment мягко удаляет комментарий автора или любой комментарий для модератора
+func (s *CommentService) DeleteComment(newsID, commentID uint, actor user.User, canModerate bool) error {
+	c, err := s.findInNews(newsID, commentID)
+	if err != nil {
+		return err
+	}
+	if !canModerate && c.UserID != actor.ID {
+		return ErrForbidden
+	}
+	return s.commentRepo.Delete(c.ID)
+}
+
+// ModerateComment выставляет статус модерации комментария
+func (s *CommentService) ModerateComment(newsID, commentID uint, req ModerateCommentRequest) (comment.Comment, error) {
+	if !comment.IsValidStatus(req.Status) {
+		return comment.Comment{}, fmt.Errorf("%w: %s", ErrInvalidStatus, req.Status)
+	}
+	c, err := s.findInNews(newsID, commentID)
+	if err != nil {
+		return comment.Comment{}, err
+	}
+	if err := s.commentRepo.UpdateStatus(c.ID, req.Status); err != nil {
+		return comment.Comment{}, err
+	}
+	c.Status = req.Status
+	return c, nil
+}
+
+// findInNews ищет комментарий и проверяет, что он относится к указанной новости
+func (s *CommentService) findInNews(newsID, commentID uint) (comment.Comment, error) {
+	c, err := s.commentRepo.FindByID(commentID)
+	if err != nil {
+		return comment.Comment{}, err
+	}
+	if c.NewsID != newsID {
+		return comment.Comment{}, comment.ErrNotFound
+	}
+	return c, nil
+}
+
+// buildTree собирает плоский список комментариев в дерево. Комментарии приходят
+// в порядке создания, поэтому родитель всегда обработан раньше ответа.
+func buildTree(comments []comment.Comment) []*CommentNode {
+	nodes := make(map[uint]*CommentNode, len(comments))
+	roots := []*CommentNode{}
+
+	for _, c := range comments {
+		node := &CommentNode{Comment: c, Replies: []*CommentNode{}}
+		if c.DeletedAt.Valid {
+			node.Deleted = true
+			node.Body = ""
+		}
+		nodes[c.ID] = node
+
+		if c.ParentID != nil {
+			if parent, ok := nodes[*c.ParentID]; ok {
+				parent.Replies = append(parent.Replies, node)
+				continue
+			}
+		}
+		// Ответ на скрытый от зрителя комментарий поднимаем на верхний уровень
+		roots = append(roots, node)
+	}
+
+	return pruneDeleted(roots)
+}
+
+// pruneDeleted убирает удаленные комментарии, у которых не осталось видимых ответов
+func pruneDeleted(nodes []*CommentNode) []*CommentNode {
+	kept := nodes[:0]
+	for _, node := range nodes {
+		node.Replies = pruneDeleted(node.Replies)
+		if node.Deleted && len(node.Replies) == 0 {
+			continue
+		}
+		kept = append(kept, node)
+	}
+	return kept
+}
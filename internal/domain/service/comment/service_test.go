@@ -0,0 +1,128 @@
+package comment
+
+import (
+	"awesomeProject/internal/config"
+	"awesomeProject/internal/domain/model/comment"
+	"awesomeProject/internal/domain/model/common"
+	"awesomeProject/internal/domain/model/news"
+	"awesomeProject/internal/domain/model/user"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeComments хранит комментарии в памяти; методы, не нужные тестам, не реализованы
+type fakeComments struct {
+	comment.Repository
+	items map[uint]comment.Comment
+}
+
+func (f *fakeComments) FindByID(id uint) (comment.Comment, error) {
+	c, ok := f.items[id]
+	if !ok {
+		return comment.Comment{}, comment.ErrNotFound
+	}
+	return c, nil
+}
+
+func (f *fakeComments) UpdateBody(id uint, body, status string, editedAt time.Time) error {
+	c := f.items[id]
+	c.Body, c.Status, c.EditedAt = body, status, &editedAt
+	f.items[id] = c
+	return nil
+}
+
+type fakeNews struct {
+	news.Repository
+}
+
+func TestUpdateCommentModeration(t *testing.T) {
+	author := user.User{Base: common.Base{ID: 1}}
+	tests := []struct {
+		name          string
+		premoderation bool
+		status        string
+		body          string
+		editor        user.User
+		createdAgo    time.Duration
+		wantStatus    string
+		wantEdited    bool
+		wantErr       error
+	}{
+		{
+			name:          "approved comment goes back to moderation",
+			premoderation: true,
+			status:        comment.StatusApproved,
+			body:          "changed",
+			editor:        author,
+			wantStatus:    comment.StatusPending,
+			wantEdited:    true,
+		},
+		{
+			name:          "unchanged body keeps approval",
+			premoderation: true,
+			status:        comment.StatusApproved,
+			body:          "original",
+			editor:        author,
+			wantStatus:    comment.StatusApproved,
+		},
+		{
+			name:       "without premoderation stays approved",
+			status:     comment.StatusApproved,
+			body:       "changed",
+			editor:     author,
+			wantStatus: comment.StatusApproved,
+			wantEdited: true,
+		},
+		{
+			name:          "rejected comment stays rejected",
+			premoderation: true,
+			status:        comment.StatusRejected,
+			body:          "changed",
+			editor:        author,
+			wantStatus:    comment.StatusRejected,
+			wantEdited:    true,
+		},
+		{
+			name:    "another user",
+			status:  comment.StatusApproved,
+			body:    "changed",
+			editor:  user.User{Base: common.Base{ID: 2}},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:       "edit window expired",
+			status:     comment.StatusApproved,
+			body:       "changed",
+			editor:     author,
+			createdAgo: time.Hour,
+			wantErr:    ErrEditWindowExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Comments.EditWindow = 15 * time.Minute
+			cfg.Comments.Premoderation = tt.premoderation
+
+			original := comment.Comment{Base: common.Base{ID: 10, CreatedAt: time.Now().Add(-tt.createdAgo)}, NewsID: 5, UserID: author.ID, Body: "original", Status: tt.status}
+			repo := &fakeComments{items: map[uint]comment.Comment{original.ID: original}}
+			s := &CommentService{commentRepo: repo, newsRepo: fakeNews{}, config: cfg}
+
+			got, err := s.UpdateComment(5, original.ID, tt.editor, UpdateCommentRequest{Body: tt.body})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got.Body != tt.body || got.Status != tt.wantStatus {
+				t.Errorf("got body %q status %q, want %q %q", got.Body, got.Status, tt.body, tt.wantStatus)
+			}
+			if edited := got.EditedAt != nil; edited != tt.wantEdited {
+				t.Errorf("edited = %v, want %v", edited, tt.wantEdited)
+			}
+		})
+	}
+}
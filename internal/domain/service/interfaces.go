@@ -13,7 +13,7 @@ type UserServiceInterface interface {
 	CreateUser(req userservice.CreateUserRequest) (user.User, error)
 
 	// Login выполняет аутентификацию пользователя
-	Login(email, password string) (userservice.AuthResponse, error)
+	Login(email, password string, client userservice.ClientInfo) (userservice.AuthResponse, error)
 
 	// GetUserByID получает пользователя по ID
 	GetUserByID(id uint) (user.User, error)
@@ -81,3 +81,5 @@ type UploadServiceInterface interface {
 	// GetAllUploads получает все загрузки
 	GetAllUploads() ([]upload.Upload, error)
 }
+
+var _ UserServiceInterface = (*userservice.UserService)(nil)
@@ -1,29 +1,54 @@
 package news
 
 import (
+	"awesomeProject/internal/config"
 	"awesomeProject/internal/database"
+	"awesomeProject/internal/domain/model/comment"
 	"awesomeProject/internal/domain/model/news"
+	"awesomeProject/internal/domain/model/reaction"
+	"awesomeProject/internal/domain/model/user"
+	reactionservice "awesomeProject/internal/domain/service/reaction"
+	"awesomeProject/internal/pagination"
 	"context"
+	"errors"
 	"fmt"
-	"sync"
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrForbidden         = errors.New("you can only modify your own news")
+	ErrConflict          = errors.New("news was modified by someone else, reload it and try again")
+	ErrEmptyQuery        = errors.New("search query is required")
+	ErrInvalidLanguage   = errors.New("unsupported search language")
+	ErrCursorUnsupported = errors.New("search results support only limit/offset pagination")
 )
 
 type NewsService struct {
-	newsRepo news.Repository
+	newsRepo     news.Repository
+	commentRepo  comment.Repository
+	reactionRepo reaction.Repository
+	config       *config.Config
 }
 
 // NewsWithDetails содержит новость с дополнительными данными
 type NewsWithDetails struct {
 	news.News
-	CommentsCount int       `json:"comments_count"`
-	LikesCount    int       `json:"likes_count"`
-	LastUpdated   time.Time `json:"last_updated"`
+	CommentsCount int                     `json:"comments_count"`
+	LikesCount    int                     `json:"likes_count"`
+	Reactions     reactionservice.Summary `json:"reactions"`
+	LastUpdated   time.Time               `json:"last_updated"`
 }
 
 func NewNewsService() *NewsService {
+	db := database.GetDB()
 	return &NewsService{
-		newsRepo: news.NewsRepository(database.GetDB()),
+		newsRepo:     news.NewsRepository(db),
+		commentRepo:  comment.NewRepository(db),
+		reactionRepo: reaction.NewRepository(db),
+		config:       config.GetConfig(),
 	}
 }
 
@@ -35,96 +60,259 @@ func (s *NewsService) CreateNews(news *news.News) error {
 	return s.newsRepo.Create(news)
 }
 
+func (s *NewsService) UpdateNews(news *news.News) error {
+	return s.newsRepo.Update(news)
+}
+
+// DeleteNews удаляет новость вместе с комментариями и реакциями в одной транзакции,
+// чтобы они не учитывались в счетчиках и выгрузках со ссылкой на удаленную новость
+func (s *NewsService) DeleteNews(id uint) error {
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		return deleteNewsWith(news.NewsRepository(tx), comment.NewRepository(tx), reaction.NewRepository(tx), id)
+	})
+}
+
+// deleteNewsWith удаляет новость и все, что к ней привязано, через переданные репозитории
+func deleteNewsWith(newsRepo news.Repository, comments comment.Repository, reactions reaction.Repository, id uint) error {
+	if err := comments.DeleteByNews(id); err != nil {
+		return err
+	}
+	if err := reactions.DeleteByNews(id); err != nil {
+		return err
+	}
+	return newsRepo.Delete(id)
+}
+
 func (s *NewsService) GetAllNews() ([]news.News, error) {
 	return s.newsRepo.FindAll()
 }
 
-// GetAllNewsWithDetails возвращает все новости с дополнительными данными
-func (s *NewsService) GetAllNewsWithDetails(ctx context.Context) ([]NewsWithDetails, error) {
+// GetNewsPage возвращает одну страницу новостей, подходящих под фильтр
+func (s *NewsService) GetNewsPage(params pagination.Params, filter news.Filter) (pagination.Page[news.News], error) {
+	items, total, err := s.newsRepo.FindPage(params, filter)
+	if err != nil {
+		return pagination.Page[news.News]{}, err
+	}
+	return pagination.NewPage(items, total, params, filter.CursorOf), nil
+}
+
+// SearchNews выполняет полнотекстовый поиск. Пустой language означает язык поиска по умолчанию.
+func (s *NewsService) SearchNews(text, language string, params pagination.Params) (pagination.Page[news.SearchResult], error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return pagination.Page[news.SearchResult]{}, ErrEmptyQuery
+	}
+	if language == "" {
+		language = s.config.Search.Language
+	}
+	if !s.config.IsSearchLanguage(language) {
+		return pagination.Page[news.SearchResult]{}, fmt.Errorf("%w: %s", ErrInvalidLanguage, language)
+	}
+	if params.After != nil {
+		return pagination.Page[news.SearchResult]{}, ErrCursorUnsupported
+	}
+
+	results, total, err := s.newsRepo.Search(news.SearchQuery{Text: text, Language: language}, params)
+	if err != nil {
+		return pagination.Page[news.SearchResult]{}, err
+	}
+
+	// Курсор для результатов поиска не выдаем: порядок по релевантности не ключевой,
+	// следующую страницу запрашивают через offset
+	if results == nil {
+		results = []news.SearchResult{}
+	}
+	return pagination.Page[news.SearchResult]{
+		Data:   results,
+		Total:  total,
+		Limit:  params.Limit,
+		Offset: params.Offset,
+	}, nil
+}
+
+// CreateNewsRequest - тело запроса на создание новости
+type CreateNewsRequest struct {
+	Title       string `json:"title" binding:"required,max=255"`
+	Description string `json:"description" binding:"required,max=255"`
+	Content     string `json:"content" binding:"required,max=255"`
+	Category    string `json:"category" binding:"required,max=255"`
+	Image       string `json:"image" binding:"max=255"`
+	Language    string `json:"language"`
+}
+
+// UpdateNewsRequest - полная замена новости (PUT).
+// Если передан updated_at, обновление пройдет только при совпадении с текущим значением.
+type UpdateNewsRequest struct {
+	CreateNewsRequest
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// PatchNewsRequest - частичное обновление новости (PATCH), меняются только переданные поля
+type PatchNewsRequest struct {
+	Title       *string    `json:"title" binding:"omitempty,min=1,max=255"`
+	Description *string    `json:"description" binding:"omitempty,min=1,max=255"`
+	Content     *string    `json:"content" binding:"omitempty,min=1,max=255"`
+	Category    *string    `json:"category" binding:"omitempty,min=1,max=255"`
+	Image       *string    `json:"image" binding:"omitempty,max=255"`
+	Language    *string    `json:"language" binding:"omitempty,min=1"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+}
+
+// CreateNewsAs создает новость от имени автора
+func (s *NewsService) CreateNewsAs(author user.User, req CreateNewsRequest) (news.News, error) {
+	if err := s.validateLanguage(req.Language); err != nil {
+		return news.News{}, err
+	}
+	n := news.News{
+		Title:       req.Title,
+		Description: req.Description,
+		Content:     req.Content,
+		Category:    req.Category,
+		Image:       req.Image,
+		Language:    req.Language,
+		Author:      author.Name,
+		AuthorID:    author.ID,
+	}
+	if err := s.CreateNews(&n); err != nil {
+		return news.News{}, err
+	}
+	return n, nil
+}
+
+// ReplaceNewsAs полностью заменяет содержимое новости
+func (s *NewsService) ReplaceNewsAs(id uint, editor user.User, canEditAny bool, req UpdateNewsRequest) (news.News, error) {
+	if err := s.validateLanguage(req.Language); err != nil {
+		return news.News{}, err
+	}
+	return s.modifyNewsAs(id, editor, canEditAny, req.UpdatedAt, func(n *news.News) {
+		n.Title = req.Title
+		n.Description = req.Description
+		n.Content = req.Content
+		n.Category = req.Category
+		n.Image = req.Image
+		n.Language = req.Language
+	})
+}
+
+// PatchNewsAs обновляет только переданные поля новости
+func (s *NewsService) PatchNewsAs(id uint, editor user.User, canEditAny bool, req PatchNewsRequest) (news.News, error) {
+	if req.Language != nil {
+		if err := s.validateLanguage(*req.Language); err != nil {
+			return news.News{}, err
+		}
+	}
+	return s.modifyNewsAs(id, editor, canEditAny, req.UpdatedAt, func(n *news.News) {
+		if req.Title != nil {
+			n.Title = *req.Title
+		}
+		if req.Description != nil {
+			n.Description = *req.Description
+		}
+		if req.Content != nil {
+			n.Content = *req.Content
+		}
+		if req.Category != nil {
+			n.Category = *req.Category
+		}
+		if req.Image != nil {
+			n.Image = *req.Image
+		}
+		if req.Language != nil {
+			n.Language = *req.Language
+		}
+	})
+}
+
+// DeleteNewsAs удаляет новость, если редактор её автор или может править чужие новости
+func (s *NewsService) DeleteNewsAs(id uint, editor user.User, canEditAny bool) error {
+	n, err := s.newsRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if !canModify(n, editor, canEditAny) {
+		return ErrForbidden
+	}
+	return s.DeleteNews(id)
+}
+
+func (s *NewsService) modifyNewsAs(id uint, editor user.User, canEditAny bool, expectedUpdatedAt *time.Time, apply func(*news.News)) (news.News, error) {
+	n, err := s.newsRepo.FindByID(id)
+	if err != nil {
+		return news.News{}, err
+	}
+	if !canModify(n, editor, canEditAny) {
+		return news.News{}, ErrForbidden
+	}
+
+	apply(&n)
+
+	if expectedUpdatedAt == nil {
+		if err := s.UpdateNews(&n); err != nil {
+			return news.News{}, err
+		}
+	} else {
+		updated, err := s.newsRepo.UpdateIfUnmodified(&n, *expectedUpdatedAt)
+		if err != nil {
+			return news.News{}, err
+		}
+		if !updated {
+			return news.News{}, ErrConflict
+		}
+	}
+	return s.newsRepo.FindByID(id)
+}
+
+// validateLanguage проверяет язык статьи; пустой язык означает язык поиска по умолчанию
+func (s *NewsService) validateLanguage(language string) error {
+	if language != "" && !s.config.IsSearchLanguage(language) {
+		return fmt.Errorf("%w: %s", ErrInvalidLanguage, language)
+	}
+	return nil
+}
+
+func canModify(n news.News, editor user.User, canEditAny bool) bool {
+	return canEditAny || (n.AuthorID != 0 && n.AuthorID == editor.ID)
+}
+
+// GetAllNewsWithDetails возвращает все новости с дополнительными данными для пользователя userID.
+// Комментарии и реакции считаются агрегирующими запросами на весь список, а не по каждой новости.
+func (s *NewsService) GetAllNewsWithDetails(ctx context.Context, userID uint) ([]NewsWithDetails, error) {
 	// Получаем базовый список новостей
 	newsList, err := s.newsRepo.FindAll()
 	if err != nil {
 		return nil, err
 	}
 
-	var wg sync.WaitGroup
-	results := make([]NewsWithDetails, len(newsList))
-	errors := make(chan error, len(newsList))
-
-	// Для каждой новости запускаем горутину для получения дополнительных данных
+	ids := make([]uint, len(newsList))
 	for i, n := range newsList {
-		wg.Add(1)
-		go func(index int, newsItem news.News) {
-			defer wg.Done()
-
-			// Создаем каналы для получения данных
-			commentsChan := make(chan int, 1)
-			likesChan := make(chan int, 1)
-			updateChan := make(chan time.Time, 1)
-
-			// Запускаем горутины для получения разных типов данных
-			go func() {
-				defer close(commentsChan)
-				// Здесь можно добавить реальную логику получения количества комментариев
-				commentsChan <- 0 // Заглушка
-			}()
-
-			go func() {
-				defer close(likesChan)
-				// Здесь можно добавить реальную логику получения количества лайков
-				likesChan <- 0 // Заглушка
-			}()
-
-			go func() {
-				defer close(updateChan)
-				// Получаем время последнего обновления
-				updateChan <- newsItem.UpdatedAt
-			}()
-
-			// Используем select для получения данных с таймаутом
-			select {
-			case comments := <-commentsChan:
-				select {
-				case likes := <-likesChan:
-					select {
-					case lastUpdate := <-updateChan:
-						results[index] = NewsWithDetails{
-							News:          newsItem,
-							CommentsCount: comments,
-							LikesCount:    likes,
-							LastUpdated:   lastUpdate,
-						}
-					case <-time.After(500 * time.Millisecond):
-						errors <- fmt.Errorf("timeout getting update time for news %d", newsItem.ID)
-					}
-				case <-time.After(500 * time.Millisecond):
-					errors <- fmt.Errorf("timeout getting likes for news %d", newsItem.ID)
-				}
-			case <-time.After(500 * time.Millisecond):
-				errors <- fmt.Errorf("timeout getting comments for news %d", newsItem.ID)
-			case <-ctx.Done():
-				errors <- ctx.Err()
-			}
-		}(i, n)
-	}
-
-	// Запускаем горутину для закрытия канала ошибок после завершения всех операций
-	go func() {
-		wg.Wait()
-		close(errors)
-	}()
-
-	// Собираем ошибки
-	var errs []error
-	for err := range errors {
-		if err != nil {
-			errs = append(errs, err)
-		}
+		ids[i] = n.ID
 	}
 
-	// Если есть ошибки, возвращаем их
-	if len(errs) > 0 {
-		return results, fmt.Errorf("errors occurred while fetching news details: %v", errs)
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout.NewsDetails)
+	defer cancel()
+
+	commentCounts, err := s.commentRepo.CountByNewsIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count comments: %w", err)
+	}
+	reactionCounts, err := s.reactionRepo.CountsByNewsIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count reactions: %w", err)
+	}
+	myReactions, err := s.reactionRepo.TypesByUser(ctx, userID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user reactions: %w", err)
+	}
+
+	results := make([]NewsWithDetails, len(newsList))
+	for i, n := range newsList {
+		results[i] = NewsWithDetails{
+			News:          n,
+			CommentsCount: int(commentCounts[n.ID]),
+			LikesCount:    int(reactionCounts[n.ID][reaction.Like]),
+			Reactions:     reactionservice.NewSummary(reactionCounts[n.ID], myReactions[n.ID]),
+			LastUpdated:   n.UpdatedAt,
+		}
 	}
 
 	return results, nil
@@ -0,0 +1,73 @@
+package news
+
+import (
+	"awesomeProject/internal/domain/model/comment"
+	"awesomeProject/internal/domain/model/news"
+	"awesomeProject/internal/domain/model/reaction"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var errStorage = errors.New("storage is unavailable")
+
+// deleteLog записывает, что и для какой новости удалено
+type deleteLog struct {
+	calls []string
+	fail  string
+}
+
+func (l *deleteLog) record(call string) error {
+	if call == l.fail {
+		return errStorage
+	}
+	l.calls = append(l.calls, call)
+	return nil
+}
+
+type fakeNews struct {
+	news.Repository
+	log *deleteLog
+}
+
+func (f fakeNews) Delete(uint) error { return f.log.record("news") }
+
+type fakeComments struct {
+	comment.Repository
+	log *deleteLog
+}
+
+func (f fakeComments) DeleteByNews(uint) error { return f.log.record("comments") }
+
+type fakeReactions struct {
+	reaction.Repository
+	log *deleteLog
+}
+
+func (f fakeReactions) DeleteByNews(uint) error { return f.log.record("reactions") }
+
+func TestDeleteNewsWith(t *testing.T) {
+	tests := []struct {
+		name      string
+		fail      string
+		wantCalls []string
+		wantErr   error
+	}{
+		{name: "news with comments and reactions", wantCalls: []string{"comments", "reactions", "news"}},
+		{name: "comments fail", fail: "comments", wantErr: errStorage},
+		{name: "reactions fail", fail: "reactions", wantCalls: []string{"comments"}, wantErr: errStorage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &deleteLog{fail: tt.fail}
+			err := deleteNewsWith(fakeNews{log: log}, fakeComments{log: log}, fakeReactions{log: log}, 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(log.calls, tt.wantCalls) {
+				t.Errorf("deleted %v, want %v", log.calls, tt.wantCalls)
+			}
+		})
+	}
+}
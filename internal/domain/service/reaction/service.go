@@ -0,0 +1,98 @@
+package reaction
+
+import (
+	"awesomeProject/internal/database"
+	"awesomeProject/internal/domain/model/news"
+	"awesomeProject/internal/domain/model/reaction"
+	"awesomeProject/internal/domain/model/user"
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidType = errors.New("unknown reaction type")
+
+type ReactionService struct {
+	reactionRepo reaction.Repository
+	newsRepo     news.Repository
+}
+
+// Summary - счетчики реакций статьи по типам и реакция текущего пользователя
+type Summary struct {
+	Counts     map[string]int64 `json:"counts"`
+	Total      int64            `json:"total"`
+	MyReaction *string          `json:"my_reaction"`
+}
+
+// SetReactionRequest - тело запроса на постановку реакции
+type SetReactionRequest struct {
+	Type string `json:"type" binding:"required"`
+}
+
+func NewReactionService() *ReactionService {
+	db := database.GetDB()
+	return &ReactionService{
+		reactionRepo: reaction.NewRepository(db),
+		newsRepo:     news.NewsRepository(db),
+	}
+}
+
+// SetReaction ставит реакцию пользователя на новость, заменяя предыдущую
+func (s *ReactionService) SetReaction(ctx context.Context, newsID uint, u user.User, req SetReactionRequest) (Summary, error) {
+	if !reaction.IsValidType(req.Type) {
+		return Summary{}, fmt.Errorf("%w: %s", ErrInvalidType, req.Type)
+	}
+	if _, err := s.newsRepo.FindByID(newsID); err != nil {
+		return Summary{}, err
+	}
+	r := reaction.Reaction{UserID: u.ID, NewsID: newsID, Type: req.Type}
+	if err := s.reactionRepo.Upsert(&r); err != nil {
+		return Summary{}, err
+	}
+	return s.summary(ctx, newsID, u.ID)
+}
+
+// RemoveReaction снимает реакцию пользователя; повторное снятие не считается ошибкой
+func (s *ReactionService) RemoveReaction(ctx context.Context, newsID uint, u user.User) (Summary, error) {
+	if _, err := s.newsRepo.FindByID(newsID); err != nil {
+		return Summary{}, err
+	}
+	if _, err := s.reactionRepo.Delete(u.ID, newsID); err != nil {
+		return Summary{}, err
+	}
+	return s.summary(ctx, newsID, u.ID)
+}
+
+// GetSummary возвращает счетчики реакций статьи для пользователя userID
+func (s *ReactionService) GetSummary(ctx context.Context, newsID, userID uint) (Summary, error) {
+	if _, err := s.newsRepo.FindByID(newsID); err != nil {
+		return Summary{}, err
+	}
+	return s.summary(ctx, newsID, userID)
+}
+
+func (s *ReactionService) summary(ctx context.Context, newsID, userID uint) (Summary, error) {
+	ids := []uint{newsID}
+	counts, err := s.reactionRepo.CountsByNewsIDs(ctx, ids)
+	if err != nil {
+		return Summary{}, err
+	}
+	mine, err := s.reactionRepo.TypesByUser(ctx, userID, ids)
+	if err != nil {
+		return Summary{}, err
+	}
+	return NewSummary(counts[newsID], mine[newsID]), nil
+}
+
+// NewSummary собирает Summary из счетчиков по типам; пустой myReaction означает, что реакции нет
+func NewSummary(counts map[string]int64, myReaction string) Summary {
+	summary := Summary{Counts: make(map[string]int64, len(reaction.Types))}
+	for _, t := range reaction.Types {
+		summary.Counts[t] = counts[t]
+		summary.Total += counts[t]
+	}
+	if myReaction != "" {
+		summary.MyReaction = &myReaction
+	}
+	return summary
+}
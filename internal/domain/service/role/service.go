@@ -0,0 +1,305 @@
+package role
+
+import (
+	"awesomeProject/internal/cache"
+	"awesomeProject/internal/database"
+	"awesomeProject/internal/domain/model/permission"
+	"awesomeProject/internal/domain/model/role"
+	"awesomeProject/internal/domain/model/user"
+	tokenService "awesomeProject/internal/domain/service/token"
+	"awesomeProject/internal/metrics"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrRoleExists   = errors.New("role with this name already exists")
+	ErrRoleInUse    = errors.New("role is assigned to users")
+	ErrBuiltinRole  = errors.New("built-in role cannot be renamed or deleted")
+	ErrAdminLockout = errors.New("admin role must keep roles:manage and users:manage")
+)
+
+// adminRequiredPermissions - права, без которых у роли admin никто не сможет
+// управлять ролями и пользователями, поэтому снять их с нее нельзя
+var adminRequiredPermissions = map[string]bool{
+	permission.RolesManage: true,
+	permission.UsersManage: true,
+}
+
+type RoleService struct {
+	roleRepo       role.Repository
+	permissionRepo permission.Repository
+	userRepo       user.Repository
+	revocations    *tokenService.RevocationService
+	cache          *cache.Cache
+}
+
+type CreateRoleRequest struct {
+	RoleName    string   `json:"role_name" binding:"required,max=255"`
+	Description string   `json:"description" binding:"required"`
+	Permissions []string `json:"permissions"`
+	RequireMFA  bool     `json:"require_mfa"`
+}
+
+type UpdateRoleRequest struct {
+	RoleName    *string `json:"role_name" binding:"omitempty,min=1,max=255"`
+	Description *string `json:"description" binding:"omitempty,min=1"`
+	RequireMFA  *bool   `json:"require_mfa"`
+}
+
+func NewRoleService() *RoleService {
+	db := database.GetDB()
+	return NewRoleServiceWith(role.NewRepository(db), permission.NewRepository(db), user.NewRepository(db),
+		tokenService.NewRevocationService(), cache.GetCache())
+}
+
+// NewRoleServiceWith создает сервис поверх переданных репозиториев, например в тестах
+func NewRoleServiceWith(roleRepo role.Repository, permissionRepo permission.Repository, userRepo user.Repository,
+	revocations *tokenService.RevocationService, c *cache.Cache) *RoleService {
+	return &RoleService{
+		roleRepo:       roleRepo,
+		permissionRepo: permissionRepo,
+		userRepo:       userRepo,
+		revocations:    revocations,
+		cache:          c,
+	}
+}
+
+func (s *RoleService) ListRoles() ([]role.Role, error) {
+	return s.roleRepo.FindAll()
+}
+
+func (s *RoleService) ListPermissions() ([]permission.Permission, error) {
+	return s.permissionRepo.FindAll()
+}
+
+func (s *RoleService) GetRoleByID(id uint) (role.Role, error) {
+	return s.roleRepo.FindByID(id)
+}
+
+func (s *RoleService) GetRoleByName(name string) (role.Role, error) {
+	return s.roleRepo.FindByName(name)
+}
+
+func (s *RoleService) CreateRole(req CreateRoleRequest) (role.Role, error) {
+	if _, err := s.roleRepo.FindByName(req.RoleName); err == nil {
+		return role.Role{}, ErrRoleExists
+	} else if !errors.Is(err, role.ErrNotFound) {
+		return role.Role{}, err
+	}
+
+	permissions, err := s.resolvePermissions(req.Permissions)
+	if err != nil {
+		return role.Role{}, err
+	}
+
+	newRole := role.Role{
+		RoleName:    req.RoleName,
+		Description: req.Description,
+		Permissions: permissions,
+		RequireMFA:  req.RequireMFA,
+	}
+	if err := s.roleRepo.Create(&newRole); err != nil {
+		return role.Role{}, err
+	}
+	return newRole, nil
+}
+
+// UpdateRole меняет описание и имя роли. Переименовать можно только роль,
+// которая никому не назначена, так как пользователи ссылаются на роль по имени.
+func (s *RoleService) UpdateRole(id uint, req UpdateRoleRequest) (role.Role, error) {
+	r, err := s.roleRepo.FindByID(id)
+	if err != nil {
+		return role.Role{}, err
+	}
+
+	if req.RoleName != nil && *req.RoleName != r.RoleName {
+		if isBuiltinRole(r.RoleName) {
+			return role.Role{}, ErrBuiltinRole
+		}
+		if err := s.ensureRoleUnused(r.RoleName); err != nil {
+			return role.Role{}, err
+		}
+		if _, err := s.roleRepo.FindByName(*req.RoleName); err == nil {
+			return role.Role{}, ErrRoleExists
+		} else if !errors.Is(err, role.ErrNotFound) {
+			return role.Role{}, err
+		}
+		s.invalidate(r.RoleName)
+		r.RoleName = *req.RoleName
+	}
+	if req.Description != nil {
+		r.Description = *req.Description
+	}
+	if req.RequireMFA != nil {
+		r.RequireMFA = *req.RequireMFA
+	}
+
+	if err := s.roleRepo.Update(&r); err != nil {
+		return role.Role{}, err
+	}
+	return r, nil
+}
+
+// DeleteRole удаляет роль, если она не встроенная и никому не назначена
+func (s *RoleService) DeleteRole(id uint) error {
+	r, err := s.roleRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if isBuiltinRole(r.RoleName) {
+		return ErrBuiltinRole
+	}
+	if err := s.ensureRoleUnused(r.RoleName); err != nil {
+		return err
+	}
+	if err := s.roleRepo.Delete(id); err != nil {
+		return err
+	}
+	s.invalidate(r.RoleName)
+	return nil
+}
+
+func (s *RoleService) AttachPermission(roleID uint, name string) (role.Role, error) {
+	return s.changePermission(roleID, name, s.roleRepo.AddPermission)
+}
+
+// DetachPermission снимает право с роли. Access-токены пользователей роли несут права
+// в claim perms, поэтому после изменения они отзываются: новые токены получат уже новый набор.
+func (s *RoleService) DetachPermission(roleID uint, name string) (role.Role, error) {
+	updated, err := s.changePermission(roleID, name, func(r *role.Role, p *permission.Permission) error {
+		if r.RoleName == role.Admin && adminRequiredPermissions[p.Name] {
+			return ErrAdminLockout
+		}
+		return s.roleRepo.RemovePermission(r, p)
+	})
+	if err != nil {
+		return role.Role{}, err
+	}
+	if err := s.revokeRoleTokens(updated.RoleName); err != nil {
+		return role.Role{}, err
+	}
+	return updated, nil
+}
+
+// revokeRoleTokens отзывает access-токены всех пользователей роли
+func (s *RoleService) revokeRoleTokens(roleName string) error {
+	ids, err := s.userRepo.FindIDsByRole(roleName)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := s.revocations.RevokeAllForUser(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *RoleService) changePermission(roleID uint, name string, change func(*role.Role, *permission.Permission) error) (role.Role, error) {
+	r, err := s.roleRepo.FindByID(roleID)
+	if err != nil {
+		return role.Role{}, err
+	}
+	p, err := s.permissionRepo.FindByName(name)
+	if err != nil {
+		return role.Role{}, err
+	}
+	if err := change(&r, &p); err != nil {
+		return role.Role{}, err
+	}
+	s.invalidate(r.RoleName)
+	return s.roleRepo.FindByID(roleID)
+}
+
+func (s *RoleService) resolvePermissions(names []string) ([]permission.Permission, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	permissions, err := s.permissionRepo.FindByNames(names)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(permissions))
+	for _, p := range permissions {
+		found[p.Name] = true
+	}
+	for _, name := range names {
+		if !found[name] {
+			return nil, fmt.Errorf("%w: %s", permission.ErrNotFound, name)
+		}
+	}
+	return permissions, nil
+}
+
+func (s *RoleService) ensureRoleUnused(roleName string) error {
+	count, err := s.userRepo.CountByRole(roleName)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrRoleInUse
+	}
+	return nil
+}
+
+// invalidate сбрасывает закэшированные права роли
+func (s *RoleService) invalidate(roleName string) {
+	s.cache.Delete(permissionsCacheKey(roleName))
+}
+
+func isBuiltinRole(roleName string) bool {
+	return roleName == role.Admin || roleName == role.Editor || roleName == role.Reader
+}
+
+// PermissionsForRole возвращает имена прав роли. Для неизвестной роли возвращается пустой список.
+func (s *RoleService) PermissionsForRole(roleName string) ([]string, error) {
+	cacheKey := permissionsCacheKey(roleName)
+	if cached, ok := s.cache.Get(cacheKey); ok {
+		metrics.RecordCacheHit()
+		return cached.([]string), nil
+	}
+	metrics.RecordCacheMiss()
+
+	r, err := s.roleRepo.FindByName(roleName)
+	if err != nil {
+		if errors.Is(err, role.ErrNotFound) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	permissions := r.PermissionNames()
+	s.cache.Set(cacheKey, permissions)
+	return permissions, nil
+}
+
+// HasPermission проверяет, есть ли у роли указанное право
+func (s *RoleService) HasPermission(roleName, permission string) (bool, error) {
+	permissions, err := s.PermissionsForRole(roleName)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range permissions {
+		if p == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RequiresMFA сообщает, обязана ли роль входить с двухфакторной аутентификацией
+func (s *RoleService) RequiresMFA(roleName string) (bool, error) {
+	r, err := s.roleRepo.FindByName(roleName)
+	if err != nil {
+		if errors.Is(err, role.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return r.RequireMFA, nil
+}
+
+func permissionsCacheKey(roleName string) string {
+	return fmt.Sprintf("role:permissions:%s", roleName)
+}
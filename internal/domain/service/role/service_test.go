@@ -0,0 +1,140 @@
+package role
+
+import (
+	"awesomeProject/internal/cache"
+	"awesomeProject/internal/domain/model/permission"
+	"awesomeProject/internal/domain/model/revoked_token"
+	"awesomeProject/internal/domain/model/role"
+	"awesomeProject/internal/domain/model/user"
+	tokenService "awesomeProject/internal/domain/service/token"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeRoles хранит роли в памяти вместе с их правами
+type fakeRoles struct {
+	role.Repository
+	items map[uint]*role.Role
+}
+
+func (f *fakeRoles) FindByID(id uint) (role.Role, error) {
+	r, ok := f.items[id]
+	if !ok {
+		return role.Role{}, role.ErrNotFound
+	}
+	return *r, nil
+}
+
+func (f *fakeRoles) RemovePermission(r *role.Role, p *permission.Permission) error {
+	stored := f.items[r.ID]
+	kept := stored.Permissions[:0]
+	for _, existing := range stored.Permissions {
+		if existing.Name != p.Name {
+			kept = append(kept, existing)
+		}
+	}
+	stored.Permissions = kept
+	return nil
+}
+
+type fakePermissions struct{ permission.Repository }
+
+func (fakePermissions) FindByName(name string) (permission.Permission, error) {
+	return permission.Permission{Name: name}, nil
+}
+
+// fakeUsers знает только, у каких пользователей какая роль
+type fakeUsers struct {
+	user.Repository
+	byRole map[string][]uint
+}
+
+func (f fakeUsers) FindIDsByRole(roleName string) ([]uint, error) {
+	return f.byRole[roleName], nil
+}
+
+// fakeRevoked запоминает пользователей, чьи токены отозваны
+type fakeRevoked struct {
+	revoked_token.Repository
+	users []uint
+}
+
+func (f *fakeRevoked) RevokeAllBefore(userID uint, _ time.Time) error {
+	f.users = append(f.users, userID)
+	return nil
+}
+
+func withPermissions(id uint, name string, permissions ...string) *role.Role {
+	r := &role.Role{RoleName: name}
+	r.ID = id
+	for _, p := range permissions {
+		r.Permissions = append(r.Permissions, permission.Permission{Name: p})
+	}
+	return r
+}
+
+func TestDetachPermission(t *testing.T) {
+	tests := []struct {
+		name        string
+		roleID      uint
+		permission  string
+		wantErr     error
+		wantRevoked []uint
+		wantLeft    []string
+	}{
+		{
+			name:        "editor loses news:write",
+			roleID:      2,
+			permission:  permission.NewsWrite,
+			wantRevoked: []uint{20, 21},
+			wantLeft:    []string{permission.NewsRead},
+		},
+		{
+			name:        "admin loses users:read",
+			roleID:      1,
+			permission:  permission.UsersRead,
+			wantRevoked: []uint{10},
+			wantLeft:    []string{permission.UsersManage, permission.RolesManage},
+		},
+		{name: "admin keeps roles:manage", roleID: 1, permission: permission.RolesManage, wantErr: ErrAdminLockout},
+		{name: "admin keeps users:manage", roleID: 1, permission: permission.UsersManage, wantErr: ErrAdminLockout},
+		{
+			name:       "custom role may lose roles:manage",
+			roleID:     3,
+			permission: permission.RolesManage,
+			wantLeft:   []string{},
+		},
+		{name: "unknown role", roleID: 9, permission: permission.NewsRead, wantErr: role.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roles := &fakeRoles{items: map[uint]*role.Role{
+				1: withPermissions(1, role.Admin, permission.UsersRead, permission.UsersManage, permission.RolesManage),
+				2: withPermissions(2, role.Editor, permission.NewsRead, permission.NewsWrite),
+				3: withPermissions(3, "auditor", permission.RolesManage),
+			}}
+			users := fakeUsers{byRole: map[string][]uint{role.Admin: {10}, role.Editor: {20, 21}}}
+			revoked := &fakeRevoked{}
+			c := cache.GetCache()
+			c.Clear()
+			s := NewRoleServiceWith(roles, fakePermissions{}, users, tokenService.NewRevocationServiceWith(revoked, c), c)
+
+			updated, err := s.DetachPermission(tt.roleID, tt.permission)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(revoked.users, tt.wantRevoked) {
+				t.Errorf("revoked tokens of %v, want %v", revoked.users, tt.wantRevoked)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if left := updated.PermissionNames(); !reflect.DeepEqual(left, tt.wantLeft) {
+				t.Errorf("permissions = %v, want %v", left, tt.wantLeft)
+			}
+		})
+	}
+}
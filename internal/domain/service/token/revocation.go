@@ -0,0 +1,127 @@
+package token
+
+import (
+	"awesomeProject/internal/cache"
+	"awesomeProject/internal/database"
+	"awesomeProject/internal/domain/model/revoked_token"
+	"awesomeProject/internal/metrics"
+	"fmt"
+	"time"
+)
+
+// RevocationService хранит отозванные access-токены в Postgres
+// и кэширует результаты проверок, чтобы middleware не ходил в базу на каждый запрос.
+type RevocationService struct {
+	repo  revoked_token.Repository
+	cache *cache.Cache
+}
+
+func NewRevocationService() *RevocationService {
+	return NewRevocationServiceWith(revoked_token.NewRepository(database.GetDB()), cache.GetCache())
+}
+
+// NewRevocationServiceWith создает сервис поверх заданного хранилища и кэша
+func NewRevocationServiceWith(repo revoked_token.Repository, c *cache.Cache) *RevocationService {
+	return &RevocationService{repo: repo, cache: c}
+}
+
+// RevokeToken отзывает один токен до момента его естественного истечения
+func (s *RevocationService) RevokeToken(jti string, userID uint, expiresAt time.Time) error {
+	if err := s.repo.Create(&revoked_token.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return err
+	}
+	s.cache.Set(jtiCacheKey(jti), true)
+	return nil
+}
+
+// RevokeAllForUser отзывает все токены пользователя, выданные до текущего момента.
+// Время округляется до секунд, так как claim iat хранится с точностью до секунды.
+func (s *RevocationService) RevokeAllForUser(userID uint) error {
+	before := time.Now().Truncate(time.Second)
+	if err := s.repo.RevokeAllBefore(userID, before); err != nil {
+		return err
+	}
+	s.cache.Set(userCacheKey(userID), before)
+	return nil
+}
+
+// RevokeAllForUserIn записывает отзыв всех токенов пользователя через repo, например внутри транзакции.
+// Кэш не меняется: после фиксации транзакции нужно вызвать ForgetUser.
+func (s *RevocationService) RevokeAllForUserIn(repo revoked_token.Repository, userID uint) error {
+	return repo.RevokeAllBefore(userID, time.Now().Truncate(time.Second))
+}
+
+// RevokeTokenIn записывает отзыв одного токена через repo, например внутри транзакции.
+// Кэш не меняется: после фиксации транзакции нужно вызвать ForgetTokens.
+func (s *RevocationService) RevokeTokenIn(repo revoked_token.Repository, jti string, userID uint, expiresAt time.Time) error {
+	return repo.Create(&revoked_token.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// ForgetUser сбрасывает кэшированный результат проверки массового отзыва токенов пользователя
+func (s *RevocationService) ForgetUser(userID uint) {
+	s.cache.Delete(userCacheKey(userID))
+}
+
+// ForgetTokens сбрасывает кэшированные результаты проверки токенов jtis
+func (s *RevocationService) ForgetTokens(jtis ...string) {
+	for _, jti := range jtis {
+		s.cache.Delete(jtiCacheKey(jti))
+	}
+}
+
+// IsRevoked проверяет, отозван ли токен по jti или массовым отзывом всех токенов пользователя
+func (s *RevocationService) IsRevoked(jti string, userID uint, issuedAt time.Time) (bool, error) {
+	revokedBefore, err := s.revokedBefore(userID)
+	if err != nil {
+		return false, err
+	}
+	if !revokedBefore.IsZero() && issuedAt.Before(revokedBefore) {
+		return true, nil
+	}
+
+	key := jtiCacheKey(jti)
+	if cached, ok := s.cache.Get(key); ok {
+		metrics.RecordCacheHit()
+		return cached.(bool), nil
+	}
+	metrics.RecordCacheMiss()
+
+	revoked, err := s.repo.ExistsByJTI(jti)
+	if err != nil {
+		return false, err
+	}
+	s.cache.Set(key, revoked)
+	return revoked, nil
+}
+
+func (s *RevocationService) revokedBefore(userID uint) (time.Time, error) {
+	key := userCacheKey(userID)
+	if cached, ok := s.cache.Get(key); ok {
+		metrics.RecordCacheHit()
+		return cached.(time.Time), nil
+	}
+	metrics.RecordCacheMiss()
+
+	before, err := s.repo.FindRevokedBefore(userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	s.cache.Set(key, before)
+	return before, nil
+}
+
+func jtiCacheKey(jti string) string {
+	return fmt.Sprintf("revoked:jti:%s", jti)
+}
+
+func userCacheKey(userID uint) string {
+	return fmt.Sprintf("revoked:user:%d", userID)
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"awesomeProject/internal/database"
+	"awesomeProject/internal/domain/model/audit"
+	"awesomeProject/internal/domain/model/role"
+	"awesomeProject/internal/domain/model/user"
+	"awesomeProject/internal/domain/model/user_deleted"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Массовые действия над пользователями
+const (
+	BulkActivate   = "activate"
+	BulkDeactivate = "deactivate"
+	BulkChangeRole = "change_role"
+	BulkDelete     = "delete"
+)
+
+var (
+	ErrBulkRoleRequired = errors.New("role is required for change_role")
+	ErrBulkSelf         = errors.New("cannot apply this action to your own account")
+)
+
+// bulkAuditActions - запись журнала аудита для каждого массового действия
+var bulkAuditActions = map[string]string{
+	BulkActivate:   audit.ActionUserActivate,
+	BulkDeactivate: audit.ActionUserDeactivate,
+	BulkChangeRole: audit.ActionUserRoleChange,
+	BulkDelete:     audit.ActionUserDelete,
+}
+
+// BulkRequest - тело запроса на массовое действие. Role нужна только для change_role.
+type BulkRequest struct {
+	Action  string `json:"action" binding:"required,oneof=activate deactivate change_role delete"`
+	UserIDs []uint `json:"user_ids" binding:"required,min=1,max=100"`
+	Role    string `json:"role"`
+}
+
+// BulkItemResult - результат действия над одним пользователем. Warning означает,
+// что изменение зафиксировано, но отозвать токены и сессии после фиксации не удалось.
+type BulkItemResult struct {
+	UserID  uint   `json:"user_id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+	Warning string `json:"warning,omitempty"`
+}
+
+// BulkReport - отчет о массовом действии по каждому пользователю
+type BulkReport struct {
+	Action    string           `json:"action"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Warnings  int              `json:"warnings"`
+	Results   []BulkItemResult `json:"results"`
+}
+
+// BulkUpdate применяет действие к списку пользователей в одной транзакции.
+// Каждый пользователь обрабатывается в своей точке сохранения: ошибка откатывает
+// только его изменения и попадает в отчет, остальные изменения фиксируются.
+// Удаленные пользователи теряют доступ в той же транзакции, а токены и сессии
+// остальных затронутых пользователей отзываются уже после ее фиксации; ошибки
+// этого шага попадают в Warning результата пользователя.
+func (s *UserService) BulkUpdate(actorID uint, req BulkRequest, client ClientInfo) (BulkReport, error) {
+	if req.Action == BulkChangeRole {
+		if req.Role == "" {
+			return BulkReport{}, ErrBulkRoleRequired
+		}
+		if _, err := s.roles.GetRoleByName(req.Role); err != nil {
+			return BulkReport{}, err
+		}
+	}
+
+	report := BulkReport{Action: req.Action, Results: []BulkItemResult{}}
+	var applied []bulkApplied
+	seen := make(map[uint]bool, len(req.UserIDs))
+	now := time.Now()
+
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		for _, id := range req.UserIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			savepoint := fmt.Sprintf("bulk_user_%d", id)
+			if err := tx.SavePoint(savepoint).Error; err != nil {
+				return err
+			}
+			result, err := s.applyBulkAction(tx, actorID, id, req, now, client)
+			if err != nil {
+				if err := tx.RollbackTo(savepoint).Error; err != nil {
+					return err
+				}
+				report.Results = append(report.Results, BulkItemResult{UserID: id, Error: err.Error()})
+				report.Failed++
+				continue
+			}
+			result.item = len(report.Results)
+			applied = append(applied, result)
+			report.Results = append(report.Results, BulkItemResult{UserID: id, Success: true})
+			report.Succeeded++
+		}
+		return nil
+	})
+	if err != nil {
+		return BulkReport{}, err
+	}
+
+	s.finishBulkActions(&report, req.Action, applied)
+	return report, nil
+}
+
+// bulkApplied - пользователь, к которому применено действие, jti токенов имперсонации,
+// отозванных при его удалении, и номер его результата в отчете
+type bulkApplied struct {
+	user        user.User
+	revokedJTIs []string
+	item        int
+}
+
+// finishBulkActions выполняет шаги после фиксации транзакции и отмечает в отчете
+// пользователей, у которых не удалось отозвать доступ
+func (s *UserService) finishBulkActions(report *BulkReport, action string, applied []bulkApplied) {
+	for _, result := range applied {
+		if err := s.afterBulkAction(action, result); err != nil {
+			log.Printf("Failed to revoke access of user %d after %s: %v", result.user.ID, action, err)
+			report.Results[result.item].Warning = "change applied, but revoking access failed: " + err.Error()
+			report.Warnings++
+		}
+	}
+}
+
+// applyBulkAction выполняет действие над одним пользователем внутри транзакции tx
+func (s *UserService) applyBulkAction(tx *gorm.DB, actorID, userID uint, req BulkRequest, now time.Time, client ClientInfo) (bulkApplied, error) {
+	if userID == actorID && req.Action != BulkActivate {
+		return bulkApplied{}, ErrBulkSelf
+	}
+
+	users := user.NewRepository(tx)
+	u, err := users.FindByID(userID)
+	if err != nil {
+		return bulkApplied{}, err
+	}
+
+	details := ""
+	var revokedJTIs []string
+	switch req.Action {
+	case BulkActivate, BulkDeactivate:
+		active := req.Action == BulkActivate
+		if err := users.UpdateFields(u.ID, map[string]interface{}{
+			"is_active":    active,
+			"is_active_at": now,
+		}); err != nil {
+			return bulkApplied{}, err
+		}
+		u.IsActive = active
+		u.IsActive_at = now
+	case BulkChangeRole:
+		if u.Role == role.Admin && req.Role != role.Admin {
+			if err := ensureNotLastAdmin(users, "demote"); err != nil {
+				return bulkApplied{}, err
+			}
+		}
+		if err := users.UpdateRole(u.ID, req.Role); err != nil {
+			return bulkApplied{}, err
+		}
+		details = fmt.Sprintf("role: %s -> %s", u.Role, req.Role)
+		u.Role = req.Role
+	case BulkDelete:
+		if _, err := s.archiveUser(users, user_deleted.NewRepository(tx), u.ID); err != nil {
+			return bulkApplied{}, err
+		}
+		if revokedJTIs, err = s.revokeDeletedUser(newAccessRepos(tx), u.ID); err != nil {
+			return bulkApplied{}, err
+		}
+	}
+
+	subjectID := u.ID
+	if err := audit.NewRepository(tx).Create(&audit.Event{
+		ActorID:   actorID,
+		SubjectID: &subjectID,
+		Action:    bulkAuditActions[req.Action],
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Details:   details,
+	}); err != nil {
+		return bulkApplied{}, err
+	}
+	return bulkApplied{user: u, revokedJTIs: revokedJTIs}, nil
+}
+
+// afterBulkAction сбрасывает кэш пользователя и отзывает доступ, который больше не должен действовать
+func (s *UserService) afterBulkAction(action string, result bulkApplied) error {
+	u := result.user
+	switch action {
+	case BulkDeactivate:
+		s.invalidateUserCache(u)
+		return s.LogoutAll(u.ID)
+	case BulkChangeRole:
+		s.invalidateUserCache(u)
+		return s.revocations.RevokeAllForUser(u.ID)
+	case BulkDelete:
+		s.forgetDeletedUser(u, result.revokedJTIs)
+	default:
+		s.invalidateUserCache(u)
+	}
+	return nil
+}
+
+// ensureNotLastAdmin блокирует строки администраторов до конца транзакции
+// и возвращает ErrLastAdmin, если администратор остался один. action попадает
+// в текст ошибки: "demote" или "delete".
+func ensureNotLastAdmin(users user.Repository, action string) error {
+	admins, err := users.LockIDsByRole(role.Admin)
+	if err != nil {
+		return err
+	}
+	if len(admins) <= 1 {
+		return fmt.Errorf("cannot %s the last admin: %w", action, ErrLastAdmin)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"awesomeProject/internal/domain/model/refresh_token"
+	"awesomeProject/internal/domain/model/revoked_token"
+	"awesomeProject/internal/domain/model/role"
+	"awesomeProject/internal/domain/model/user"
+	tokenService "awesomeProject/internal/domain/service/token"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var errStorage = errors.New("storage is unavailable")
+
+// failingRevoked и failingRefreshTokens не могут отозвать токены
+type failingRevoked struct{ revoked_token.Repository }
+
+func (failingRevoked) RevokeAllBefore(uint, time.Time) error { return errStorage }
+
+type failingRefreshTokens struct{ refresh_token.Repository }
+
+func (failingRefreshTokens) RevokeAllForUser(uint) error { return errStorage }
+
+func TestFinishBulkActions(t *testing.T) {
+	tests := []struct {
+		name        string
+		action      string
+		setup       func(s *UserService)
+		wantWarning bool
+	}{
+		{name: "activate", action: BulkActivate},
+		{name: "change role", action: BulkChangeRole},
+		{name: "deactivate", action: BulkDeactivate},
+		{
+			name:   "change role without token revocation",
+			action: BulkChangeRole,
+			setup: func(s *UserService) {
+				s.revocations = tokenService.NewRevocationServiceWith(failingRevoked{}, s.cache)
+			},
+			wantWarning: true,
+		},
+		{
+			name:        "deactivate without logout",
+			action:      BulkDeactivate,
+			setup:       func(s *UserService) { s.refreshTokenRepo = failingRefreshTokens{} },
+			wantWarning: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newImpersonationFixture(t, userWithRole(1, role.Admin), userWithRole(2, role.Reader)).service
+			if tt.setup != nil {
+				tt.setup(s)
+			}
+			report := BulkReport{Action: tt.action, Succeeded: 2, Results: []BulkItemResult{
+				{UserID: 1, Success: true},
+				{UserID: 2, Success: true},
+			}}
+			applied := []bulkApplied{{user: userWithRole(2, role.Reader), item: 1}}
+
+			s.finishBulkActions(&report, tt.action, applied)
+
+			if report.Results[0].Warning != "" {
+				t.Errorf("untouched user got warning %q", report.Results[0].Warning)
+			}
+			got := report.Results[1]
+			if !got.Success || report.Succeeded != 2 || report.Failed != 0 {
+				t.Errorf("committed change must stay successful: %+v", report)
+			}
+			if tt.wantWarning {
+				if report.Warnings != 1 || !strings.Contains(got.Warning, errStorage.Error()) {
+					t.Errorf("warnings = %d, warning = %q, want the revocation error", report.Warnings, got.Warning)
+				}
+				return
+			}
+			if report.Warnings != 0 || got.Warning != "" {
+				t.Errorf("warnings = %d, warning = %q, want none", report.Warnings, got.Warning)
+			}
+		})
+	}
+}
+
+func TestEnsureNotLastAdmin(t *testing.T) {
+	tests := []struct {
+		name    string
+		admins  int
+		action  string
+		wantMsg string
+	}{
+		{name: "two admins", admins: 2, action: "delete"},
+		{name: "demote last admin", admins: 1, action: "demote", wantMsg: "cannot demote the last admin"},
+		{name: "delete last admin", admins: 1, action: "delete", wantMsg: "cannot delete the last admin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var users []user.User
+			for id := 1; id <= tt.admins; id++ {
+				users = append(users, userWithRole(uint(id), role.Admin))
+			}
+
+			err := ensureNotLastAdmin(newFakeUsers(users...), tt.action)
+			if tt.wantMsg == "" {
+				if err != nil {
+					t.Fatalf("ensureNotLastAdmin: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrLastAdmin) {
+				t.Fatalf("err = %v, want ErrLastAdmin", err)
+			}
+			if !strings.HasPrefix(err.Error(), tt.wantMsg) {
+				t.Errorf("message = %q, want it to start with %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
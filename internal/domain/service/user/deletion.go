@@ -0,0 +1,136 @@
+package service
+
+import (
+	"awesomeProject/internal/database"
+	"awesomeProject/internal/domain/model/role"
+	"awesomeProject/internal/domain/model/user"
+	"awesomeProject/internal/domain/model/user_deleted"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// anonymizeBatchSize - сколько архивных пользователей обезличивается за один проход
+const anonymizeBatchSize = 100
+
+// DeleteUser переносит пользователя в архив users_deleted_struct и отзывает все его
+// токены, сессии и ключи API. До истечения USER_DELETION_GRACE_PERIOD пользователя можно восстановить.
+// Последнего администратора удалить нельзя.
+func (s *UserService) DeleteUser(id uint) error {
+	var archived user_deleted.UserDeleted
+	var jtis []string
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		var err error
+		archived, err = s.archiveUser(user.NewRepository(tx), user_deleted.NewRepository(tx), id)
+		if err != nil {
+			return err
+		}
+		jtis, err = s.revokeDeletedUser(newAccessRepos(tx), id)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.forgetDeletedUser(archived.User, jtis)
+	return nil
+}
+
+// archiveUser переносит пользователя в архив внутри транзакции, в которой созданы users и deleted
+func (s *UserService) archiveUser(users user.Repository, deleted user_deleted.Repository, id uint) (user_deleted.UserDeleted, error) {
+	u, err := users.FindByID(id)
+	if err != nil {
+		return user_deleted.UserDeleted{}, err
+	}
+	if u.Role == role.Admin {
+		if err := ensureNotLastAdmin(users, "delete"); err != nil {
+			return user_deleted.UserDeleted{}, err
+		}
+	}
+	return deleted.Archive(id, time.Now().Add(s.config.UserDeletion.GracePeriod))
+}
+
+// revokeDeletedUser отзывает токены, сессии, ключи API и выданные им токены имперсонации
+// удаленного пользователя. repos создаются в транзакции удаления, поэтому при ошибке
+// пользователь остается на месте вместе со своим доступом. Возвращает jti отозванных токенов имперсонации.
+func (s *UserService) revokeDeletedUser(repos accessRepos, id uint) ([]string, error) {
+	if err := repos.refreshTokens.RevokeAllForUser(id); err != nil {
+		return nil, err
+	}
+	if err := repos.sessions.RevokeAllForUser(id); err != nil {
+		return nil, err
+	}
+	if err := repos.apiKeys.RevokeAllForUser(id); err != nil {
+		return nil, err
+	}
+	if err := s.revocations.RevokeAllForUserIn(repos.revoked, id); err != nil {
+		return nil, err
+	}
+	return s.endImpersonations(repos, id)
+}
+
+// forgetDeletedUser сбрасывает кэш удаленного пользователя и проверок его токенов
+// после фиксации транзакции удаления
+func (s *UserService) forgetDeletedUser(u user.User, jtis []string) {
+	s.invalidateUserCache(u)
+	s.revocations.ForgetUser(u.ID)
+	s.revocations.ForgetTokens(jtis...)
+}
+
+// RestoreUser возвращает удаленного пользователя из архива под прежним ID.
+// Сессии и ключи API, отозванные при удалении, не восстанавливаются.
+func (s *UserService) RestoreUser(id uint) (user.User, error) {
+	restored, err := s.deletedRepo.Restore(id)
+	if err != nil {
+		return user.User{}, err
+	}
+	s.invalidateUserCache(restored)
+	return restored, nil
+}
+
+// ListDeletedUsers возвращает архив удаленных пользователей, последние удаленные первыми
+func (s *UserService) ListDeletedUsers() ([]user_deleted.UserDeleted, error) {
+	return s.deletedRepo.FindAll()
+}
+
+// AnonymizeExpiredUsers обезличивает архивных пользователей, чей срок хранения истек:
+// стирает данные профиля, IP и user agent из истории входов, 2FA и связи с внешними провайдерами
+func (s *UserService) AnonymizeExpiredUsers() (int, error) {
+	now := time.Now()
+	due, err := s.deletedRepo.FindDueForAnonymization(now, anonymizeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for i, archived := range due {
+		if err := s.sessionRepo.AnonymizeForUser(archived.UserID); err != nil {
+			return i, err
+		}
+		if err := s.mfaRepo.Delete(archived.UserID); err != nil {
+			return i, err
+		}
+		if err := s.identityRepo.DeleteByUser(archived.UserID); err != nil {
+			return i, err
+		}
+		if err := s.deletedRepo.Anonymize(archived.ID, now); err != nil {
+			return i, err
+		}
+	}
+	return len(due), nil
+}
+
+// StartAnonymizer периодически обезличивает архивных пользователей в фоне
+func (s *UserService) StartAnonymizer() {
+	go func() {
+		ticker := time.NewTicker(s.config.UserDeletion.SweepInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			count, err := s.AnonymizeExpiredUsers()
+			if err != nil {
+				log.Printf("Failed to anonymize deleted users: %v", err)
+			}
+			if count > 0 {
+				log.Printf("Anonymized %d deleted users", count)
+			}
+		}
+	}()
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"awesomeProject/internal/domain/model/api_key"
+	"awesomeProject/internal/domain/model/audit"
+	"awesomeProject/internal/domain/model/impersonation"
+	"awesomeProject/internal/domain/model/refresh_token"
+	"awesomeProject/internal/domain/model/session"
+	tokenService "awesomeProject/internal/domain/service/token"
+	"errors"
+	"testing"
+	"time"
+)
+
+// revokedBy записывает, у какого пользователя что отозвано, и может вернуть ошибку
+type revokedBy struct {
+	users []uint
+	err   error
+}
+
+func (r *revokedBy) revoke(userID uint) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.users = append(r.users, userID)
+	return nil
+}
+
+type recordingRefreshTokens struct {
+	refresh_token.Repository
+	revokedBy
+}
+
+func (r *recordingRefreshTokens) RevokeAllForUser(userID uint) error { return r.revoke(userID) }
+
+type recordingSessions struct {
+	session.Repository
+	revokedBy
+}
+
+func (r *recordingSessions) RevokeAllForUser(userID uint) error { return r.revoke(userID) }
+
+type recordingAPIKeys struct {
+	api_key.Repository
+	revokedBy
+}
+
+func (r *recordingAPIKeys) RevokeAllForUser(userID uint) error { return r.revoke(userID) }
+
+func TestRevokeDeletedUser(t *testing.T) {
+	errFailed := errors.New("write failed")
+
+	tests := []struct {
+		name    string
+		fail    string
+		wantErr error
+	}{
+		{name: "everything is revoked"},
+		{name: "refresh tokens fail", fail: "refresh_tokens", wantErr: errFailed},
+		{name: "sessions fail", fail: "sessions", wantErr: errFailed},
+		{name: "api keys fail", fail: "api_keys", wantErr: errFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t, newFakeUsers())
+			s.revocations = tokenService.NewRevocationServiceWith(&fakeRevoked{}, s.cache)
+			repos := accessRepos{
+				refreshTokens: &recordingRefreshTokens{},
+				sessions:      &recordingSessions{},
+				apiKeys:       &recordingAPIKeys{},
+				revoked:       &fakeRevoked{},
+				impersonations: &fakeImpersonations{items: []impersonation.Impersonation{
+					{JTI: "by-deleted", ActorID: 1, SubjectID: 2, ExpiresAt: time.Now().Add(time.Minute)},
+					{JTI: "by-other", ActorID: 3, SubjectID: 2, ExpiresAt: time.Now().Add(time.Minute)},
+				}},
+				audit: &fakeAudit{},
+			}
+			switch tt.fail {
+			case "refresh_tokens":
+				repos.refreshTokens.(*recordingRefreshTokens).err = errFailed
+			case "sessions":
+				repos.sessions.(*recordingSessions).err = errFailed
+			case "api_keys":
+				repos.apiKeys.(*recordingAPIKeys).err = errFailed
+			}
+
+			jtis, err := s.revokeDeletedUser(repos, 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			revoked := map[string][]uint{
+				"refresh tokens": repos.refreshTokens.(*recordingRefreshTokens).users,
+				"sessions":       repos.sessions.(*recordingSessions).users,
+				"api keys":       repos.apiKeys.(*recordingAPIKeys).users,
+				"access tokens":  repos.revoked.(*fakeRevoked).users,
+			}
+			for name, users := range revoked {
+				if len(users) != 1 || users[0] != 1 {
+					t.Errorf("%s revoked for %v, want [1]", name, users)
+				}
+			}
+			if len(jtis) != 1 || jtis[0] != "by-deleted" {
+				t.Errorf("impersonation tokens revoked = %v, want [by-deleted]", jtis)
+			}
+			if got := repos.revoked.(*fakeRevoked).jtis; len(got) != 1 || got[0] != "by-deleted" {
+				t.Errorf("revoked jtis = %v, want [by-deleted]", got)
+			}
+			if got := repos.audit.(*fakeAudit).actions(); len(got) != 1 || got[0] != audit.ActionImpersonationStop {
+				t.Errorf("audit = %v, want [%s]", got, audit.ActionImpersonationStop)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"awesomeProject/internal/database"
+	"awesomeProject/internal/domain/model/upload"
+	"awesomeProject/internal/domain/model/user"
+	"awesomeProject/internal/export"
+	"awesomeProject/internal/mailer"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// purgeExportsBatchSize - сколько просроченных архивов удаляется за один проход
+const purgeExportsBatchSize = 100
+
+const exportDownloadPurpose = "export-download"
+
+var (
+	ErrExportInProgress  = errors.New("data export is already in progress")
+	ErrExportExpired     = errors.New("data export has expired")
+	ErrInvalidExportLink = errors.New("invalid or expired download link")
+)
+
+// RequestExport запускает в фоне сборку архива со всеми данными пользователя.
+// Когда архив готов, он сохраняется как загрузка со сроком хранения EXPORT_TTL,
+// а пользователю отправляется письмо со ссылкой на скачивание.
+func (s *UserService) RequestExport(userID uint) error {
+	u, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if _, running := s.exportsInProgress.LoadOrStore(u.ID, true); running {
+		return ErrExportInProgress
+	}
+
+	go func() {
+		defer s.exportsInProgress.Delete(u.ID)
+		if err := s.buildExport(u); err != nil {
+			log.Printf("Failed to export data of user %d: %v", u.ID, err)
+		}
+	}()
+	return nil
+}
+
+// ListExports возвращает еще не удаленные архивы выгрузки пользователя, новые первыми.
+// Путь к файлу на сервере в ответ не попадает.
+func (s *UserService) ListExports(userID uint) ([]upload.Upload, error) {
+	exports, err := s.uploadRepo.FindByUser(userID, upload.TypeExport)
+	if err != nil {
+		return nil, err
+	}
+	for i := range exports {
+		exports[i].Path = ""
+	}
+	return exports, nil
+}
+
+// GetExport возвращает архив выгрузки пользователя для скачивания.
+// Чужие архивы и загрузки другого типа не находятся.
+func (s *UserService) GetExport(userID, exportID uint) (upload.Upload, error) {
+	u, err := s.uploadRepo.FindByID(exportID)
+	if err != nil {
+		return upload.Upload{}, err
+	}
+	if u.UserID != userID || u.Type != upload.TypeExport {
+		return upload.Upload{}, upload.ErrNotFound
+	}
+	if u.IsExpired(time.Now()) {
+		return upload.Upload{}, ErrExportExpired
+	}
+	return u, nil
+}
+
+// GetExportByLink возвращает архив по токену из письма. Ссылка действует до удаления архива
+// и перестает работать после смены email или деактивации аккаунта.
+func (s *UserService) GetExportByLink(token string) (upload.Upload, error) {
+	payload, err := parseSignedToken(s.config.Export.LinkSecret, exportDownloadPurpose, token, time.Now())
+	if err != nil {
+		return upload.Upload{}, ErrInvalidExportLink
+	}
+
+	u, err := s.userRepo.FindByID(payload.UserID)
+	if err != nil {
+		if errors.Is(err, user.ErrNotFound) {
+			return upload.Upload{}, ErrInvalidExportLink
+		}
+		return upload.Upload{}, err
+	}
+	if u.Email != payload.Email || !u.IsActive {
+		return upload.Upload{}, ErrInvalidExportLink
+	}
+	return s.GetExport(u.ID, payload.ResourceID)
+}
+
+// exportDownloadLink возвращает ссылку на скачивание архива, подписанную на срок его хранения
+func (s *UserService) exportDownloadLink(u user.User, archive upload.Upload) (string, error) {
+	token, err := signToken(s.config.Export.LinkSecret, signedTokenPayload{
+		Purpose:    exportDownloadPurpose,
+		UserID:     u.ID,
+		Email:      u.Email,
+		ExpiresAt:  archive.ExpiresAt.Unix(),
+		ResourceID: archive.ID,
+	})
+	if err != nil {
+		return "", err
+	}
+	return s.config.Verification.BaseURL + "/exports/download?token=" + url.QueryEscape(token), nil
+}
+
+// buildExport собирает архив, сохраняет его и уведомляет пользователя
+func (s *UserService) buildExport(u user.User) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Export.Timeout)
+	defer cancel()
+
+	if err := os.MkdirAll(s.config.Export.Dir, 0o700); err != nil {
+		return err
+	}
+	name, err := randomToken(16)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.config.Export.Dir, name+".zip")
+
+	if err := writeExport(ctx, u.ID, path); err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.config.Export.TTL)
+	archive := upload.Upload{
+		UserID:      u.ID,
+		Title:       "Personal data export",
+		Author:      u.Name,
+		File:        fmt.Sprintf("export-%d-%s.zip", u.ID, now.Format("20060102T150405")),
+		Description: "Archive with all personal data linked to the account",
+		Content:     "application/zip",
+		Type:        upload.TypeExport,
+		Path:        path,
+		ExpiresAt:   &expiresAt,
+	}
+	if err := s.uploadRepo.Create(&archive); err != nil {
+		os.Remove(path)
+		return err
+	}
+	return s.sendExportReady(ctx, u, archive)
+}
+
+// writeExport пишет ZIP-архив данных пользователя в файл path
+func writeExport(ctx context.Context, userID uint, path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := export.Build(ctx, database.GetDB(), userID, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// sendExportReady отправляет пользователю письмо со ссылкой на готовый архив
+func (s *UserService) sendExportReady(ctx context.Context, u user.User, archive upload.Upload) error {
+	link, err := s.exportDownloadLink(u, archive)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      u.Email,
+		Subject: "Your data export is ready",
+		Body: fmt.Sprintf("Hello, %s!\n\nThe archive with your personal data is ready. "+
+			"Download it using this link and do not share it:\n%s\n\n"+
+			"The archive will be deleted on %s. If you did not request an export, change your password.\n",
+			u.Name, link, archive.ExpiresAt.Format(time.RFC1123)),
+	})
+}
+
+// PurgeExpiredExports удаляет просроченные архивы выгрузки вместе с файлами
+func (s *UserService) PurgeExpiredExports() (int, error) {
+	expired, err := s.uploadRepo.FindExpired(time.Now(), purgeExportsBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for i, archive := range expired {
+		if err := os.Remove(archive.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return i, err
+		}
+		if err := s.uploadRepo.Delete(archive.ID); err != nil {
+			return i, err
+		}
+	}
+	return len(expired), nil
+}
+
+// StartExportCleanup периодически удаляет просроченные архивы выгрузки в фоне
+func (s *UserService) StartExportCleanup() {
+	go func() {
+		ticker := time.NewTicker(s.config.Export.SweepInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			count, err := s.PurgeExpiredExports()
+			if err != nil {
+				log.Printf("Failed to purge expired data exports: %v", err)
+			}
+			if count > 0 {
+				log.Printf("Purged %d expired data exports", count)
+			}
+		}
+	}()
+}
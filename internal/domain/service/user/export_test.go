@@ -0,0 +1,136 @@
+package service
+
+import (
+	"awesomeProject/internal/domain/model/common"
+	"awesomeProject/internal/domain/model/upload"
+	"awesomeProject/internal/domain/model/user"
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var exportLink = regexp.MustCompile(`http://app\.test/exports/download\?token=(\S+)`)
+
+// fakeUploads хранит загрузки в памяти
+type fakeUploads struct {
+	upload.Repository
+	items map[uint]upload.Upload
+}
+
+func (f *fakeUploads) FindByID(id uint) (upload.Upload, error) {
+	u, ok := f.items[id]
+	if !ok {
+		return upload.Upload{}, upload.ErrNotFound
+	}
+	return u, nil
+}
+
+func TestGetExportByLink(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+	owner := user.User{Base: common.Base{ID: 1}, Email: "ann@example.com", IsActive: true}
+	archives := map[uint]upload.Upload{
+		10: {UserID: 1, Type: upload.TypeExport, Path: "/tmp/a.zip", ExpiresAt: &future},
+		11: {UserID: 2, Type: upload.TypeExport, Path: "/tmp/b.zip", ExpiresAt: &future},
+		12: {UserID: 1, Type: "image", Path: "/tmp/c.png"},
+		13: {UserID: 1, Type: upload.TypeExport, Path: "/tmp/d.zip", ExpiresAt: &past},
+	}
+	link := func(id uint, expiresAt time.Time) signedTokenPayload {
+		return signedTokenPayload{Purpose: exportDownloadPurpose, UserID: 1, Email: owner.Email, ExpiresAt: expiresAt.Unix(), ResourceID: id}
+	}
+
+	tests := []struct {
+		name     string
+		secret   string
+		payload  signedTokenPayload
+		token    string
+		setup    func(u *user.User)
+		wantPath string
+		wantErr  error
+	}{
+		{name: "valid link", payload: link(10, future), wantPath: "/tmp/a.zip"},
+		{name: "garbage", token: "not-a-token", wantErr: ErrInvalidExportLink},
+		{name: "other secret", secret: "other", payload: link(10, future), wantErr: ErrInvalidExportLink},
+		{name: "expired link", payload: link(10, past), wantErr: ErrInvalidExportLink},
+		{
+			name:    "verification token",
+			payload: signedTokenPayload{Purpose: verifyEmailPurpose, UserID: 1, Email: owner.Email, ExpiresAt: future.Unix(), ResourceID: 10},
+			wantErr: ErrInvalidExportLink,
+		},
+		{name: "email changed", payload: link(10, future), setup: func(u *user.User) { u.Email = "new@example.com" }, wantErr: ErrInvalidExportLink},
+		{name: "deactivated", payload: link(10, future), setup: func(u *user.User) { u.IsActive = false }, wantErr: ErrInvalidExportLink},
+		{name: "archive of another user", payload: link(11, future), wantErr: upload.ErrNotFound},
+		{name: "not an export", payload: link(12, future), wantErr: upload.ErrNotFound},
+		{name: "archive expired", payload: link(13, future), wantErr: ErrExportExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := owner
+			if tt.setup != nil {
+				tt.setup(&u)
+			}
+			s, _ := newTestService(t, newFakeUsers(u))
+			s.uploadRepo = &fakeUploads{items: archives}
+
+			token := tt.token
+			if token == "" {
+				secret := tt.secret
+				if secret == "" {
+					secret = s.config.Export.LinkSecret
+				}
+				var err error
+				if token, err = signToken(secret, tt.payload); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			archive, err := s.GetExportByLink(token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && archive.Path != tt.wantPath {
+				t.Errorf("path = %q, want %q", archive.Path, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestSendExportReadyLinksWithoutBearer(t *testing.T) {
+	owner := user.User{Base: common.Base{ID: 1}, Name: "Ann", Email: "ann@example.com", IsActive: true}
+	s, mail := newTestService(t, newFakeUsers(owner))
+	expiresAt := time.Now().Add(time.Hour)
+	archive := upload.Upload{ID: 10, UserID: 1, Type: upload.TypeExport, Path: "/tmp/a.zip", ExpiresAt: &expiresAt}
+	s.uploadRepo = &fakeUploads{items: map[uint]upload.Upload{10: archive}}
+
+	if err := s.sendExportReady(context.Background(), owner, archive); err != nil {
+		t.Fatalf("sendExportReady: %v", err)
+	}
+	msg := waitForMail(t, mail, owner.Email)
+	match := exportLink.FindStringSubmatch(msg.Body)
+	if match == nil {
+		t.Fatalf("no download link in %q", msg.Body)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.GetExportByLink(token)
+	if err != nil {
+		t.Fatalf("GetExportByLink: %v", err)
+	}
+	if got.Path != archive.Path {
+		t.Errorf("path = %q, want %q", got.Path, archive.Path)
+	}
+
+	payload, err := parseSignedToken(s.config.Export.LinkSecret, exportDownloadPurpose, token, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload.ExpiresAt != expiresAt.Unix() {
+		t.Errorf("link expires at %d, want archive expiry %d", payload.ExpiresAt, expiresAt.Unix())
+	}
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"awesomeProject/internal/domain/model/audit"
+	"awesomeProject/internal/domain/model/impersonation"
+	"awesomeProject/internal/domain/model/user"
+	"awesomeProject/internal/pagination"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// expireBatchSize - сколько истекших имперсонаций обрабатывается за один проход
+const expireBatchSize = 100
+
+var (
+	ErrCannotImpersonate = errors.New("this user cannot be impersonated")
+	ErrNotImpersonating  = errors.New("current session is not an impersonation")
+)
+
+// ImpersonationResponse - токен для работы от имени пользователя. Refresh-токена нет:
+// по истечении ExpiresIn имперсонацию нужно начать заново.
+type ImpersonationResponse struct {
+	Token          string     `json:"token"`
+	ExpiresIn      int64      `json:"expires_in"`
+	User           *user.User `json:"user"`
+	ImpersonatorID uint       `json:"impersonator_id"`
+}
+
+// Impersonate выдает администратору короткоживущий токен от имени пользователя targetID.
+// Токен несет права пользователя, а администратор указан в claim act. Начало записывается в аудит.
+func (s *UserService) Impersonate(adminID, targetID uint, client ClientInfo) (ImpersonationResponse, error) {
+	if adminID == targetID {
+		return ImpersonationResponse{}, ErrCannotImpersonate
+	}
+	admin, err := s.userRepo.FindByID(adminID)
+	if err != nil {
+		return ImpersonationResponse{}, err
+	}
+	target, err := s.userRepo.FindByID(targetID)
+	if err != nil {
+		return ImpersonationResponse{}, err
+	}
+	// Имперсонация не должна давать прав, которых у администратора нет,
+	// поэтому права пользователя - строгое подмножество прав администратора
+	lower, err := s.hasFewerPermissions(target.Role, admin.Role)
+	if err != nil {
+		return ImpersonationResponse{}, err
+	}
+	if !lower {
+		return ImpersonationResponse{}, ErrCannotImpersonate
+	}
+
+	jti, err := randomToken(16)
+	if err != nil {
+		return ImpersonationResponse{}, err
+	}
+	ttl := s.config.Auth.ImpersonationTTL
+	token, err := s.signAccessToken(target, jwt.MapClaims{
+		"jti": jti,
+		"act": map[string]string{"sub": strconv.FormatUint(uint64(adminID), 10)},
+	}, ttl)
+	if err != nil {
+		return ImpersonationResponse{}, err
+	}
+	if err := s.impersonationRepo.Create(&impersonation.Impersonation{
+		JTI:       jti,
+		ActorID:   adminID,
+		SubjectID: target.ID,
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return ImpersonationResponse{}, err
+	}
+	if err := s.recordAudit(adminID, target.ID, audit.ActionImpersonationStart, client); err != nil {
+		return ImpersonationResponse{}, err
+	}
+	return ImpersonationResponse{
+		Token:          token,
+		ExpiresIn:      int64(ttl.Seconds()),
+		User:           &target,
+		ImpersonatorID: adminID,
+	}, nil
+}
+
+// hasFewerPermissions сообщает, что права роли role - строгое подмножество прав роли than
+func (s *UserService) hasFewerPermissions(role, than string) (bool, error) {
+	permissions, err := s.roles.PermissionsForRole(role)
+	if err != nil {
+		return false, err
+	}
+	thanPermissions, err := s.roles.PermissionsForRole(than)
+	if err != nil {
+		return false, err
+	}
+	granted := make(map[string]bool, len(thanPermissions))
+	for _, name := range thanPermissions {
+		granted[name] = true
+	}
+	for _, name := range permissions {
+		if !granted[name] {
+			return false, nil
+		}
+		delete(granted, name)
+	}
+	return len(granted) > 0, nil
+}
+
+// StopImpersonation отзывает токен имперсонации и записывает окончание в аудит
+func (s *UserService) StopImpersonation(adminID, targetID uint, jti string, expiresAt time.Time, client ClientInfo) error {
+	if adminID == 0 {
+		return ErrNotImpersonating
+	}
+	if err := s.revocations.RevokeToken(jti, targetID, expiresAt); err != nil {
+		return err
+	}
+	// Имперсонация могла уже закончиться вместе с сессиями администратора
+	ended, err := s.impersonationRepo.End(jti, time.Now())
+	if err != nil || !ended {
+		return err
+	}
+	return s.recordAudit(adminID, targetID, audit.ActionImpersonationStop, client)
+}
+
+// endImpersonations завершает действующие имперсонации администратора adminID через repos,
+// например когда он выходит из всех сессий или удаляется. Возвращает jti отозванных токенов:
+// после фиксации изменений кэш их проверок нужно сбросить.
+func (s *UserService) endImpersonations(repos accessRepos, adminID uint) ([]string, error) {
+	ended, err := repos.impersonations.EndAllForActor(adminID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	jtis := make([]string, 0, len(ended))
+	for _, i := range ended {
+		if err := s.revocations.RevokeTokenIn(repos.revoked, i.JTI, i.SubjectID, i.ExpiresAt); err != nil {
+			return jtis, err
+		}
+		jtis = append(jtis, i.JTI)
+		subjectID := i.SubjectID
+		if err := repos.audit.Create(&audit.Event{
+			ActorID:   i.ActorID,
+			SubjectID: &subjectID,
+			Action:    audit.ActionImpersonationStop,
+		}); err != nil {
+			return jtis, err
+		}
+	}
+	return jtis, nil
+}
+
+// ExpireImpersonations записывает в аудит окончание имперсонаций, токены которых истекли
+func (s *UserService) ExpireImpersonations() (int, error) {
+	ended, err := s.impersonationRepo.EndExpired(time.Now(), expireBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for i, e := range ended {
+		if err := s.recordAudit(e.ActorID, e.SubjectID, audit.ActionImpersonationExpire, ClientInfo{}); err != nil {
+			return i, err
+		}
+	}
+	return len(ended), nil
+}
+
+// StartImpersonationExpiry периодически записывает истекшие имперсонации в аудит в фоне
+func (s *UserService) StartImpersonationExpiry() {
+	go func() {
+		ticker := time.NewTicker(s.config.Auth.ImpersonationSweepInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			count, err := s.ExpireImpersonations()
+			if err != nil {
+				log.Printf("Failed to record expired impersonations: %v", err)
+			}
+			if count > 0 {
+				log.Printf("Recorded %d expired impersonations", count)
+			}
+		}
+	}()
+}
+
+// GetAuditLog возвращает страницу журнала аудита, новые записи первыми
+func (s *UserService) GetAuditLog(filter audit.Filter, params pagination.Params) (pagination.Page[audit.Event], error) {
+	items, total, err := s.auditRepo.FindPage(filter, params)
+	if err != nil {
+		return pagination.Page[audit.Event]{}, err
+	}
+	return pagination.NewPage(items, total, params, func(e audit.Event) pagination.Cursor {
+		return pagination.Cursor{ID: e.ID, Sort: audit.PageOrder.Key()}
+	}), nil
+}
+
+func (s *UserService) recordAudit(actorID, subjectID uint, action string, client ClientInfo) error {
+	return s.auditRepo.Create(&audit.Event{
+		ActorID:   actorID,
+		SubjectID: &subjectID,
+		Action:    action,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+	})
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"awesomeProject/internal/domain/model/audit"
+	"awesomeProject/internal/domain/model/impersonation"
+	"awesomeProject/internal/domain/model/permission"
+	"awesomeProject/internal/domain/model/refresh_token"
+	"awesomeProject/internal/domain/model/revoked_token"
+	"awesomeProject/internal/domain/model/role"
+	"awesomeProject/internal/domain/model/session"
+	"awesomeProject/internal/domain/model/user"
+	roleService "awesomeProject/internal/domain/service/role"
+	tokenService "awesomeProject/internal/domain/service/token"
+	"awesomeProject/internal/signing"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeImpersonations хранит выданные токены имперсонации в памяти
+type fakeImpersonations struct {
+	impersonation.Repository
+	items []impersonation.Impersonation
+}
+
+func (f *fakeImpersonations) Create(i *impersonation.Impersonation) error {
+	f.items = append(f.items, *i)
+	return nil
+}
+
+func (f *fakeImpersonations) End(jti string, at time.Time) (bool, error) {
+	for i := range f.items {
+		if f.items[i].JTI == jti && f.items[i].EndedAt == nil {
+			f.items[i].EndedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeImpersonations) end(match func(impersonation.Impersonation) bool, at time.Time) []impersonation.Impersonation {
+	var ended []impersonation.Impersonation
+	for i := range f.items {
+		if f.items[i].EndedAt == nil && match(f.items[i]) {
+			f.items[i].EndedAt = &at
+			ended = append(ended, f.items[i])
+		}
+	}
+	return ended
+}
+
+func (f *fakeImpersonations) EndAllForActor(actorID uint, at time.Time) ([]impersonation.Impersonation, error) {
+	return f.end(func(i impersonation.Impersonation) bool {
+		return i.ActorID == actorID && i.ExpiresAt.After(at)
+	}, at), nil
+}
+
+func (f *fakeImpersonations) EndExpired(now time.Time, limit int) ([]impersonation.Impersonation, error) {
+	return f.end(func(i impersonation.Impersonation) bool { return !i.ExpiresAt.After(now) }, now), nil
+}
+
+// fakeRevoked запоминает отозванные jti и пользователей, чьи токены отозваны целиком
+type fakeRevoked struct {
+	revoked_token.Repository
+	jtis  []string
+	users []uint
+}
+
+func (f *fakeRevoked) Create(token *revoked_token.RevokedToken) error {
+	f.jtis = append(f.jtis, token.JTI)
+	return nil
+}
+
+func (f *fakeRevoked) RevokeAllBefore(userID uint, _ time.Time) error {
+	f.users = append(f.users, userID)
+	return nil
+}
+
+// noRefreshTokens и noSessions - пустые хранилища refresh-токенов и сессий
+type noRefreshTokens struct{ refresh_token.Repository }
+
+func (noRefreshTokens) RevokeAllForUser(uint) error { return nil }
+
+type noSessions struct{ session.Repository }
+
+func (noSessions) RevokeAllForUser(uint) error { return nil }
+
+type impersonationFixture struct {
+	service        *UserService
+	impersonations *fakeImpersonations
+	revoked        *fakeRevoked
+	audit          *fakeAudit
+}
+
+func newImpersonationFixture(t *testing.T, users ...user.User) impersonationFixture {
+	t.Helper()
+	s, _ := newTestService(t, newFakeUsers(users...))
+	key, err := signing.GenerateEd25519()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.keys, err = signing.NewKeySet(key); err != nil {
+		t.Fatal(err)
+	}
+	f := impersonationFixture{
+		service:        s,
+		impersonations: &fakeImpersonations{},
+		revoked:        &fakeRevoked{},
+		audit:          &fakeAudit{},
+	}
+	s.config.Auth.ImpersonationTTL = time.Minute
+	s.impersonationRepo = f.impersonations
+	s.auditRepo = f.audit
+	s.refreshTokenRepo = noRefreshTokens{}
+	s.sessionRepo = noSessions{}
+	s.revokedRepo = f.revoked
+	s.revocations = tokenService.NewRevocationServiceWith(f.revoked, s.cache)
+	s.roles = roleService.NewRoleServiceWith(fakeRoles{permissions: map[string][]string{
+		role.Admin:  {permission.NewsRead, permission.NewsWrite, permission.UsersRead, permission.UsersManage, permission.RolesManage},
+		role.Editor: {permission.NewsRead, permission.NewsWrite},
+		role.Reader: {permission.NewsRead},
+		"support":   {permission.NewsRead, permission.UsersRead, permission.UsersManage},
+	}}, nil, nil, nil, s.cache)
+	return f
+}
+
+func userWithRole(id uint, r string) user.User {
+	u := testUser(id, "")
+	u.Role = r
+	return u
+}
+
+func TestImpersonatePrivilege(t *testing.T) {
+	tests := []struct {
+		name        string
+		actorRole   string
+		targetRole  string
+		wantAllowed bool
+	}{
+		{name: "admin impersonates editor", actorRole: role.Admin, targetRole: role.Editor, wantAllowed: true},
+		{name: "admin impersonates reader", actorRole: role.Admin, targetRole: role.Reader, wantAllowed: true},
+		{name: "support impersonates reader", actorRole: "support", targetRole: role.Reader, wantAllowed: true},
+		{name: "admin cannot impersonate another admin", actorRole: role.Admin, targetRole: role.Admin},
+		{name: "support cannot gain news:write", actorRole: "support", targetRole: role.Editor},
+		{name: "support cannot impersonate admin", actorRole: "support", targetRole: role.Admin},
+		{name: "same permissions", actorRole: "support", targetRole: "support"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newImpersonationFixture(t, userWithRole(1, tt.actorRole), userWithRole(2, tt.targetRole))
+			resp, err := f.service.Impersonate(1, 2, ClientInfo{IP: "10.0.0.1"})
+			if !tt.wantAllowed {
+				if !errors.Is(err, ErrCannotImpersonate) {
+					t.Fatalf("err = %v, want %v", err, ErrCannotImpersonate)
+				}
+				if len(f.impersonations.items) != 0 || len(f.audit.events) != 0 {
+					t.Errorf("refused impersonation left records: %+v %+v", f.impersonations.items, f.audit.events)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Impersonate: %v", err)
+			}
+			if resp.Token == "" || resp.ImpersonatorID != 1 || resp.User.ID != 2 {
+				t.Errorf("response = %+v", resp)
+			}
+			if len(f.impersonations.items) != 1 || f.impersonations.items[0].JTI == "" {
+				t.Fatalf("impersonations = %+v, want one with a jti", f.impersonations.items)
+			}
+			if got := f.audit.actions(); len(got) != 1 || got[0] != audit.ActionImpersonationStart {
+				t.Errorf("audit = %v, want %s", got, audit.ActionImpersonationStart)
+			}
+		})
+	}
+}
+
+func TestImpersonateSelf(t *testing.T) {
+	f := newImpersonationFixture(t, userWithRole(1, role.Admin))
+	if _, err := f.service.Impersonate(1, 1, ClientInfo{}); !errors.Is(err, ErrCannotImpersonate) {
+		t.Fatalf("err = %v, want %v", err, ErrCannotImpersonate)
+	}
+}
+
+func TestImpersonationEnd(t *testing.T) {
+	tests := []struct {
+		name        string
+		end         func(f impersonationFixture, started impersonation.Impersonation) error
+		wantRevoked bool
+		wantAudit   []string
+	}{
+		{
+			name: "stopped by the admin",
+			end: func(f impersonationFixture, started impersonation.Impersonation) error {
+				return f.service.StopImpersonation(1, 2, started.JTI, started.ExpiresAt, ClientInfo{})
+			},
+			wantRevoked: true,
+			wantAudit:   []string{audit.ActionImpersonationStart, audit.ActionImpersonationStop},
+		},
+		{
+			name: "admin logs out everywhere",
+			end: func(f impersonationFixture, _ impersonation.Impersonation) error {
+				return f.service.LogoutAll(1)
+			},
+			wantRevoked: true,
+			wantAudit:   []string{audit.ActionImpersonationStart, audit.ActionImpersonationStop},
+		},
+		{
+			name: "stopped after logout everywhere is recorded once",
+			end: func(f impersonationFixture, started impersonation.Impersonation) error {
+				if err := f.service.LogoutAll(1); err != nil {
+					return err
+				}
+				return f.service.StopImpersonation(1, 2, started.JTI, started.ExpiresAt, ClientInfo{})
+			},
+			wantRevoked: true,
+			wantAudit:   []string{audit.ActionImpersonationStart, audit.ActionImpersonationStop},
+		},
+		{
+			name: "impersonated user logs out everywhere",
+			end: func(f impersonationFixture, _ impersonation.Impersonation) error {
+				return f.service.LogoutAll(2)
+			},
+			wantAudit: []string{audit.ActionImpersonationStart},
+		},
+		{
+			name: "expired",
+			end: func(f impersonationFixture, _ impersonation.Impersonation) error {
+				f.impersonations.items[0].ExpiresAt = time.Now().Add(-time.Second)
+				count, err := f.service.ExpireImpersonations()
+				if err == nil && count != 1 {
+					t.Errorf("expired %d impersonations, want 1", count)
+				}
+				return err
+			},
+			wantAudit: []string{audit.ActionImpersonationStart, audit.ActionImpersonationExpire},
+		},
+		{
+			name: "not expired yet",
+			end: func(f impersonationFixture, _ impersonation.Impersonation) error {
+				_, err := f.service.ExpireImpersonations()
+				return err
+			},
+			wantAudit: []string{audit.ActionImpersonationStart},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newImpersonationFixture(t, userWithRole(1, role.Admin), userWithRole(2, role.Reader))
+			if _, err := f.service.Impersonate(1, 2, ClientInfo{}); err != nil {
+				t.Fatal(err)
+			}
+			started := f.impersonations.items[0]
+			if err := tt.end(f, started); err != nil {
+				t.Fatal(err)
+			}
+
+			revoked := false
+			for _, jti := range f.revoked.jtis {
+				revoked = revoked || jti == started.JTI
+			}
+			if revoked != tt.wantRevoked {
+				t.Errorf("token revoked = %v, want %v", revoked, tt.wantRevoked)
+			}
+			got := f.audit.actions()
+			if len(got) != len(tt.wantAudit) {
+				t.Fatalf("audit = %v, want %v", got, tt.wantAudit)
+			}
+			for i := range got {
+				if got[i] != tt.wantAudit[i] {
+					t.Errorf("audit = %v, want %v", got, tt.wantAudit)
+				}
+			}
+		})
+	}
+}
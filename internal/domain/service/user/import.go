@@ -0,0 +1,461 @@
+package service
+
+import (
+	"awesomeProject/internal/database"
+	"awesomeProject/internal/domain/model/audit"
+	"awesomeProject/internal/domain/model/role"
+	"awesomeProject/internal/domain/model/user"
+	"awesomeProject/internal/mailer"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+)
+
+// Форматы файла импорта пользователей
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+)
+
+// ImportMaxRows - максимальное число строк в одном импорте
+const ImportMaxRows = 1000
+
+// Итог обработки строки импорта
+const (
+	ImportCreated   = "created"
+	ImportUpdated   = "updated"
+	ImportUnchanged = "unchanged"
+	ImportFailed    = "failed"
+)
+
+// ImportInvitationPending - приглашение поставлено в очередь и отправляется в фоне
+const ImportInvitationPending = "pending"
+
+var (
+	ErrUnsupportedImportFormat = errors.New("unsupported import format, use csv or ndjson")
+	ErrImportTooLarge          = fmt.Errorf("import is limited to %d rows", ImportMaxRows)
+	ErrInvalidImportHeader     = errors.New("invalid csv header")
+)
+
+// errDryRun откатывает транзакцию пробного импорта
+var errDryRun = errors.New("dry run")
+
+// importColumns - колонки CSV и поля NDJSON. Role необязательна.
+var importColumns = map[string]bool{"name": true, "age": true, "city": true, "email": true, "role": true}
+
+// ImportRow - строка импорта. Правила проверки совпадают с CreateUserRequest,
+// только пароля нет: пользователь задаст его по ссылке из приглашения.
+type ImportRow struct {
+	Name  string `json:"name" binding:"required"`
+	Age   int    `json:"age" binding:"required,min=18"`
+	City  string `json:"city" binding:"required"`
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role"`
+
+	line     int
+	parseErr error
+}
+
+// ImportRowResult - результат обработки одной строки. Invitation есть только у созданных
+// пользователей: письма отправляются после ответа, поэтому их статус - pending.
+type ImportRowResult struct {
+	Line       int      `json:"line"`
+	Email      string   `json:"email,omitempty"`
+	Status     string   `json:"status"`
+	UserID     uint     `json:"user_id,omitempty"`
+	Invitation string   `json:"invitation,omitempty"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// ImportReport - отчет об импорте по каждой строке
+type ImportReport struct {
+	DryRun    bool              `json:"dry_run"`
+	Total     int               `json:"total"`
+	Created   int               `json:"created"`
+	Updated   int               `json:"updated"`
+	Unchanged int               `json:"unchanged"`
+	Failed    int               `json:"failed"`
+	Rows      []ImportRowResult `json:"rows"`
+}
+
+// ImportUsers создает и обновляет пользователей по email из CSV или NDJSON.
+// Все строки обрабатываются в одной транзакции, каждая в своей точке сохранения:
+// ошибочная строка откатывается и попадает в отчет, остальные фиксируются.
+// Новым пользователям вместо пароля в фоне отправляется приглашение со ссылкой сброса пароля.
+// При dryRun транзакция откатывается и письма не отправляются.
+func (s *UserService) ImportUsers(actorID uint, format string, r io.Reader, dryRun bool, client ClientInfo) (ImportReport, error) {
+	rows, err := parseImport(format, r)
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	report := ImportReport{DryRun: dryRun, Total: len(rows), Rows: make([]ImportRowResult, len(rows))}
+	roleErrors := make(map[string]error)
+	seen := make(map[string]int, len(rows))
+	var created, roleChanged []user.User
+	now := time.Now()
+
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		created, roleChanged = nil, nil
+		for i, row := range rows {
+			result := &report.Rows[i]
+			*result = ImportRowResult{Line: row.line, Email: row.Email}
+
+			if errs := s.validateImportRow(row, roleErrors); len(errs) > 0 {
+				result.Status, result.Errors = ImportFailed, errs
+				continue
+			}
+			if line, dup := seen[row.Email]; dup {
+				result.Status = ImportFailed
+				result.Errors = []string{fmt.Sprintf("email is already used on line %d", line)}
+				continue
+			}
+			seen[row.Email] = row.line
+
+			savepoint := fmt.Sprintf("import_line_%d", row.line)
+			if err := tx.SavePoint(savepoint).Error; err != nil {
+				return err
+			}
+			u, status, changedRole, err := s.importRow(tx, actorID, row, now, client)
+			if err != nil {
+				if err := tx.RollbackTo(savepoint).Error; err != nil {
+					return err
+				}
+				result.Status, result.Errors = ImportFailed, []string{err.Error()}
+				continue
+			}
+			result.Status, result.UserID = status, u.ID
+			switch {
+			case status == ImportCreated:
+				created = append(created, u)
+			case changedRole:
+				roleChanged = append(roleChanged, u)
+			}
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return ImportReport{}, err
+	}
+
+	for i := range report.Rows {
+		result := &report.Rows[i]
+		switch result.Status {
+		case ImportCreated:
+			report.Created++
+		case ImportUpdated:
+			report.Updated++
+		case ImportUnchanged:
+			report.Unchanged++
+		case ImportFailed:
+			report.Failed++
+		}
+		// ID строк пробного импорта откатились вместе с транзакцией
+		if dryRun {
+			result.UserID = 0
+		}
+	}
+	if dryRun {
+		return report, nil
+	}
+
+	for _, u := range roleChanged {
+		if err := s.revocations.RevokeAllForUser(u.ID); err != nil {
+			log.Printf("Failed to revoke tokens of user %d after import: %v", u.ID, err)
+		}
+	}
+	for i := range report.Rows {
+		result := &report.Rows[i]
+		if result.Status == ImportCreated {
+			result.Invitation = ImportInvitationPending
+		}
+		if result.Status == ImportCreated || result.Status == ImportUpdated {
+			s.cache.Delete(fmt.Sprintf("user:id:%d", result.UserID))
+			s.cache.Delete(fmt.Sprintf("user:email:%s", result.Email))
+		}
+	}
+	s.trySendInvitations(created)
+	return report, nil
+}
+
+// trySendInvitations отправляет приглашения в фоне: импорт сотен пользователей
+// не ждет почтовый сервер, а ошибки отправки только пишутся в лог
+func (s *UserService) trySendInvitations(users []user.User) {
+	if len(users) == 0 {
+		return
+	}
+	go func() {
+		for _, u := range users {
+			if err := s.sendInvitation(u); err != nil {
+				log.Printf("Failed to send invitation to %s: %v", u.Email, err)
+			}
+		}
+	}()
+}
+
+// validateImportRow проверяет строку по правилам CreateUserRequest и существование роли.
+// Результат проверки ролей запоминается в roleErrors, чтобы не искать одну роль много раз.
+func (s *UserService) validateImportRow(row ImportRow, roleErrors map[string]error) []string {
+	if row.parseErr != nil {
+		return []string{row.parseErr.Error()}
+	}
+	var errs []string
+	if err := binding.Validator.ValidateStruct(&row); err != nil {
+		errs = append(errs, strings.Split(err.Error(), "\n")...)
+	}
+	if row.Role != "" {
+		err, checked := roleErrors[row.Role]
+		if !checked {
+			_, err = s.roles.GetRoleByName(row.Role)
+			roleErrors[row.Role] = err
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("role %q: %v", row.Role, err))
+		}
+	}
+	return errs
+}
+
+// importRow создает пользователя или обновляет найденного по email внутри транзакции tx
+func (s *UserService) importRow(tx *gorm.DB, actorID uint, row ImportRow, now time.Time, client ClientInfo) (user.User, string, bool, error) {
+	users := user.NewRepository(tx)
+	u, err := users.FindByEmail(row.Email)
+	status, changedRole := ImportUnchanged, false
+
+	switch {
+	case errors.Is(err, user.ErrNotFound):
+		// Случайный пароль никому не известен: войти можно только после сброса по приглашению
+		password, err := randomToken(32)
+		if err != nil {
+			return user.User{}, "", false, err
+		}
+		u = user.User{
+			Email:       row.Email,
+			Password:    password,
+			Name:        row.Name,
+			Age:         row.Age,
+			City:        row.City,
+			Role:        role.Reader,
+			IsActive:    true,
+			IsActive_at: now,
+		}
+		if row.Role != "" {
+			u.Role = row.Role
+		}
+		if err := users.Create(&u); err != nil {
+			return user.User{}, "", false, err
+		}
+		status = ImportCreated
+	case err != nil:
+		return user.User{}, "", false, err
+	default:
+		fields := make(map[string]interface{})
+		if u.Name != row.Name {
+			fields["name"], u.Name = row.Name, row.Name
+		}
+		if u.Age != row.Age {
+			fields["age"], u.Age = row.Age, row.Age
+		}
+		if u.City != row.City {
+			fields["city"], u.City = row.City, row.City
+		}
+		if row.Role != "" && u.Role != row.Role {
+			if u.Role == role.Admin {
+				if err := ensureNotLastAdmin(users, "demote"); err != nil {
+					return user.User{}, "", false, err
+				}
+			}
+			fields["role"], u.Role = row.Role, row.Role
+			changedRole = true
+		}
+		if len(fields) == 0 {
+			return u, ImportUnchanged, false, nil
+		}
+		if err := users.UpdateFields(u.ID, fields); err != nil {
+			return user.User{}, "", false, err
+		}
+		status = ImportUpdated
+	}
+
+	subjectID := u.ID
+	if err := audit.NewRepository(tx).Create(&audit.Event{
+		ActorID:   actorID,
+		SubjectID: &subjectID,
+		Action:    audit.ActionUserImport,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Details:   status,
+	}); err != nil {
+		return user.User{}, "", false, err
+	}
+	return u, status, changedRole, nil
+}
+
+// sendInvitation отправляет импортированному пользователю ссылку, по которой он задаст пароль
+func (s *UserService) sendInvitation(u user.User) error {
+	token, err := s.createPasswordReset(u.ID, s.config.Auth.InvitationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := s.config.Verification.BaseURL + "/reset-password?token=" + url.QueryEscape(token)
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout.RequestTimeout)
+	defer cancel()
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      u.Email,
+		Subject: "You have been invited",
+		Body: fmt.Sprintf("Hello, %s!\n\nAn account has been created for you. "+
+			"To choose a password and sign in open the link below:\n%s\n\n"+
+			"If the link does not open, send this token to POST /auth/reset-password:\n%s\n\n"+
+			"The link expires in %s.\n",
+			u.Name, link, token, s.config.Auth.InvitationTTL),
+	})
+}
+
+// parseImport читает строки импорта. Ошибки отдельных строк сохраняются в строке
+// и попадают в отчет, ошибка всего файла возвращается сразу. Email приводится
+// к нижнему регистру один раз здесь: по нему ищутся дубли и существующие пользователи.
+func parseImport(format string, r io.Reader) ([]ImportRow, error) {
+	var rows []ImportRow
+	var err error
+	switch format {
+	case ImportFormatCSV:
+		rows, err = parseImportCSV(r)
+	case ImportFormatNDJSON:
+		rows, err = parseImportNDJSON(r)
+	default:
+		return nil, ErrUnsupportedImportFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].Email = strings.ToLower(strings.TrimSpace(rows[i].Email))
+	}
+	return rows, nil
+}
+
+// parseImportCSV читает CSV с заголовком. Порядок колонок любой, role можно не указывать.
+func parseImportCSV(r io.Reader) ([]ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportHeader, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))
+		if !importColumns[name] {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidImportHeader, name)
+		}
+		if _, dup := columns[name]; dup {
+			return nil, fmt.Errorf("%w: duplicate column %q", ErrInvalidImportHeader, name)
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"name", "age", "city", "email"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: missing column %q", ErrInvalidImportHeader, name)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []ImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if len(rows) == ImportMaxRows {
+			return nil, ErrImportTooLarge
+		}
+		// Строка с другим числом колонок попадает в отчет, остальные ошибки
+		// (например, незакрытая кавычка) ломают разбор всего файла. csv.ParseError
+		// содержит номер строки и отдается клиенту как 400.
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) && errors.Is(parseErr.Err, csv.ErrFieldCount) {
+				rows = append(rows, ImportRow{line: parseErr.StartLine, parseErr: fmt.Errorf("expected %d columns", len(header))})
+				continue
+			}
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		row := ImportRow{
+			Name:  field(record, "name"),
+			City:  field(record, "city"),
+			Email: field(record, "email"),
+			Role:  field(record, "role"),
+			line:  line,
+		}
+		if age := field(record, "age"); age != "" {
+			if row.Age, err = strconv.Atoi(age); err != nil {
+				row.parseErr = errors.New("age must be an integer")
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// parseImportNDJSON читает по одному JSON-объекту на строку. Пустые строки пропускаются.
+func parseImportNDJSON(r io.Reader) ([]ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var rows []ImportRow
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		if len(rows) == ImportMaxRows {
+			return nil, ErrImportTooLarge
+		}
+
+		var row ImportRow
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&row); err != nil {
+			row = ImportRow{parseErr: fmt.Errorf("invalid json: %v", err)}
+		}
+		row.Name = strings.TrimSpace(row.Name)
+		row.City = strings.TrimSpace(row.City)
+		row.Email = strings.TrimSpace(row.Email)
+		row.Role = strings.TrimSpace(row.Role)
+		row.line = line
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
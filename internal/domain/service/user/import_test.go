@@ -0,0 +1,184 @@
+package service
+
+import (
+	"awesomeProject/internal/domain/model/user"
+	"encoding/csv"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// importedRow - проверяемая часть ImportRow
+type importedRow struct {
+	Line     int
+	Name     string
+	Age      int
+	City     string
+	Email    string
+	Role     string
+	ParseErr string
+}
+
+func importedRows(rows []ImportRow) []importedRow {
+	got := make([]importedRow, 0, len(rows))
+	for _, row := range rows {
+		r := importedRow{Line: row.line, Name: row.Name, Age: row.Age, City: row.City, Email: row.Email, Role: row.Role}
+		if row.parseErr != nil {
+			r.ParseErr = row.parseErr.Error()
+		}
+		got = append(got, r)
+	}
+	return got
+}
+
+func TestParseImportCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		want     []importedRow
+		wantErr  error
+		wantLine int
+	}{
+		{name: "empty file", input: "", want: []importedRow{}},
+		{
+			name:  "columns in any order",
+			input: "email,name,age,city\n ann@example.com ,Ann,30,Riga\n",
+			want:  []importedRow{{Line: 2, Name: "Ann", Age: 30, City: "Riga", Email: "ann@example.com"}},
+		},
+		{
+			name:  "email is lowercased",
+			input: "name,age,city,email,role\nAnn,30,Riga,  Ann@Example.COM,editor\n",
+			want:  []importedRow{{Line: 2, Name: "Ann", Age: 30, City: "Riga", Email: "ann@example.com", Role: "editor"}},
+		},
+		{
+			name:  "header with BOM and spaces",
+			input: "\uFEFFName, Age ,City,Email\nAnn,30,Riga,ann@example.com\n",
+			want:  []importedRow{{Line: 2, Name: "Ann", Age: 30, City: "Riga", Email: "ann@example.com"}},
+		},
+		{
+			name:  "row errors are reported per line",
+			input: "name,age,city,email\nAnn,thirty,Riga,ann@example.com\nBob,40\nEve,25,Oslo,eve@example.com\n",
+			want: []importedRow{
+				{Line: 2, Name: "Ann", City: "Riga", Email: "ann@example.com", ParseErr: "age must be an integer"},
+				{Line: 3, ParseErr: "expected 4 columns"},
+				{Line: 4, Name: "Eve", Age: 25, City: "Oslo", Email: "eve@example.com"},
+			},
+		},
+		{name: "unknown column", input: "name,age,city,email,phone\n", wantErr: ErrInvalidImportHeader},
+		{name: "duplicate column", input: "name,age,city,email,Email\n", wantErr: ErrInvalidImportHeader},
+		{name: "missing column", input: "name,age,city\n", wantErr: ErrInvalidImportHeader},
+		{
+			name:     "broken quote",
+			input:    "name,age,city,email\nAnn,30,Riga,ann@example.com\nBob,40,\"Oslo,bob@example.com\n",
+			wantErr:  csv.ErrQuote,
+			wantLine: 3,
+		},
+		{
+			name:     "bare quote",
+			input:    "name,age,city,email\nAnn,30,Ri\"ga,ann@example.com\n",
+			wantErr:  csv.ErrBareQuote,
+			wantLine: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := parseImport(ImportFormatCSV, strings.NewReader(tt.input))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantLine != 0 {
+				var parseErr *csv.ParseError
+				if !errors.As(err, &parseErr) || parseErr.StartLine != tt.wantLine {
+					t.Fatalf("err = %v, want csv.ParseError starting on line %d", err, tt.wantLine)
+				}
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got := importedRows(rows); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseImportNDJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []importedRow
+	}{
+		{name: "empty file", input: "", want: []importedRow{}},
+		{
+			name:  "blank lines are skipped",
+			input: "{\"name\":\"Ann\",\"age\":30,\"city\":\"Riga\",\"email\":\" Ann@Example.com \"}\n\n{\"name\":\"Bob\",\"age\":40,\"city\":\"Oslo\",\"email\":\"bob@example.com\",\"role\":\"editor\"}\n",
+			want: []importedRow{
+				{Line: 1, Name: "Ann", Age: 30, City: "Riga", Email: "ann@example.com"},
+				{Line: 3, Name: "Bob", Age: 40, City: "Oslo", Email: "bob@example.com", Role: "editor"},
+			},
+		},
+		{
+			name:  "invalid lines are reported",
+			input: "not json\n{\"name\":\"Ann\",\"phone\":\"1\"}\n",
+			want: []importedRow{
+				{Line: 1, ParseErr: "invalid json: invalid character 'o' in literal null (expecting 'u')"},
+				{Line: 2, ParseErr: "invalid json: json: unknown field \"phone\""},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := parseImport(ImportFormatNDJSON, strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("parseImport: %v", err)
+			}
+			if got := importedRows(rows); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseImportLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		input   string
+		wantErr error
+	}{
+		{name: "unknown format", format: "xml", wantErr: ErrUnsupportedImportFormat},
+		{name: "csv at limit", format: ImportFormatCSV, input: "name,age,city,email\n" + strings.Repeat("a,1,b,c\n", ImportMaxRows)},
+		{name: "csv over limit", format: ImportFormatCSV, input: "name,age,city,email\n" + strings.Repeat("a,1,b,c\n", ImportMaxRows+1), wantErr: ErrImportTooLarge},
+		{name: "ndjson over limit", format: ImportFormatNDJSON, input: strings.Repeat("{}\n", ImportMaxRows+1), wantErr: ErrImportTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseImport(tt.format, strings.NewReader(tt.input)); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTrySendInvitations(t *testing.T) {
+	invited := []user.User{testUser(1, "ann@example.com"), testUser(2, "bob@example.com")}
+	s, mail := newTestService(t, newFakeUsers(invited...))
+	resets := &fakeResets{}
+	s.passwordResetRepo = resets
+
+	s.trySendInvitations(invited)
+
+	for _, u := range invited {
+		msg := waitForMail(t, mail, u.Email)
+		if !strings.Contains(msg.Body, "http://app.test/reset-password?token=") {
+			t.Errorf("invitation to %s has no reset link: %q", u.Email, msg.Body)
+		}
+	}
+	if got := resets.userIDs(); !reflect.DeepEqual(got, []uint{1, 2}) {
+		t.Errorf("reset tokens for %v, want [1 2]", got)
+	}
+}
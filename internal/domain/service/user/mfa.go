@@ -0,0 +1,390 @@
+package service
+
+import (
+	"awesomeProject/internal/domain/model/mfa"
+	"awesomeProject/internal/domain/model/session"
+	"awesomeProject/internal/domain/model/user"
+	"awesomeProject/internal/totp"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+const mfaChallengePurpose = "mfa-challenge"
+
+var (
+	ErrInvalidMFAToken   = errors.New("invalid or expired mfa token")
+	ErrInvalidMFACode    = errors.New("invalid authentication code")
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrMFASetupMissing   = errors.New("two-factor setup has not been started")
+	ErrMFARequiredByRole = errors.New("two-factor authentication is required for your role")
+
+	errNoEncryptionKey = errors.New("MFA_ENCRYPTION_KEY is not configured")
+)
+
+// MFAChallenge - промежуточный результат входа: пароль верный, нужен второй фактор.
+// Если EnrollmentRequired, роль требует 2FA, а у пользователя она еще не настроена.
+type MFAChallenge struct {
+	Token              string `json:"mfa_token"`
+	ExpiresIn          int64  `json:"expires_in"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
+}
+
+// MFASetup - данные для добавления аккаунта в приложение-аутентификатор
+type MFASetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// MFAConfirmation - результат включения 2FA. Коды восстановления показываются один раз.
+// Auth заполнен, если 2FA включалась на шаге входа.
+type MFAConfirmation struct {
+	RecoveryCodes []string      `json:"recovery_codes"`
+	Auth          *AuthResponse `json:"auth,omitempty"`
+}
+
+// mfaChallengeFor возвращает challenge, если пользователь должен пройти второй шаг входа
+func (s *UserService) mfaChallengeFor(u user.User) (*MFAChallenge, error) {
+	enabled, err := s.isMFAEnabled(u.ID)
+	if err != nil {
+		return nil, err
+	}
+	required, err := s.roles.RequiresMFA(u.Role)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled && !required {
+		return nil, nil
+	}
+
+	token, err := signToken(s.config.MFA.ChallengeSecret, signedTokenPayload{
+		Purpose:    mfaChallengePurpose,
+		UserID:     u.ID,
+		Email:      u.Email,
+		ExpiresAt:  time.Now().Add(s.config.MFA.ChallengeTTL).Unix(),
+		Enrollment: !enabled,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &MFAChallenge{
+		Token:              token,
+		ExpiresIn:          int64(s.config.MFA.ChallengeTTL.Seconds()),
+		EnrollmentRequired: !enabled,
+	}, nil
+}
+
+// VerifyMFA завершает вход кодом из приложения или кодом восстановления
+func (s *UserService) VerifyMFA(mfaToken, code, recoveryCode string, client ClientInfo) (AuthResponse, error) {
+	u, err := s.userFromChallenge(mfaToken, false)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	if err := s.lockout.Check(u.Email, client.IP); err != nil {
+		return AuthResponse{}, err
+	}
+
+	if err := s.checkSecondFactor(u.ID, code, recoveryCode); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			s.recordLogin(u.Email, &u.ID, client, session.FailureInvalidMFA)
+			if err := s.lockout.Fail(u.Email, client.IP); err != nil {
+				log.Printf("Failed to register failed mfa attempt for %s: %v", u.Email, err)
+			}
+		}
+		return AuthResponse{}, err
+	}
+
+	if err := s.consumeChallenge(mfaToken); err != nil {
+		return AuthResponse{}, err
+	}
+	return s.completeLogin(u, client)
+}
+
+// StartMFAEnrollment начинает настройку 2FA на шаге входа, когда её требует роль.
+// Принимается только mfa_token, выданный с enrollment_required.
+func (s *UserService) StartMFAEnrollment(mfaToken string) (MFASetup, error) {
+	u, err := s.userFromChallenge(mfaToken, true)
+	if err != nil {
+		return MFASetup{}, err
+	}
+	return s.SetupMFA(u.ID)
+}
+
+// ConfirmMFAEnrollment включает 2FA на шаге входа и сразу выдает токены
+func (s *UserService) ConfirmMFAEnrollment(mfaToken, code string, client ClientInfo) (MFAConfirmation, error) {
+	u, err := s.userFromChallenge(mfaToken, true)
+	if err != nil {
+		return MFAConfirmation{}, err
+	}
+	codes, err := s.ConfirmMFA(u.ID, code)
+	if err != nil {
+		return MFAConfirmation{}, err
+	}
+	if err := s.consumeChallenge(mfaToken); err != nil {
+		return MFAConfirmation{}, err
+	}
+	response, err := s.completeLogin(u, client)
+	if err != nil {
+		return MFAConfirmation{}, err
+	}
+	return MFAConfirmation{RecoveryCodes: codes, Auth: &response}, nil
+}
+
+// SetupMFA создает новый TOTP-секрет. Фактор не действует, пока не подтвержден кодом.
+func (s *UserService) SetupMFA(userID uint) (MFASetup, error) {
+	u, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return MFASetup{}, err
+	}
+	enabled, err := s.isMFAEnabled(u.ID)
+	if err != nil {
+		return MFASetup{}, err
+	}
+	if enabled {
+		return MFASetup{}, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return MFASetup{}, err
+	}
+	encrypted, err := s.encryptSecret(secret)
+	if err != nil {
+		return MFASetup{}, err
+	}
+	if err := s.mfaRepo.SaveFactor(&mfa.Factor{UserID: u.ID, SecretEncrypted: encrypted}); err != nil {
+		return MFASetup{}, err
+	}
+	return MFASetup{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.config.MFA.Issuer, u.Email, secret),
+	}, nil
+}
+
+// ConfirmMFA включает 2FA после проверки первого кода и выдает коды восстановления
+func (s *UserService) ConfirmMFA(userID uint, code string) ([]string, error) {
+	factor, err := s.mfaRepo.FindByUserID(userID)
+	if err != nil {
+		if errors.Is(err, mfa.ErrNotFound) {
+			return nil, ErrMFASetupMissing
+		}
+		return nil, err
+	}
+	if factor.IsEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := s.decryptSecret(factor.SecretEncrypted)
+	if err != nil {
+		return nil, err
+	}
+	counter, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+	if err := s.mfaRepo.Confirm(userID, counter, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(userID)
+}
+
+// DisableMFA выключает 2FA после проверки пароля и второго фактора.
+// Если роль требует 2FA, выключить её нельзя.
+func (s *UserService) DisableMFA(userID uint, password, code, recoveryCode string) error {
+	u, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if !u.CheckPasswordHash(password) {
+		return ErrWrongPassword
+	}
+	required, err := s.roles.RequiresMFA(u.Role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrMFARequiredByRole
+	}
+	if err := s.checkSecondFactor(u.ID, code, recoveryCode); err != nil {
+		return err
+	}
+	return s.mfaRepo.Delete(u.ID)
+}
+
+// RegenerateRecoveryCodes заменяет коды восстановления; старые перестают действовать
+func (s *UserService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	if err := s.checkSecondFactor(userID, code, ""); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(userID)
+}
+
+// checkSecondFactor проверяет TOTP-код или, если он не передан, код восстановления
+func (s *UserService) checkSecondFactor(userID uint, code, recoveryCode string) error {
+	factor, err := s.mfaRepo.FindByUserID(userID)
+	if err != nil {
+		if errors.Is(err, mfa.ErrNotFound) {
+			return ErrMFANotEnabled
+		}
+		return err
+	}
+	if !factor.IsEnabled() {
+		return ErrMFANotEnabled
+	}
+
+	if code == "" {
+		if recoveryCode == "" {
+			return ErrInvalidMFACode
+		}
+		used, err := s.mfaRepo.UseRecoveryCode(userID, hashToken(normalizeRecoveryCode(recoveryCode)))
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	secret, err := s.decryptSecret(factor.SecretEncrypted)
+	if err != nil {
+		return err
+	}
+	counter, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	// Один и тот же код нельзя предъявить дважды
+	advanced, err := s.mfaRepo.AdvanceCounter(userID, counter)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *UserService) isMFAEnabled(userID uint) (bool, error) {
+	factor, err := s.mfaRepo.FindByUserID(userID)
+	if err != nil {
+		if errors.Is(err, mfa.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return factor.IsEnabled(), nil
+}
+
+// userFromChallenge проверяет mfa_token и возвращает пользователя, прошедшего первый шаг входа.
+// enrollment - ожидаемый вид challenge: настройка 2FA или ввод кода.
+func (s *UserService) userFromChallenge(mfaToken string, enrollment bool) (user.User, error) {
+	payload, err := parseSignedToken(s.config.MFA.ChallengeSecret, mfaChallengePurpose, mfaToken, time.Now())
+	if err != nil || payload.Enrollment != enrollment {
+		return user.User{}, ErrInvalidMFAToken
+	}
+	used, err := s.mfaRepo.IsChallengeUsed(hashToken(mfaToken))
+	if err != nil {
+		return user.User{}, err
+	}
+	if used {
+		return user.User{}, ErrInvalidMFAToken
+	}
+	u, err := s.userRepo.FindByID(payload.UserID)
+	if err != nil || u.Email != payload.Email {
+		return user.User{}, ErrInvalidMFAToken
+	}
+	return u, nil
+}
+
+// consumeChallenge гасит mfa_token после успешного второго шага, чтобы его нельзя было использовать снова.
+// Погашенные токены хранятся в базе, поэтому повтор не пройдет ни на другом экземпляре, ни при выключенном кэше.
+func (s *UserService) consumeChallenge(mfaToken string) error {
+	used, err := s.mfaRepo.UseChallenge(hashToken(mfaToken), time.Now().Add(s.config.MFA.ChallengeTTL))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidMFAToken
+	}
+	return nil
+}
+
+// replaceRecoveryCodes выпускает новые коды восстановления и сохраняет их хеши
+func (s *UserService) replaceRecoveryCodes(userID uint) ([]string, error) {
+	codes := make([]string, s.config.MFA.RecoveryCodes)
+	hashes := make([]string, len(codes))
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+		codes[i] = code[:4] + "-" + code[4:]
+		hashes[i] = hashToken(code)
+	}
+	if err := s.mfaRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode приводит введенный код к виду, от которого считался хеш
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// encryptSecret шифрует TOTP-секрет AES-256-GCM ключом из MFA_ENCRYPTION_KEY
+func (s *UserService) encryptSecret(secret string) (string, error) {
+	gcm, err := s.secretCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *UserService) decryptSecret(encrypted string) (string, error) {
+	gcm, err := s.secretCipher()
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("encrypted mfa secret is too short")
+	}
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	secret, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+func (s *UserService) secretCipher() (cipher.AEAD, error) {
+	if s.config.MFA.EncryptionKey == "" {
+		return nil, errNoEncryptionKey
+	}
+	key := sha256.Sum256([]byte(s.config.MFA.EncryptionKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"awesomeProject/internal/domain/model/mfa"
+	"awesomeProject/internal/domain/model/role"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeFactors хранит факторы 2FA и погашенные mfa_token в памяти
+type fakeFactors struct {
+	mfa.Repository
+	items map[uint]mfa.Factor
+	used  map[string]bool
+}
+
+func (f *fakeFactors) FindByUserID(userID uint) (mfa.Factor, error) {
+	factor, ok := f.items[userID]
+	if !ok {
+		return mfa.Factor{}, mfa.ErrNotFound
+	}
+	return factor, nil
+}
+
+func (f *fakeFactors) SaveFactor(factor *mfa.Factor) error {
+	f.items[factor.UserID] = *factor
+	return nil
+}
+
+func (f *fakeFactors) Delete(userID uint) error {
+	delete(f.items, userID)
+	return nil
+}
+
+func (f *fakeFactors) IsChallengeUsed(hash string) (bool, error) {
+	return f.used[hash], nil
+}
+
+func (f *fakeFactors) UseChallenge(hash string, _ time.Time) (bool, error) {
+	if f.used[hash] {
+		return false, nil
+	}
+	if f.used == nil {
+		f.used = map[string]bool{}
+	}
+	f.used[hash] = true
+	return true, nil
+}
+
+func (f *fakeFactors) UseRecoveryCode(uint, string) (bool, error) {
+	return true, nil
+}
+
+func TestMFAChallengeKinds(t *testing.T) {
+	challenge := func(secret string, enrollment bool) string {
+		token, err := signToken(secret, signedTokenPayload{
+			Purpose:    mfaChallengePurpose,
+			UserID:     1,
+			Email:      "ann@example.com",
+			ExpiresAt:  time.Now().Add(time.Minute).Unix(),
+			Enrollment: enrollment,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	const challengeSecret = "test-mfa-challenge-secret"
+
+	tests := []struct {
+		name    string
+		token   string
+		call    string
+		wantErr error
+	}{
+		{
+			name:  "enrollment challenge starts enrollment",
+			token: challenge(challengeSecret, true),
+			call:  "enroll",
+		},
+		{
+			name:    "verification challenge cannot enroll",
+			token:   challenge(challengeSecret, false),
+			call:    "enroll",
+			wantErr: ErrInvalidMFAToken,
+		},
+		{
+			name:    "verification challenge cannot confirm enrollment",
+			token:   challenge(challengeSecret, false),
+			call:    "confirm",
+			wantErr: ErrInvalidMFAToken,
+		},
+		{
+			name:    "challenge signed with the verification secret is forged",
+			token:   challenge("test-verification-secret", true),
+			call:    "enroll",
+			wantErr: ErrInvalidMFAToken,
+		},
+		{
+			name:    "enrollment challenge cannot verify a code",
+			token:   challenge(challengeSecret, true),
+			call:    "verify",
+			wantErr: ErrInvalidMFAToken,
+		},
+		{
+			name:    "verification token is not a challenge",
+			token:   mustSign(t, "test-mfa-challenge-secret", verifyEmailPurpose),
+			call:    "enroll",
+			wantErr: ErrInvalidMFAToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t, newFakeUsers(testUser(1, "ann@example.com")))
+			s.mfaRepo = &fakeFactors{items: map[uint]mfa.Factor{}}
+
+			var err error
+			switch tt.call {
+			case "enroll":
+				var setup MFASetup
+				setup, err = s.StartMFAEnrollment(tt.token)
+				if err == nil && !strings.HasPrefix(setup.ProvisioningURI, "otpauth://totp/") {
+					t.Errorf("unexpected provisioning uri %q", setup.ProvisioningURI)
+				}
+			case "confirm":
+				_, err = s.ConfirmMFAEnrollment(tt.token, "000000", ClientInfo{})
+			case "verify":
+				_, err = s.VerifyMFA(tt.token, "000000", "", ClientInfo{})
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func mustSign(t *testing.T, secret, purpose string) string {
+	t.Helper()
+	token, err := signToken(secret, signedTokenPayload{Purpose: purpose, UserID: 1, Email: "ann@example.com", ExpiresAt: time.Now().Add(time.Minute).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestEncryptSecret(t *testing.T) {
+	s, _ := newTestService(t, newFakeUsers())
+	encrypted, err := s.encryptSecret("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("encryptSecret: %v", err)
+	}
+	if strings.Contains(encrypted, "JBSWY3DPEHPK3PXP") {
+		t.Fatal("secret is stored in plain text")
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		want    string
+		wantErr error
+	}{
+		{name: "same key", key: "test-mfa-encryption-key", want: "JBSWY3DPEHPK3PXP"},
+		{name: "no key configured", key: "", wantErr: errNoEncryptionKey},
+		{name: "other key", key: "another-key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.config.MFA.EncryptionKey = tt.key
+			got, err := s.decryptSecret(encrypted)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+			case tt.want == "":
+				if err == nil {
+					t.Fatal("decrypting with another key must fail")
+				}
+			case err != nil || got != tt.want:
+				t.Fatalf("decryptSecret = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyMFAUsesChallengeOnce(t *testing.T) {
+	u := userWithRole(1, role.Reader)
+	u.Email = "ann@example.com"
+	confirmed := time.Now()
+
+	tests := []struct {
+		name        string
+		usedBefore  bool
+		wantErr     error
+		wantSuccess bool
+	}{
+		{name: "fresh challenge issues tokens", wantSuccess: true},
+		{name: "replayed challenge is rejected", usedBefore: true, wantErr: ErrInvalidMFAToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, sessions := newLoginFixture(t, u)
+			factors := s.mfaRepo.(*fakeFactors)
+			factors.items[u.ID] = mfa.Factor{UserID: u.ID, ConfirmedAt: &confirmed}
+			challenge, err := s.mfaChallengeFor(u)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.usedBefore {
+				if err := s.consumeChallenge(challenge.Token); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			response, err := s.VerifyMFA(challenge.Token, "", "abcd-efgh", ClientInfo{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got := response.Token != ""; got != tt.wantSuccess {
+				t.Fatalf("tokens issued = %v, want %v", got, tt.wantSuccess)
+			}
+			recorded := len(sessions.events) == 1 && sessions.events[0].Success
+			if recorded != tt.wantSuccess {
+				t.Fatalf("successful login recorded = %v, want %v (events %+v)", recorded, tt.wantSuccess, sessions.events)
+			}
+			if !factors.used[hashToken(challenge.Token)] {
+				t.Fatal("challenge is not marked as used")
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"awesomeProject/internal/domain/model/identity"
+	"awesomeProject/internal/domain/model/role"
+	"awesomeProject/internal/domain/model/session"
+	"awesomeProject/internal/domain/model/user"
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrExternalEmailNotVerified = errors.New("identity provider did not confirm the email address")
+
+// ExternalIdentity - пользователь, подтвержденный внешним OIDC-провайдером
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// LoginWithIdentity входит по внешней учетной записи. Уже связанная запись ведет к своему
+// пользователю; новая связывается с аккаунтом по подтвержденному провайдером email,
+// а если такого аккаунта нет, он создается. Двухфакторная аутентификация применяется как при входе по паролю.
+func (s *UserService) LoginWithIdentity(ext ExternalIdentity, client ClientInfo) (AuthResponse, error) {
+	u, err := s.userForIdentity(ext)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	if !u.IsActive {
+		s.recordLogin(u.Email, &u.ID, client, session.FailureDisabled)
+		return AuthResponse{}, ErrAccountDisabled
+	}
+
+	challenge, err := s.mfaChallengeFor(u)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	if challenge != nil {
+		s.recordLogin(u.Email, &u.ID, client, session.FailureMFAPending)
+		return AuthResponse{MFA: challenge}, nil
+	}
+	return s.completeLogin(u, client)
+}
+
+func (s *UserService) userForIdentity(ext ExternalIdentity) (user.User, error) {
+	linked, err := s.identityRepo.FindByProviderSubject(ext.Provider, ext.Subject)
+	if err == nil {
+		return s.userRepo.FindByID(linked.UserID)
+	}
+	if !errors.Is(err, identity.ErrNotFound) {
+		return user.User{}, err
+	}
+
+	// Связать аккаунт по email можно, только если провайдер подтвердил владение адресом
+	if ext.Email == "" || !ext.EmailVerified {
+		return user.User{}, ErrExternalEmailNotVerified
+	}
+
+	u, err := s.userForExternalEmail(ext)
+	if err != nil {
+		return user.User{}, err
+	}
+
+	err = s.identityRepo.Create(&identity.Identity{
+		UserID:   u.ID,
+		Provider: ext.Provider,
+		Subject:  ext.Subject,
+		Email:    ext.Email,
+	})
+	if errors.Is(err, identity.ErrDuplicate) {
+		// Параллельный вход уже связал эту учетную запись: берем пользователя из связи
+		linked, err := s.identityRepo.FindByProviderSubject(ext.Provider, ext.Subject)
+		if err != nil {
+			return user.User{}, err
+		}
+		if linked.UserID == u.ID {
+			return u, nil
+		}
+		return s.userRepo.FindByID(linked.UserID)
+	}
+	if err != nil {
+		return user.User{}, err
+	}
+	return u, nil
+}
+
+// userForExternalEmail находит аккаунт с подтвержденным провайдером email или создает новый.
+// Если аккаунт одновременно создал параллельный вход, используется он.
+func (s *UserService) userForExternalEmail(ext ExternalIdentity) (user.User, error) {
+	u, err := s.userRepo.FindByEmail(ext.Email)
+	if errors.Is(err, user.ErrNotFound) {
+		u, err = s.provisionUser(ext)
+		if errors.Is(err, user.ErrEmailExists) {
+			u, err = s.userRepo.FindByEmail(ext.Email)
+		}
+		return u, err
+	}
+	if err != nil {
+		return user.User{}, err
+	}
+
+	if !u.IsVerified {
+		if err := s.resetUnverifiedAccess(u); err != nil {
+			return user.User{}, err
+		}
+		now := time.Now()
+		if err := s.userRepo.MarkVerified(u.ID, now); err != nil {
+			return user.User{}, err
+		}
+		u.IsVerified = true
+		u.IsVerified_at = now
+		s.invalidateUserCache(u)
+	}
+	return u, nil
+}
+
+// resetUnverifiedAccess лишает доступа того, кто зарегистрировал аккаунт до владельца адреса.
+// Пока email не подтвержден, аккаунт мог создать кто угодно, поэтому перед связыванием
+// пароль заменяется случайным, а сессии, токены, API-ключи и 2FA регистранта отзываются.
+func (s *UserService) resetUnverifiedAccess(u user.User) error {
+	password, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	if err := s.setPassword(u, password); err != nil {
+		return err
+	}
+	if err := s.apiKeyRepo.RevokeAllForUser(u.ID); err != nil {
+		return err
+	}
+	if err := s.mfaRepo.Delete(u.ID); err != nil {
+		return err
+	}
+	return s.LogoutAll(u.ID)
+}
+
+// provisionUser создает аккаунт при первом входе через провайдера. Пароль случайный:
+// войти по паролю можно будет после его сброса.
+func (s *UserService) provisionUser(ext ExternalIdentity) (user.User, error) {
+	password, err := randomToken(32)
+	if err != nil {
+		return user.User{}, err
+	}
+	name := ext.Name
+	if name == "" {
+		name, _, _ = strings.Cut(ext.Email, "@")
+	}
+
+	now := time.Now()
+	newUser := &user.User{
+		Email:         ext.Email,
+		Password:      password,
+		Name:          name,
+		Role:          role.Reader,
+		IsActive:      true,
+		IsActive_at:   now,
+		IsVerified:    true,
+		IsVerified_at: now,
+	}
+	if err := s.userRepo.Create(newUser); err != nil {
+		return user.User{}, err
+	}
+	return *newUser, nil
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"awesomeProject/internal/domain/model/identity"
+	"awesomeProject/internal/domain/model/mfa"
+	"awesomeProject/internal/domain/model/role"
+	"awesomeProject/internal/domain/model/user"
+	tokenService "awesomeProject/internal/domain/service/token"
+	"errors"
+	"testing"
+)
+
+// fakeIdentities хранит связи с провайдерами в памяти. Если задан race, Create ведет себя
+// так, будто параллельный вход успел связать учетную запись первым.
+type fakeIdentities struct {
+	identity.Repository
+	items []identity.Identity
+	race  *identity.Identity
+}
+
+func (f *fakeIdentities) FindByProviderSubject(provider, subject string) (identity.Identity, error) {
+	for _, i := range f.items {
+		if i.Provider == provider && i.Subject == subject {
+			return i, nil
+		}
+	}
+	return identity.Identity{}, identity.ErrNotFound
+}
+
+func (f *fakeIdentities) Create(i *identity.Identity) error {
+	if f.race != nil {
+		f.items = append(f.items, *f.race)
+		f.race = nil
+		return identity.ErrDuplicate
+	}
+	f.items = append(f.items, *i)
+	return nil
+}
+
+// racingUsers создает winner вместо нового пользователя, как параллельная регистрация с тем же email
+type racingUsers struct {
+	*fakeUsers
+	winner user.User
+}
+
+func (r racingUsers) Create(*user.User) error {
+	r.fakeUsers.mu.Lock()
+	r.fakeUsers.items[r.winner.ID] = r.winner
+	r.fakeUsers.mu.Unlock()
+	return user.ErrEmailExists
+}
+
+func TestUserForIdentity(t *testing.T) {
+	ext := ExternalIdentity{Provider: "google", Subject: "sub", Email: "a@example.com", EmailVerified: true, Name: "Alice"}
+	verified := testUser(1, "a@example.com")
+	verified.IsVerified = true
+	verified.Password = "owner-password-hash"
+	squatted := testUser(1, "a@example.com")
+	squatted.Password = "squatter-password-hash"
+	other := testUser(2, "b@example.com")
+	other.IsVerified = true
+
+	tests := []struct {
+		name       string
+		ext        ExternalIdentity
+		users      []user.User
+		identities []identity.Identity
+		race       *identity.Identity
+		raceUser   *user.User
+		wantID     uint
+		wantLinks  int
+		wantReset  bool
+		wantErr    error
+	}{
+		{
+			name:       "already linked",
+			ext:        ext,
+			users:      []user.User{verified, other},
+			identities: []identity.Identity{{UserID: 2, Provider: "google", Subject: "sub"}},
+			wantID:     2,
+			wantLinks:  1,
+		},
+		{
+			name:      "linked by verified email",
+			ext:       ext,
+			users:     []user.User{verified},
+			wantID:    1,
+			wantLinks: 1,
+		},
+		{
+			name:      "unverified account is verified by the provider and taken from its registrant",
+			ext:       ext,
+			users:     []user.User{squatted},
+			wantID:    1,
+			wantLinks: 1,
+			wantReset: true,
+		},
+		{
+			name:      "new account is provisioned",
+			ext:       ext,
+			wantID:    1,
+			wantLinks: 1,
+		},
+		{
+			name:    "email not confirmed by the provider",
+			ext:     ExternalIdentity{Provider: "google", Subject: "sub", Email: "a@example.com"},
+			users:   []user.User{verified},
+			wantErr: ErrExternalEmailNotVerified,
+		},
+		{
+			name:    "no email",
+			ext:     ExternalIdentity{Provider: "google", Subject: "sub", EmailVerified: true},
+			wantErr: ErrExternalEmailNotVerified,
+		},
+		{
+			name:      "identity linked concurrently to the same account",
+			ext:       ext,
+			users:     []user.User{verified},
+			race:      &identity.Identity{UserID: 1, Provider: "google", Subject: "sub"},
+			wantID:    1,
+			wantLinks: 1,
+		},
+		{
+			name:      "identity linked concurrently to another account",
+			ext:       ext,
+			users:     []user.User{verified, other},
+			race:      &identity.Identity{UserID: 2, Provider: "google", Subject: "sub"},
+			wantID:    2,
+			wantLinks: 1,
+		},
+		{
+			name:      "account provisioned concurrently",
+			ext:       ext,
+			raceUser:  &user.User{Email: "a@example.com", Role: role.Reader, IsActive: true, IsVerified: true},
+			wantID:    7,
+			wantLinks: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newFakeUsers(tt.users...)
+			s, _ := newTestService(t, users)
+			if tt.raceUser != nil {
+				winner := *tt.raceUser
+				winner.ID = 7
+				s.userRepo = racingUsers{fakeUsers: users, winner: winner}
+			}
+			identities := &fakeIdentities{items: tt.identities, race: tt.race}
+			s.identityRepo = identities
+			refreshTokens, sessions, apiKeys := &recordingRefreshTokens{}, &recordingSessions{}, &recordingAPIKeys{}
+			revoked := &fakeRevoked{}
+			factors := &fakeFactors{items: map[uint]mfa.Factor{1: {UserID: 1}}}
+			s.refreshTokenRepo, s.sessionRepo, s.apiKeyRepo, s.mfaRepo = refreshTokens, sessions, apiKeys, factors
+			s.impersonationRepo, s.revokedRepo = &fakeImpersonations{}, revoked
+			s.revocations = tokenService.NewRevocationServiceWith(revoked, s.cache)
+
+			u, err := s.userForIdentity(tt.ext)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if u.ID != tt.wantID {
+				t.Errorf("user = %d, want %d", u.ID, tt.wantID)
+			}
+			if !u.IsVerified {
+				t.Errorf("user %d is not verified after login through the provider", u.ID)
+			}
+			if len(identities.items) != tt.wantLinks {
+				t.Errorf("identities = %+v, want %d", identities.items, tt.wantLinks)
+			}
+			if linked, err := identities.FindByProviderSubject("google", "sub"); err != nil || linked.UserID != tt.wantID {
+				t.Errorf("identity links user %d (%v), want %d", linked.UserID, err, tt.wantID)
+			}
+			if stored, err := users.FindByID(u.ID); err != nil || !stored.IsVerified {
+				t.Errorf("stored user %d is not verified (%v)", u.ID, err)
+			}
+
+			// Аккаунт, зарегистрированный до подтверждения адреса, больше не пускает регистранта
+			stored, _ := users.FindByID(u.ID)
+			if tt.wantReset && stored.Password == "squatter-password-hash" {
+				t.Error("registrant's password was not replaced with a random one")
+			}
+			revokedEverywhere := len(refreshTokens.users) == 1 && len(sessions.users) == 1 &&
+				len(apiKeys.users) == 1 && len(revoked.users) == 1
+			if revokedEverywhere != tt.wantReset {
+				t.Errorf("access revoked = %v, want %v", revokedEverywhere, tt.wantReset)
+			}
+			if _, ok := factors.items[1]; ok == tt.wantReset {
+				t.Errorf("registrant's 2FA kept = %v, want %v", ok, !tt.wantReset)
+			}
+		})
+	}
+}
+
+func TestProvisionedUserIsReader(t *testing.T) {
+	users := newFakeUsers()
+	s, _ := newTestService(t, users)
+	s.identityRepo = &fakeIdentities{}
+
+	u, err := s.userForIdentity(ExternalIdentity{Provider: "google", Subject: "sub", Email: "new@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Role != role.Reader || !u.IsActive || u.Name != "new" {
+		t.Errorf("provisioned user = %+v, want active reader named after the email", u)
+	}
+}
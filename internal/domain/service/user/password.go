@@ -0,0 +1,170 @@
+package service
+
+import (
+	"awesomeProject/internal/domain/model/password_reset"
+	"awesomeProject/internal/domain/model/user"
+	"awesomeProject/internal/mailer"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+)
+
+// forgotPasswordInterval - минимальный интервал между письмами сброса пароля на один аккаунт
+const forgotPasswordInterval = time.Minute
+
+var (
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+	ErrWrongPassword     = errors.New("current password is incorrect")
+)
+
+// ForgotPassword отправляет письмо со ссылкой сброса пароля. Ранее выданные токены гасятся.
+// Чтобы ни ответ, ни его время не выдавали зарегистрированные email, письмо уходит в фоне,
+// а ошибок у метода нет: они только пишутся в лог. Повторное письмо не отправляется,
+// пока в базе есть неиспользованный токен моложе forgotPasswordInterval.
+func (s *UserService) ForgotPassword(email string) {
+	u, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		if !errors.Is(err, user.ErrNotFound) {
+			log.Printf("Failed to start password reset: %v", err)
+		}
+		return
+	}
+
+	recent, err := s.passwordResetRepo.HasUnusedSince(u.ID, time.Now().Add(-forgotPasswordInterval))
+	if err != nil {
+		log.Printf("Failed to start password reset for user %d: %v", u.ID, err)
+		return
+	}
+	if recent {
+		return
+	}
+
+	token, err := s.createPasswordReset(u.ID, s.config.Auth.PasswordResetTTL)
+	if err != nil {
+		log.Printf("Failed to start password reset for user %d: %v", u.ID, err)
+		return
+	}
+	go func() {
+		if err := s.sendPasswordReset(u, token); err != nil {
+			log.Printf("Failed to send password reset email to %s: %v", u.Email, err)
+		}
+	}()
+}
+
+// sendPasswordReset отправляет пользователю письмо со ссылкой сброса пароля
+func (s *UserService) sendPasswordReset(u user.User, token string) error {
+	link := s.config.Verification.BaseURL + "/reset-password?token=" + url.QueryEscape(token)
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout.RequestTimeout)
+	defer cancel()
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      u.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello, %s!\n\nSomeone requested a password reset for your account. "+
+			"To choose a new password open the link below:\n%s\n\n"+
+			"If the link does not open, send this token to POST /auth/reset-password:\n%s\n\n"+
+			"The link expires in %s. If you did not request a reset, ignore this email.\n",
+			u.Name, link, token, s.config.Auth.PasswordResetTTL),
+	})
+}
+
+// createPasswordReset гасит старые токены пользователя и выдает новый со сроком жизни ttl
+func (s *UserService) createPasswordReset(userID uint, ttl time.Duration) (string, error) {
+	if err := s.passwordResetRepo.InvalidateForUser(userID); err != nil {
+		return "", err
+	}
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	if err := s.passwordResetRepo.Create(&password_reset.PasswordReset{
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ResetPassword задает новый пароль по одноразовому токену, подтверждает email
+// и завершает все сессии пользователя
+func (s *UserService) ResetPassword(token, newPassword string) error {
+	reset, err := s.passwordResetRepo.FindByHash(hashToken(token))
+	if err != nil {
+		if errors.Is(err, password_reset.ErrNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+	if !reset.IsUsable(time.Now()) {
+		return ErrInvalidResetToken
+	}
+
+	// Помечаем токен использованным до смены пароля: из двух параллельных запросов пройдет один
+	used, err := s.passwordResetRepo.MarkUsed(reset.ID)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidResetToken
+	}
+
+	u, err := s.userRepo.FindByID(reset.UserID)
+	if err != nil {
+		if errors.Is(err, user.ErrNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+	if err := s.setPassword(u, newPassword); err != nil {
+		return err
+	}
+	// Ссылка пришла на адрес пользователя, значит он им владеет
+	if !u.IsVerified {
+		if err := s.userRepo.MarkVerified(u.ID, time.Now()); err != nil {
+			return err
+		}
+		s.invalidateUserCache(u)
+	}
+	if err := s.lockout.Unlock(u.Email); err != nil {
+		log.Printf("Failed to reset login attempts for %s: %v", u.Email, err)
+	}
+	return s.LogoutAll(u.ID)
+}
+
+// ChangePassword меняет пароль после проверки текущего. Все сессии пользователя завершаются,
+// а для текущего клиента открывается новая, чтобы он остался в системе.
+func (s *UserService) ChangePassword(userID uint, oldPassword, newPassword string, client ClientInfo) (AuthResponse, error) {
+	u, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	if !u.CheckPasswordHash(oldPassword) {
+		return AuthResponse{}, ErrWrongPassword
+	}
+
+	if err := s.setPassword(u, newPassword); err != nil {
+		return AuthResponse{}, err
+	}
+	if err := s.LogoutAll(u.ID); err != nil {
+		return AuthResponse{}, err
+	}
+	return s.generateAuthResponse(u, client)
+}
+
+// setPassword сохраняет хеш нового пароля и сбрасывает пользователя в кэше
+func (s *UserService) setPassword(u user.User, password string) error {
+	hashed, err := user.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePassword(u.ID, hashed); err != nil {
+		return err
+	}
+	s.invalidateUserCache(u)
+	return nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"awesomeProject/internal/domain/model/common"
+	"awesomeProject/internal/domain/model/password_reset"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeResets хранит выданные токены сброса пароля. Письма со ссылками уходят в фоне,
+// поэтому доступ защищен мьютексом.
+type fakeResets struct {
+	password_reset.Repository
+	mu     sync.Mutex
+	resets []password_reset.PasswordReset
+}
+
+func (f *fakeResets) InvalidateForUser(userID uint) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	for i := range f.resets {
+		if f.resets[i].UserID == userID && f.resets[i].UsedAt == nil {
+			f.resets[i].UsedAt = &now
+		}
+	}
+	return nil
+}
+
+func (f *fakeResets) Create(reset *password_reset.PasswordReset) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if reset.CreatedAt.IsZero() {
+		reset.CreatedAt = time.Now()
+	}
+	f.resets = append(f.resets, *reset)
+	return nil
+}
+
+func (f *fakeResets) HasUnusedSince(userID uint, since time.Time) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, r := range f.resets {
+		if r.UserID == userID && r.UsedAt == nil && r.CreatedAt.After(since) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// userIDs возвращает пользователей, которым выдавались токены, по порядку
+func (f *fakeResets) userIDs() []uint {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ids := make([]uint, 0, len(f.resets))
+	for _, r := range f.resets {
+		ids = append(ids, r.UserID)
+	}
+	return ids
+}
+
+func TestForgotPassword(t *testing.T) {
+	tests := []struct {
+		name       string
+		email      string
+		calls      int
+		existing   []password_reset.PasswordReset
+		wantMails  int
+		wantTokens int
+	}{
+		{name: "registered address", email: "ann@example.com", calls: 1, wantMails: 1, wantTokens: 1},
+		{name: "throttled", email: "ann@example.com", calls: 3, wantMails: 1, wantTokens: 1},
+		{
+			name:       "recent token from another instance",
+			email:      "ann@example.com",
+			calls:      1,
+			existing:   []password_reset.PasswordReset{{UserID: 1, Base: common.Base{CreatedAt: time.Now().Add(-10 * time.Second)}}},
+			wantTokens: 1,
+		},
+		{
+			name:       "old token",
+			email:      "ann@example.com",
+			calls:      1,
+			existing:   []password_reset.PasswordReset{{UserID: 1, Base: common.Base{CreatedAt: time.Now().Add(-time.Hour)}}},
+			wantMails:  1,
+			wantTokens: 2,
+		},
+		{name: "unknown address", email: "nobody@example.com", calls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mail := newTestService(t, newFakeUsers(testUser(1, "ann@example.com")))
+			s.config.Auth.PasswordResetTTL = time.Hour
+			resets := &fakeResets{resets: tt.existing}
+			s.passwordResetRepo = resets
+
+			for i := 0; i < tt.calls; i++ {
+				s.ForgotPassword(tt.email)
+			}
+			if tt.wantMails > 0 {
+				waitForMail(t, mail, tt.email)
+			}
+			if got := len(mail.Messages()); got != tt.wantMails {
+				t.Errorf("sent %d emails, want %d", got, tt.wantMails)
+			}
+			if got := len(resets.userIDs()); got != tt.wantTokens {
+				t.Errorf("issued %d tokens, want %d", got, tt.wantTokens)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"awesomeProject/internal/domain/model/user"
+	"awesomeProject/internal/metrics"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrEmailTaken совпадает с ошибкой репозитория, поэтому параллельная смена email
+	// на тот же адрес, пойманная уникальным индексом, тоже дает ErrEmailTaken
+	ErrEmailTaken              = user.ErrEmailExists
+	ErrCurrentPasswordRequired = errors.New("current_password is required to change the email")
+)
+
+// UpdateProfileRequest - частичное обновление профиля: nil-поля не меняются.
+// Смена email требует текущий пароль и повторного подтверждения адреса.
+type UpdateProfileRequest struct {
+	Name            *string `json:"name" binding:"omitempty,min=1,max=255"`
+	Age             *int    `json:"age" binding:"omitempty,min=18"`
+	City            *string `json:"city" binding:"omitempty,min=1,max=255"`
+	Email           *string `json:"email" binding:"omitempty,email,max=255"`
+	CurrentPassword string  `json:"current_password"`
+}
+
+// UpdateProfile применяет изменения профиля пользователя и возвращает его новую версию
+func (s *UserService) UpdateProfile(id uint, req UpdateProfileRequest) (user.User, error) {
+	u, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return user.User{}, err
+	}
+
+	fields := make(map[string]interface{})
+	if req.Name != nil && *req.Name != u.Name {
+		fields["name"] = *req.Name
+	}
+	if req.Age != nil && *req.Age != u.Age {
+		fields["age"] = *req.Age
+	}
+	if req.City != nil && *req.City != u.City {
+		fields["city"] = *req.City
+	}
+	emailChanged := req.Email != nil && *req.Email != u.Email
+	if emailChanged {
+		if req.CurrentPassword == "" {
+			return user.User{}, ErrCurrentPasswordRequired
+		}
+		if !u.CheckPasswordHash(req.CurrentPassword) {
+			return user.User{}, ErrWrongPassword
+		}
+		if _, err := s.userRepo.FindByEmail(*req.Email); err == nil {
+			return user.User{}, ErrEmailTaken
+		} else if !errors.Is(err, user.ErrNotFound) {
+			return user.User{}, err
+		}
+		// Новый адрес нужно подтвердить заново; старые ссылки подтверждения привязаны к прежнему email
+		fields["email"] = *req.Email
+		fields["is_verified"] = false
+		fields["is_verified_at"] = time.Time{}
+	}
+	if len(fields) == 0 {
+		return u, nil
+	}
+
+	if err := s.userRepo.UpdateFields(id, fields); err != nil {
+		return user.User{}, err
+	}
+	s.invalidateUserCache(u)
+
+	updated, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return user.User{}, err
+	}
+	s.invalidateUserCache(updated)
+	if emailChanged {
+		s.trySendVerification(updated)
+	}
+	return updated, nil
+}
+
+// UpdateUser сохраняет имя, возраст, город и email из u; пустые поля не меняются.
+// Новый email нужно подтвердить заново, письмо с подтверждением отправляется на него.
+func (s *UserService) UpdateUser(id uint, u *user.User) error {
+	current, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	fields := make(map[string]interface{})
+	if u.Name != "" {
+		fields["name"] = u.Name
+	}
+	if u.Age != 0 {
+		fields["age"] = u.Age
+	}
+	if u.City != "" {
+		fields["city"] = u.City
+	}
+	if u.Email != "" && u.Email != current.Email {
+		if _, err := s.userRepo.FindByEmail(u.Email); err == nil {
+			return ErrEmailTaken
+		} else if !errors.Is(err, user.ErrNotFound) {
+			return err
+		}
+		fields["email"] = u.Email
+		fields["is_verified"] = false
+		fields["is_verified_at"] = time.Time{}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	if err := s.userRepo.UpdateFields(id, fields); err != nil {
+		return err
+	}
+	s.invalidateUserCache(current)
+	email, emailChanged := fields["email"].(string)
+	if !emailChanged {
+		return nil
+	}
+	s.cache.Delete(fmt.Sprintf("user:email:%s", email))
+
+	updated, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	s.trySendVerification(updated)
+	return nil
+}
+
+// DeleteAccount удаляет аккаунт пользователя после проверки пароля.
+// Последний администратор удалить свой аккаунт не может.
+func (s *UserService) DeleteAccount(id uint, password string) error {
+	u, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if !u.CheckPasswordHash(password) {
+		return ErrWrongPassword
+	}
+	return s.DeleteUser(id)
+}
+
+// GetUserByEmail возвращает пользователя по email, используя кэш
+func (s *UserService) GetUserByEmail(email string) (user.User, error) {
+	cacheKey := fmt.Sprintf("user:email:%s", email)
+	if cachedUser, ok := s.cache.Get(cacheKey); ok {
+		metrics.RecordCacheHit()
+		return cachedUser.(user.User), nil
+	}
+	metrics.RecordCacheMiss()
+
+	u, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		return user.User{}, err
+	}
+	s.cache.Set(cacheKey, u)
+	return u, nil
+}
+
+// UpdateUserStatus включает или выключает аккаунт
+func (s *UserService) UpdateUserStatus(id uint, isActive bool) error {
+	u, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdateFields(id, map[string]interface{}{
+		"is_active":    isActive,
+		"is_active_at": time.Now(),
+	}); err != nil {
+		return err
+	}
+	s.invalidateUserCache(u)
+	return nil
+}
+
+// UpdateUserVerification меняет отметку о подтверждении email
+func (s *UserService) UpdateUserVerification(id uint, isVerified bool) error {
+	u, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	verifiedAt := time.Time{}
+	if isVerified {
+		verifiedAt = time.Now()
+	}
+	if err := s.userRepo.UpdateFields(id, map[string]interface{}{
+		"is_verified":    isVerified,
+		"is_verified_at": verifiedAt,
+	}); err != nil {
+		return err
+	}
+	s.invalidateUserCache(u)
+	return nil
+}
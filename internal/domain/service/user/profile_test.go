@@ -0,0 +1,230 @@
+package service
+
+import (
+	"awesomeProject/internal/domain/model/role"
+	"awesomeProject/internal/domain/model/user"
+	"awesomeProject/internal/domain/model/user_deleted"
+	"errors"
+	"testing"
+	"time"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// profileUsers - пользователь 1 с подтвержденным email и паролем secret и пользователь 2
+func profileUsers(t *testing.T) *fakeUsers {
+	t.Helper()
+	hash, err := user.HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := testUser(1, "old@example.com")
+	owner.Password = hash
+	owner.IsVerified = true
+	owner.IsVerified_at = time.Now()
+	return newFakeUsers(owner, testUser(2, "taken@example.com"))
+}
+
+func TestUpdateProfile(t *testing.T) {
+	tests := []struct {
+		name         string
+		req          UpdateProfileRequest
+		updateErr    error
+		wantErr      error
+		wantEmail    string
+		wantVerified bool
+		wantMail     bool
+	}{
+		{
+			name:         "name only keeps verification",
+			req:          UpdateProfileRequest{Name: ptr("New")},
+			wantEmail:    "old@example.com",
+			wantVerified: true,
+		},
+		{
+			name:      "email change resets verification and sends mail",
+			req:       UpdateProfileRequest{Email: ptr("new@example.com"), CurrentPassword: "secret"},
+			wantEmail: "new@example.com",
+			wantMail:  true,
+		},
+		{
+			name:    "email change needs the password",
+			req:     UpdateProfileRequest{Email: ptr("new@example.com")},
+			wantErr: ErrCurrentPasswordRequired,
+		},
+		{
+			name:    "email change with a wrong password",
+			req:     UpdateProfileRequest{Email: ptr("new@example.com"), CurrentPassword: "wrong"},
+			wantErr: ErrWrongPassword,
+		},
+		{
+			name:    "email of another user",
+			req:     UpdateProfileRequest{Email: ptr("taken@example.com"), CurrentPassword: "secret"},
+			wantErr: ErrEmailTaken,
+		},
+		{
+			name:      "email taken concurrently",
+			req:       UpdateProfileRequest{Email: ptr("race@example.com"), CurrentPassword: "secret"},
+			updateErr: user.ErrEmailExists,
+			wantErr:   ErrEmailTaken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := profileUsers(t)
+			users.updateErr = tt.updateErr
+			s, mail := newTestService(t, users)
+
+			updated, err := s.UpdateProfile(1, tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if updated.Email != tt.wantEmail || updated.IsVerified != tt.wantVerified {
+				t.Errorf("user email %q verified %v, want %q %v", updated.Email, updated.IsVerified, tt.wantEmail, tt.wantVerified)
+			}
+			if tt.wantMail {
+				waitForMail(t, mail, tt.wantEmail)
+			}
+		})
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+	tests := []struct {
+		name         string
+		changes      user.User
+		updateErr    error
+		wantErr      error
+		wantEmail    string
+		wantVerified bool
+		wantMail     bool
+	}{
+		{
+			name:         "city only keeps verification",
+			changes:      user.User{City: "Berlin"},
+			wantEmail:    "old@example.com",
+			wantVerified: true,
+		},
+		{
+			name:         "same email keeps verification",
+			changes:      user.User{Email: "old@example.com"},
+			wantEmail:    "old@example.com",
+			wantVerified: true,
+		},
+		{
+			name:      "email change resets verification and sends mail",
+			changes:   user.User{Email: "new@example.com"},
+			wantEmail: "new@example.com",
+			wantMail:  true,
+		},
+		{
+			name:    "email of another user",
+			changes: user.User{Email: "taken@example.com"},
+			wantErr: ErrEmailTaken,
+		},
+		{
+			name:      "email taken concurrently",
+			changes:   user.User{Email: "race@example.com"},
+			updateErr: user.ErrEmailExists,
+			wantErr:   ErrEmailTaken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := profileUsers(t)
+			users.updateErr = tt.updateErr
+			s, mail := newTestService(t, users)
+
+			err := s.UpdateUser(1, &tt.changes)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			stored, _ := users.FindByID(1)
+			if stored.Email != tt.wantEmail || stored.IsVerified != tt.wantVerified {
+				t.Errorf("user email %q verified %v, want %q %v", stored.Email, stored.IsVerified, tt.wantEmail, tt.wantVerified)
+			}
+			if tt.wantMail {
+				waitForMail(t, mail, tt.wantEmail)
+			}
+		})
+	}
+}
+
+// fakeDeleted переносит пользователей из fakeUsers в архив
+type fakeDeleted struct {
+	user_deleted.Repository
+	users    *fakeUsers
+	archived []user_deleted.UserDeleted
+}
+
+func (f *fakeDeleted) Archive(userID uint, purgeAfter time.Time) (user_deleted.UserDeleted, error) {
+	f.users.mu.Lock()
+	defer f.users.mu.Unlock()
+	u, ok := f.users.items[userID]
+	if !ok {
+		return user_deleted.UserDeleted{}, user.ErrNotFound
+	}
+	delete(f.users.items, userID)
+	archived := user_deleted.UserDeleted{User: u, UserID: u.ID, Email: u.Email, PurgeAfter: purgeAfter}
+	f.archived = append(f.archived, archived)
+	return archived, nil
+}
+
+func TestArchiveUser(t *testing.T) {
+	admin := func(id uint) user.User {
+		u := testUser(id, "")
+		u.Role = role.Admin
+		return u
+	}
+	reader := testUser(3, "reader@example.com")
+	reader.Role = role.Reader
+
+	tests := []struct {
+		name    string
+		users   []user.User
+		id      uint
+		wantErr error
+	}{
+		{name: "reader", users: []user.User{admin(1), reader}, id: 3},
+		{name: "one of two admins", users: []user.User{admin(1), admin(2)}, id: 1},
+		{name: "last admin", users: []user.User{admin(1), reader}, id: 1, wantErr: ErrLastAdmin},
+		{name: "unknown user", users: []user.User{admin(1)}, id: 9, wantErr: user.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newFakeUsers(tt.users...)
+			s, _ := newTestService(t, users)
+			deleted := &fakeDeleted{users: users}
+
+			_, err := s.archiveUser(users, deleted, tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			wantArchived := 1
+			if tt.wantErr != nil {
+				wantArchived = 0
+			}
+			if len(deleted.archived) != wantArchived {
+				t.Errorf("archived %d users, want %d", len(deleted.archived), wantArchived)
+			}
+		})
+	}
+}
+
+func TestDeleteAccountChecksPassword(t *testing.T) {
+	s, _ := newTestService(t, profileUsers(t))
+	if err := s.DeleteAccount(1, "wrong"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("err = %v, want %v", err, ErrWrongPassword)
+	}
+}
@@ -4,83 +4,254 @@ import (
 	"awesomeProject/internal/cache"
 	"awesomeProject/internal/config"
 	"awesomeProject/internal/database"
+	"awesomeProject/internal/domain/model/api_key"
+	"awesomeProject/internal/domain/model/audit"
+	"awesomeProject/internal/domain/model/identity"
+	"awesomeProject/internal/domain/model/impersonation"
+	"awesomeProject/internal/domain/model/mfa"
+	"awesomeProject/internal/domain/model/password_reset"
+	"awesomeProject/internal/domain/model/refresh_token"
+	"awesomeProject/internal/domain/model/revoked_token"
+	"awesomeProject/internal/domain/model/role"
+	"awesomeProject/internal/domain/model/session"
+	"awesomeProject/internal/domain/model/upload"
 	"awesomeProject/internal/domain/model/user"
+	"awesomeProject/internal/domain/model/user_deleted"
+	roleService "awesomeProject/internal/domain/service/role"
+	tokenService "awesomeProject/internal/domain/service/token"
+	"awesomeProject/internal/lockout"
+	"awesomeProject/internal/mailer"
 	"awesomeProject/internal/metrics"
+	"awesomeProject/internal/pagination"
+	"awesomeProject/internal/signing"
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 )
 
-var SecretKey = "secret"
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used")
+	ErrLastAdmin           = errors.New("at least one admin is required")
+	ErrAccountDisabled     = errors.New("account is deactivated")
+)
 
+// AuthResponse - результат входа. Если у пользователя включена двухфакторная
+// аутентификация, токены не выдаются, а в MFA возвращается challenge для второго шага.
 type AuthResponse struct {
-	Token        string    `json:"token"`
-	RefreshToken string    `json:"refresh_token"`
-	User         user.User `json:"user"`
+	Token        string        `json:"token,omitempty"`
+	RefreshToken string        `json:"refresh_token,omitempty"`
+	ExpiresIn    int64         `json:"expires_in,omitempty"`
+	User         *user.User    `json:"user,omitempty"`
+	MFA          *MFAChallenge `json:"mfa,omitempty"`
 }
 
 type UserService struct {
-	userRepo user.Repository
-	cache    *cache.Cache
-	config   *config.Config
+	userRepo          user.Repository
+	refreshTokenRepo  refresh_token.Repository
+	passwordResetRepo password_reset.Repository
+	mfaRepo           mfa.Repository
+	auditRepo         audit.Repository
+	identityRepo      identity.Repository
+	deletedRepo       user_deleted.Repository
+	apiKeyRepo        api_key.Repository
+	uploadRepo        upload.Repository
+	sessionRepo       session.Repository
+	impersonationRepo impersonation.Repository
+	revokedRepo       revoked_token.Repository
+	revocations       *tokenService.RevocationService
+	roles             *roleService.RoleService
+	lockout           *lockout.Guard
+	mailer            mailer.Mailer
+	keys              *signing.KeySet
+	cache             *cache.Cache
+	config            *config.Config
+
+	// exportsInProgress - пользователи, для которых сейчас собирается выгрузка данных
+	exportsInProgress sync.Map
 }
 
 // UserWithDetails содержит пользователя с дополнительными данными
 type UserWithDetails struct {
 	user.User
-	LastLoginAt    time.Time `json:"last_login_at"`
-	LoginCount     int       `json:"login_count"`
-	ActiveSessions int       `json:"active_sessions"`
+	LastLoginAt    *time.Time `json:"last_login_at"`
+	LoginCount     int        `json:"login_count"`
+	ActiveSessions int        `json:"active_sessions"`
+}
+
+// ClientInfo описывает клиента, с которого выполняется вход
+type ClientInfo struct {
+	IP        string
+	UserAgent string
 }
 
 func NewUserService() *UserService {
 	return &UserService{
-		userRepo: user.NewRepository(database.GetDB()),
-		cache:    cache.GetCache(),
-		config:   config.GetConfig(),
+		userRepo:          user.NewRepository(database.GetDB()),
+		refreshTokenRepo:  refresh_token.NewRepository(database.GetDB()),
+		passwordResetRepo: password_reset.NewRepository(database.GetDB()),
+		mfaRepo:           mfa.NewRepository(database.GetDB()),
+		auditRepo:         audit.NewRepository(database.GetDB()),
+		identityRepo:      identity.NewRepository(database.GetDB()),
+		deletedRepo:       user_deleted.NewRepository(database.GetDB()),
+		apiKeyRepo:        api_key.NewRepository(database.GetDB()),
+		uploadRepo:        upload.NewRepository(database.GetDB()),
+		sessionRepo:       session.NewRepository(database.GetDB()),
+		impersonationRepo: impersonation.NewRepository(database.GetDB()),
+		revokedRepo:       revoked_token.NewRepository(database.GetDB()),
+		revocations:       tokenService.NewRevocationService(),
+		roles:             roleService.NewRoleService(),
+		lockout:           lockout.NewGuardFromConfig(),
+		mailer:            mailer.GetMailer(),
+		keys:              signing.GetKeySet(),
+		cache:             cache.GetCache(),
+		config:            config.GetConfig(),
 	}
 }
 
-func (s *UserService) Login(email, password string) (AuthResponse, error) {
+// Login проверяет пароль и выдает токены. Неудачные попытки считаются по аккаунту и IP;
+// пока действует задержка или блокировка, возвращается *lockout.LockedError.
+func (s *UserService) Login(email, password string, client ClientInfo) (AuthResponse, error) {
+	if err := s.lockout.Check(email, client.IP); err != nil {
+		var locked *lockout.LockedError
+		if errors.As(err, &locked) {
+			s.recordLogin(email, nil, client, session.FailureLocked)
+		}
+		return AuthResponse{}, err
+	}
+
+	u, err := s.authenticate(email, password)
+	if err != nil {
+		var userID *uint
+		reason := session.FailureUnknownEmail
+		if u.ID != 0 {
+			userID = &u.ID
+			reason = session.FailureWrongPassword
+		}
+		s.recordLogin(email, userID, client, reason)
+		if err := s.lockout.Fail(email, client.IP); err != nil {
+			log.Printf("Failed to register failed login for %s: %v", email, err)
+		}
+		return AuthResponse{}, err
+	}
+
+	if !u.IsActive {
+		s.recordLogin(email, &u.ID, client, session.FailureDisabled)
+		return AuthResponse{}, ErrAccountDisabled
+	}
+	if s.config.Verification.Required && !u.IsVerified {
+		s.recordLogin(email, &u.ID, client, session.FailureNotVerified)
+		return AuthResponse{}, ErrEmailNotVerified
+	}
+
+	challenge, err := s.mfaChallengeFor(u)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	if challenge != nil {
+		s.recordLogin(email, &u.ID, client, session.FailureMFAPending)
+		return AuthResponse{MFA: challenge}, nil
+	}
+	return s.completeLogin(u, client)
+}
+
+// authenticate находит пользователя по email и проверяет пароль.
+// При неверном пароле найденный пользователь возвращается вместе с ошибкой.
+func (s *UserService) authenticate(email, password string) (user.User, error) {
 	// Пробуем получить пользователя из кэша
 	cacheKey := fmt.Sprintf("user:email:%s", email)
 	if cachedUser, ok := s.cache.Get(cacheKey); ok {
 		metrics.RecordCacheHit()
 		u := cachedUser.(user.User)
 		if !u.CheckPasswordHash(password) {
-			return AuthResponse{}, errors.New("invalid credentials")
+			return u, errors.New("invalid credentials")
 		}
-		return s.generateAuthResponse(u)
+		return u, nil
 	}
 	metrics.RecordCacheMiss()
 
 	// Если пользователя нет в кэше, получаем из базы данных
 	u, err := s.userRepo.FindByEmail(email)
 	if err != nil {
-		return AuthResponse{}, errors.New("invalid credentials")
+		return user.User{}, errors.New("invalid credentials")
 	}
 
 	if !u.CheckPasswordHash(password) {
-		return AuthResponse{}, errors.New("invalid credentials")
+		return u, errors.New("invalid credentials")
 	}
 
 	// Сохраняем пользователя в кэш
 	s.cache.Set(cacheKey, u)
-	return s.generateAuthResponse(u)
+	return u, nil
 }
 
-func (s *UserService) generateAuthResponse(u user.User) (AuthResponse, error) {
-	claims := jwt.MapClaims{
-		"user_id": u.ID,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
+// recordLogin пишет попытку входа в историю. Пустой failureReason означает успешный вход.
+// Ошибка записи только логируется, чтобы не мешать входу.
+func (s *UserService) recordLogin(email string, userID *uint, client ClientInfo, failureReason string) {
+	event := session.LoginEvent{
+		UserID:        userID,
+		Email:         email,
+		IP:            client.IP,
+		UserAgent:     client.UserAgent,
+		Success:       failureReason == "",
+		FailureReason: failureReason,
 	}
+	if err := s.sessionRepo.CreateLoginEvent(&event); err != nil {
+		log.Printf("Failed to record login event for %s: %v", email, err)
+	}
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(SecretKey))
+// completeLogin выдаёт токены и только после этого записывает успешный вход
+// и сбрасывает счетчик неудачных попыток
+func (s *UserService) completeLogin(u user.User, client ClientInfo) (AuthResponse, error) {
+	response, err := s.generateAuthResponse(u, client)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	s.recordLogin(u.Email, &u.ID, client, "")
+	if err := s.lockout.Succeed(u.Email); err != nil {
+		log.Printf("Failed to reset login attempts for %s: %v", u.Email, err)
+	}
+	return response, nil
+}
+
+// generateAuthResponse выдаёт пару токенов для нового логина, открывая новое семейство
+// refresh-токенов и сессию с тем же идентификатором
+func (s *UserService) generateAuthResponse(u user.User, client ClientInfo) (AuthResponse, error) {
+	familyID, err := randomToken(16)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	response, err := s.issueTokens(u, familyID)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+
+	now := time.Now()
+	if err := s.sessionRepo.Create(&session.Session{
+		UserID:     u.ID,
+		FamilyID:   familyID,
+		IP:         client.IP,
+		UserAgent:  client.UserAgent,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.config.Auth.RefreshTokenTTL),
+	}); err != nil {
+		return AuthResponse{}, err
+	}
+	return response, nil
+}
+
+// issueTokens выдаёт access JWT и новый refresh-токен в рамках семейства familyID.
+// Семейство играет роль идентификатора сессии и попадает в claim sid.
+func (s *UserService) issueTokens(u user.User, familyID string) (AuthResponse, error) {
+	ttl := s.config.Auth.AccessTokenTTL
+	tokenString, err := s.signAccessToken(u, jwt.MapClaims{"sid": familyID}, ttl)
 	if err != nil {
 		return AuthResponse{}, err
 	}
@@ -89,13 +260,181 @@ func (s *UserService) generateAuthResponse(u user.User) (AuthResponse, error) {
 		return AuthResponse{}, err
 	}
 
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	if err := s.refreshTokenRepo.Create(&refresh_token.RefreshToken{
+		UserID:    u.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.config.Auth.RefreshTokenTTL),
+	}); err != nil {
+		return AuthResponse{}, err
+	}
+
 	return AuthResponse{
 		Token:        tokenString,
-		RefreshToken: "",
-		User:         u,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(ttl.Seconds()),
+		User:         &u,
 	}, nil
 }
 
+// RefreshToken обменивает refresh-токен на новую пару токенов.
+// Каждый refresh-токен одноразовый: повторное предъявление уже использованного
+// токена считается кражей, и всё семейство токенов этого логина отзывается.
+func (s *UserService) RefreshToken(refreshToken string) (AuthResponse, error) {
+	stored, err := s.refreshTokenRepo.FindByHash(hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, refresh_token.ErrNotFound) {
+			return AuthResponse{}, ErrInvalidRefreshToken
+		}
+		return AuthResponse{}, err
+	}
+
+	if stored.RevokedAt != nil {
+		return AuthResponse{}, ErrInvalidRefreshToken
+	}
+	if stored.RotatedAt != nil {
+		return AuthResponse{}, s.revokeReusedFamily(stored)
+	}
+	if !stored.IsActive(time.Now()) {
+		return AuthResponse{}, ErrInvalidRefreshToken
+	}
+
+	// Помечаем токен использованным атомарно: из двух параллельных запросов
+	// с одним токеном пройдёт только первый
+	rotated, err := s.refreshTokenRepo.MarkRotated(stored.ID)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	if !rotated {
+		return AuthResponse{}, s.revokeReusedFamily(stored)
+	}
+
+	u, err := s.GetUserByID(stored.UserID)
+	if err != nil {
+		return AuthResponse{}, ErrInvalidRefreshToken
+	}
+	if !u.IsActive {
+		return AuthResponse{}, ErrInvalidRefreshToken
+	}
+
+	response, err := s.issueTokens(u, stored.FamilyID)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	if err := s.sessionRepo.Touch(stored.FamilyID, time.Now().Add(s.config.Auth.RefreshTokenTTL)); err != nil {
+		return AuthResponse{}, err
+	}
+	return response, nil
+}
+
+// Logout завершает одну сессию: отзывает текущий access-токен и refresh-токены его логина
+func (s *UserService) Logout(userID uint, jti string, expiresAt time.Time, sessionID string) error {
+	if err := s.revocations.RevokeToken(jti, userID, expiresAt); err != nil {
+		return err
+	}
+	if sessionID == "" {
+		return nil
+	}
+	if err := s.refreshTokenRepo.RevokeFamily(sessionID); err != nil {
+		return err
+	}
+	return s.sessionRepo.RevokeFamily(sessionID)
+}
+
+// LogoutAll завершает все сессии пользователя на всех устройствах
+func (s *UserService) LogoutAll(userID uint) error {
+	if err := s.refreshTokenRepo.RevokeAllForUser(userID); err != nil {
+		return err
+	}
+	if err := s.sessionRepo.RevokeAllForUser(userID); err != nil {
+		return err
+	}
+	// Токены имперсонации выданы на других пользователей, поэтому отзываются отдельно
+	jtis, err := s.endImpersonations(s.accessRepos(), userID)
+	s.revocations.ForgetTokens(jtis...)
+	if err != nil {
+		return err
+	}
+	return s.revocations.RevokeAllForUser(userID)
+}
+
+// accessRepos - хранилища, через которые отзывается доступ пользователя. При удалении
+// они создаются поверх транзакции, чтобы отзыв зафиксировался вместе с переносом в архив.
+type accessRepos struct {
+	refreshTokens  refresh_token.Repository
+	sessions       session.Repository
+	apiKeys        api_key.Repository
+	revoked        revoked_token.Repository
+	impersonations impersonation.Repository
+	audit          audit.Repository
+}
+
+func newAccessRepos(tx *gorm.DB) accessRepos {
+	return accessRepos{
+		refreshTokens:  refresh_token.NewRepository(tx),
+		sessions:       session.NewRepository(tx),
+		apiKeys:        api_key.NewRepository(tx),
+		revoked:        revoked_token.NewRepository(tx),
+		impersonations: impersonation.NewRepository(tx),
+		audit:          audit.NewRepository(tx),
+	}
+}
+
+// accessRepos возвращает хранилища сервиса, работающие вне транзакции
+func (s *UserService) accessRepos() accessRepos {
+	return accessRepos{
+		refreshTokens:  s.refreshTokenRepo,
+		sessions:       s.sessionRepo,
+		apiKeys:        s.apiKeyRepo,
+		revoked:        s.revokedRepo,
+		impersonations: s.impersonationRepo,
+		audit:          s.auditRepo,
+	}
+}
+
+// signAccessToken подписывает access-токен пользователя с его текущими правами.
+// extra дополняет стандартные claims: sid для сессии, act для имперсонации.
+func (s *UserService) signAccessToken(u user.User, extra jwt.MapClaims, ttl time.Duration) (string, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	permissions, err := s.roles.PermissionsForRole(u.Role)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"user_id": u.ID,
+		"role":    u.Role,
+		"perms":   permissions,
+		"jti":     jti,
+		"iat":     now.Unix(),
+		"exp":     now.Add(ttl).Unix(),
+	}
+	for name, value := range extra {
+		claims[name] = value
+	}
+	return s.keys.Sign(claims)
+}
+
+// revokeReusedFamily отзывает семейство токенов после обнаружения повторного использования
+func (s *UserService) revokeReusedFamily(stored refresh_token.RefreshToken) error {
+	log.Printf("Refresh token reuse detected for user %d, revoking family %s", stored.UserID, stored.FamilyID)
+	if err := s.refreshTokenRepo.RevokeFamily(stored.FamilyID); err != nil {
+		return err
+	}
+	if err := s.sessionRepo.RevokeFamily(stored.FamilyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
 func (s *UserService) GetUserByID(id uint) (user.User, error) {
 	// Пробуем получить пользователя из кэша
 	cacheKey := fmt.Sprintf("user:id:%d", id)
@@ -116,11 +455,90 @@ func (s *UserService) GetUserByID(id uint) (user.User, error) {
 	return u, nil
 }
 
+// AssignRole назначает пользователю роль. Последнего администратора понизить нельзя.
+// Старые access-токены отзываются, чтобы новые права вступили в силу сразу,
+// а refresh-токены остаются рабочими и выдадут токен уже с новыми claims.
+func (s *UserService) AssignRole(userID uint, roleName string) (user.User, error) {
+	if _, err := s.roles.GetRoleByName(roleName); err != nil {
+		return user.User{}, err
+	}
+
+	var updated user.User
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		users := user.NewRepository(tx)
+		u, err := users.FindByID(userID)
+		if err != nil {
+			return err
+		}
+
+		if u.Role == role.Admin && roleName != role.Admin {
+			if err := ensureNotLastAdmin(users, "demote"); err != nil {
+				return err
+			}
+		}
+
+		if err := users.UpdateRole(u.ID, roleName); err != nil {
+			return err
+		}
+		u.Role = roleName
+		updated = u
+		return nil
+	})
+	if err != nil {
+		return user.User{}, err
+	}
+
+	s.invalidateUserCache(updated)
+	if err := s.revocations.RevokeAllForUser(updated.ID); err != nil {
+		return user.User{}, err
+	}
+	return updated, nil
+}
+
+// UnlockUser снимает блокировку входа с аккаунта пользователя
+func (s *UserService) UnlockUser(userID uint) (user.User, error) {
+	u, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return user.User{}, err
+	}
+	if err := s.lockout.Unlock(u.Email); err != nil {
+		return user.User{}, err
+	}
+	return u, nil
+}
+
+// invalidateUserCache удаляет пользователя из кэша по всем ключам
+func (s *UserService) invalidateUserCache(u user.User) {
+	s.cache.Delete(fmt.Sprintf("user:id:%d", u.ID))
+	s.cache.Delete(fmt.Sprintf("user:email:%s", u.Email))
+}
+
 func (s *UserService) GetAll() ([]user.User, error) {
 	return s.userRepo.FindAll()
 }
 
-// GetAllWithDetails возвращает всех пользователей с дополнительными данными
+// GetPage возвращает одну страницу пользователей, подходящих под фильтр
+func (s *UserService) GetPage(params pagination.Params, filter user.Filter) (pagination.Page[user.User], error) {
+	items, total, err := s.userRepo.FindPage(params, filter)
+	if err != nil {
+		return pagination.Page[user.User]{}, err
+	}
+	return pagination.NewPage(items, total, params, filter.CursorOf), nil
+}
+
+// GetLoginHistory возвращает страницу истории входов пользователя, новые события первыми
+func (s *UserService) GetLoginHistory(userID uint, params pagination.Params) (pagination.Page[session.LoginEvent], error) {
+	items, total, err := s.sessionRepo.FindLoginEventsPage(userID, params)
+	if err != nil {
+		return pagination.Page[session.LoginEvent]{}, err
+	}
+	return pagination.NewPage(items, total, params, func(e session.LoginEvent) pagination.Cursor {
+		return pagination.Cursor{ID: e.ID, Sort: session.LoginEventsOrder.Key()}
+	}), nil
+}
+
+// GetAllWithDetails возвращает всех пользователей с дополнительными данными.
+// Статистика входов и сессий считается одним запросом на весь список.
 func (s *UserService) GetAllWithDetails(ctx context.Context) ([]UserWithDetails, error) {
 	// Получаем базовый список пользователей
 	users, err := s.userRepo.FindAll()
@@ -128,84 +546,28 @@ func (s *UserService) GetAllWithDetails(ctx context.Context) ([]UserWithDetails,
 		return nil, err
 	}
 
-	var wg sync.WaitGroup
-	results := make([]UserWithDetails, len(users))
-	errors := make(chan error, len(users))
-
-	// Для каждого пользователя запускаем горутину для получения дополнительных данных
+	ids := make([]uint, len(users))
 	for i, u := range users {
-		wg.Add(1)
-		go func(index int, userItem user.User) {
-			defer wg.Done()
-
-			// Создаем каналы для получения данных
-			lastLoginChan := make(chan time.Time, 1)
-			loginCountChan := make(chan int, 1)
-			sessionsChan := make(chan int, 1)
-
-			// Запускаем горутины для получения разных типов данных
-			go func() {
-				defer close(lastLoginChan)
-				// Здесь можно добавить реальную логику получения времени последнего входа
-				lastLoginChan <- time.Now() // Заглушка
-			}()
-
-			go func() {
-				defer close(loginCountChan)
-				// Здесь можно добавить реальную логику получения количества входов
-				loginCountChan <- 0 // Заглушка
-			}()
-
-			go func() {
-				defer close(sessionsChan)
-				// Здесь можно добавить реальную логику получения активных сессий
-				sessionsChan <- 0 // Заглушка
-			}()
-
-			// Используем select для получения данных с таймаутом
-			select {
-			case lastLogin := <-lastLoginChan:
-				select {
-				case loginCount := <-loginCountChan:
-					select {
-					case sessions := <-sessionsChan:
-						results[index] = UserWithDetails{
-							User:           userItem,
-							LastLoginAt:    lastLogin,
-							LoginCount:     loginCount,
-							ActiveSessions: sessions,
-						}
-					case <-time.After(s.config.Timeout.UserDetails):
-						errors <- fmt.Errorf("timeout getting sessions for user %d", userItem.ID)
-					}
-				case <-time.After(s.config.Timeout.UserDetails):
-					errors <- fmt.Errorf("timeout getting login count for user %d", userItem.ID)
-				}
-			case <-time.After(s.config.Timeout.UserDetails):
-				errors <- fmt.Errorf("timeout getting last login for user %d", userItem.ID)
-			case <-ctx.Done():
-				errors <- ctx.Err()
-			}
-		}(i, u)
+		ids[i] = u.ID
 	}
 
-	// Запускаем горутину для закрытия канала ошибок после завершения всех операций
-	go func() {
-		wg.Wait()
-		close(errors)
-	}()
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout.UserDetails)
+	defer cancel()
 
-	// Собираем ошибки
-	var errs []error
-	for err := range errors {
-		if err != nil {
-			errs = append(errs, err)
-		}
+	stats, err := s.sessionRepo.StatsByUserIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load login statistics: %w", err)
 	}
 
-	// Если есть ошибки, возвращаем их
-	if len(errs) > 0 {
-		return results, fmt.Errorf("errors occurred while fetching user details: %v", errs)
+	results := make([]UserWithDetails, len(users))
+	for i, u := range users {
+		userStats := stats[u.ID]
+		results[i] = UserWithDetails{
+			User:           u,
+			LastLoginAt:    userStats.LastLoginAt,
+			LoginCount:     int(userStats.LoginCount),
+			ActiveSessions: int(userStats.ActiveSessions),
+		}
 	}
 
 	return results, nil
@@ -233,16 +595,18 @@ func (s *UserService) CreateUser(req CreateUserRequest) (user.User, error) {
 		Name:          req.Name,
 		Age:           req.Age,
 		City:          req.City,
+		Role:          role.Reader,
 		IsActive:      true,
 		IsActive_at:   time.Now(),
-		IsVerified:    true,
-		IsVerified_at: time.Now(),
+		IsVerified:    false,
+		IsVerified_at: time.Time{},
 		IsDeleted:     false,
 	}
 
 	if err := s.userRepo.Create(newUser); err != nil {
 		return user.User{}, err
 	}
+	s.trySendVerification(*newUser)
 
 	// Сохраняем пользователя в кэш
 	cacheKey := fmt.Sprintf("user:email:%s", req.Email)
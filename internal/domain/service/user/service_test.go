@@ -0,0 +1,342 @@
+package service
+
+import (
+	"awesomeProject/internal/cache"
+	"awesomeProject/internal/config"
+	"awesomeProject/internal/domain/model/audit"
+	"awesomeProject/internal/domain/model/common"
+	"awesomeProject/internal/domain/model/mfa"
+	"awesomeProject/internal/domain/model/permission"
+	"awesomeProject/internal/domain/model/refresh_token"
+	"awesomeProject/internal/domain/model/role"
+	"awesomeProject/internal/domain/model/session"
+	"awesomeProject/internal/domain/model/user"
+	"awesomeProject/internal/lockout"
+	"awesomeProject/internal/mailer"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeUsers хранит пользователей в памяти. Методы, которые не нужны тестам,
+// не реализованы и паникуют через встроенный nil-интерфейс.
+type fakeUsers struct {
+	user.Repository
+	mu    sync.Mutex
+	items map[uint]user.User
+	next  uint
+	// updateErr возвращается из UpdateFields вместо сохранения, например как гонка за уникальный email
+	updateErr error
+}
+
+func newFakeUsers(users ...user.User) *fakeUsers {
+	f := &fakeUsers{items: map[uint]user.User{}}
+	for _, u := range users {
+		f.items[u.ID] = u
+		if u.ID > f.next {
+			f.next = u.ID
+		}
+	}
+	return f
+}
+
+func (f *fakeUsers) FindByEmail(email string) (user.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, u := range f.items {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return user.User{}, user.ErrNotFound
+}
+
+func (f *fakeUsers) FindByID(id uint) (user.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	u, ok := f.items[id]
+	if !ok {
+		return user.User{}, user.ErrNotFound
+	}
+	return u, nil
+}
+
+func (f *fakeUsers) Create(u *user.User) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.next++
+	u.ID = f.next
+	f.items[u.ID] = *u
+	return nil
+}
+
+func (f *fakeUsers) MarkVerified(id uint, at time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	u := f.items[id]
+	u.IsVerified, u.IsVerified_at = true, at
+	f.items[id] = u
+	return nil
+}
+
+func (f *fakeUsers) ClaimVerificationSend(id uint, now time.Time, interval time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	u := f.items[id]
+	if u.VerificationSentAt != nil && u.VerificationSentAt.After(now.Add(-interval)) {
+		return false, nil
+	}
+	u.VerificationSentAt = &now
+	f.items[id] = u
+	return true, nil
+}
+
+func (f *fakeUsers) UpdateFields(id uint, fields map[string]interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.updateErr != nil {
+		return f.updateErr
+	}
+	u, ok := f.items[id]
+	if !ok {
+		return user.ErrNotFound
+	}
+	for name, value := range fields {
+		switch name {
+		case "name":
+			u.Name = value.(string)
+		case "age":
+			u.Age = value.(int)
+		case "city":
+			u.City = value.(string)
+		case "email":
+			u.Email = value.(string)
+		case "is_verified":
+			u.IsVerified = value.(bool)
+		case "is_verified_at":
+			u.IsVerified_at = value.(time.Time)
+		case "is_active":
+			u.IsActive = value.(bool)
+		case "is_active_at":
+			u.IsActive_at = value.(time.Time)
+		default:
+			panic("fakeUsers.UpdateFields: unexpected column " + name)
+		}
+	}
+	f.items[id] = u
+	return nil
+}
+
+func (f *fakeUsers) UpdatePassword(id uint, hashedPassword string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	u, ok := f.items[id]
+	if !ok {
+		return user.ErrNotFound
+	}
+	u.Password = hashedPassword
+	f.items[id] = u
+	return nil
+}
+
+func (f *fakeUsers) LockIDsByRole(role string) ([]uint, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var ids []uint
+	for _, u := range f.items {
+		if u.Role == role {
+			ids = append(ids, u.ID)
+		}
+	}
+	return ids, nil
+}
+
+// fakeRoles отдает встроенные роли с их правами
+type fakeRoles struct {
+	role.Repository
+	permissions map[string][]string
+}
+
+func (f fakeRoles) FindByName(name string) (role.Role, error) {
+	names, ok := f.permissions[name]
+	if !ok {
+		return role.Role{}, role.ErrNotFound
+	}
+	r := role.Role{RoleName: name}
+	for _, n := range names {
+		r.Permissions = append(r.Permissions, permission.Permission{Name: n})
+	}
+	return r, nil
+}
+
+// fakeAudit запоминает записи журнала аудита
+type fakeAudit struct {
+	audit.Repository
+	mu     sync.Mutex
+	events []audit.Event
+}
+
+func (f *fakeAudit) Create(event *audit.Event) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, *event)
+	return nil
+}
+
+// actions возвращает действия из журнала по порядку
+func (f *fakeAudit) actions() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	actions := make([]string, len(f.events))
+	for i, e := range f.events {
+		actions[i] = e.Action
+	}
+	return actions
+}
+
+// testConfig - настройки с заданными секретами и короткими сроками
+func testConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Timeout.RequestTimeout = time.Second
+	cfg.Verification.Secret = "test-verification-secret"
+	cfg.Verification.TTL = time.Hour
+	cfg.Verification.BaseURL = "http://app.test"
+	cfg.MFA.Issuer = "Test"
+	cfg.MFA.EncryptionKey = "test-mfa-encryption-key"
+	cfg.MFA.ChallengeSecret = "test-mfa-challenge-secret"
+	cfg.MFA.ChallengeTTL = time.Minute
+	cfg.Export.LinkSecret = "test-export-link-secret"
+	return cfg
+}
+
+// newTestService собирает UserService поверх фейковых репозиториев и почты в памяти
+func newTestService(t *testing.T, users *fakeUsers) (*UserService, *mailer.MemoryMailer) {
+	t.Helper()
+	c := cache.GetCache()
+	c.Clear()
+	mail := mailer.NewMemoryMailer()
+	return &UserService{
+		userRepo: users,
+		mailer:   mail,
+		cache:    c,
+		config:   testConfig(),
+	}, mail
+}
+
+// waitForMail ждет письмо на адрес to: часть писем отправляется в фоне
+func waitForMail(t *testing.T, m *mailer.MemoryMailer, to string) mailer.Message {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if msg, ok := m.Last(to); ok {
+			return msg
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("no email sent to %s", to)
+	return mailer.Message{}
+}
+
+func testUser(id uint, email string) user.User {
+	return user.User{Base: common.Base{ID: id}, Email: email, Name: "Test", IsActive: true}
+}
+
+func (f *fakeUsers) UpdateToken(uint, string) error { return nil }
+
+// fakeLoginSessions запоминает записи истории входов и принимает новые сессии
+type fakeLoginSessions struct {
+	session.Repository
+	events []session.LoginEvent
+}
+
+func (f *fakeLoginSessions) CreateLoginEvent(event *session.LoginEvent) error {
+	f.events = append(f.events, *event)
+	return nil
+}
+
+func (f *fakeLoginSessions) Create(*session.Session) error { return nil }
+
+type acceptRefreshTokens struct{ refresh_token.Repository }
+
+func (acceptRefreshTokens) Create(*refresh_token.RefreshToken) error { return nil }
+
+// newLoginFixture собирает сервис, способный выдать токены при входе
+func newLoginFixture(t *testing.T, u user.User) (*UserService, *fakeLoginSessions) {
+	t.Helper()
+	s := newImpersonationFixture(t, u).service
+	sessions := &fakeLoginSessions{}
+	s.sessionRepo = sessions
+	s.refreshTokenRepo = acceptRefreshTokens{}
+	s.mfaRepo = &fakeFactors{items: map[uint]mfa.Factor{}}
+	s.lockout = lockout.NewGuard(lockout.NewCacheStore(s.cache), s.config)
+	return s, sessions
+}
+
+func TestLoginRecordsOutcome(t *testing.T) {
+	hash, err := user.HashPassword("secret-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	confirmed := time.Now()
+
+	tests := []struct {
+		name        string
+		edit        func(u *user.User)
+		mfa         bool
+		password    string
+		wantErr     error
+		wantTokens  bool
+		wantSuccess bool
+		wantReason  string
+	}{
+		{name: "tokens issued", password: "secret-password", wantTokens: true, wantSuccess: true},
+		{name: "wrong password", password: "nope", wantReason: session.FailureWrongPassword},
+		{
+			name:       "disabled account",
+			edit:       func(u *user.User) { u.IsActive = false },
+			password:   "secret-password",
+			wantErr:    ErrAccountDisabled,
+			wantReason: session.FailureDisabled,
+		},
+		{
+			name:       "unverified email",
+			edit:       func(u *user.User) { u.IsVerified = false },
+			password:   "secret-password",
+			wantErr:    ErrEmailNotVerified,
+			wantReason: session.FailureNotVerified,
+		},
+		{name: "second factor pending", mfa: true, password: "secret-password", wantReason: session.FailureMFAPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := userWithRole(1, role.Reader)
+			u.Email, u.Password, u.IsVerified = "ann@example.com", hash, true
+			if tt.edit != nil {
+				tt.edit(&u)
+			}
+			s, sessions := newLoginFixture(t, u)
+			s.config.Verification.Required = true
+			if tt.mfa {
+				s.mfaRepo.(*fakeFactors).items[u.ID] = mfa.Factor{UserID: u.ID, ConfirmedAt: &confirmed}
+			}
+
+			response, err := s.Login(u.Email, tt.password, ClientInfo{IP: "10.0.0.1"})
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got := response.Token != ""; got != tt.wantTokens {
+				t.Fatalf("tokens issued = %v, want %v", got, tt.wantTokens)
+			}
+			if len(sessions.events) != 1 {
+				t.Fatalf("recorded %d login events, want 1", len(sessions.events))
+			}
+			event := sessions.events[0]
+			if event.Success != tt.wantSuccess || event.FailureReason != tt.wantReason {
+				t.Fatalf("recorded success=%v reason=%q, want success=%v reason=%q",
+					event.Success, event.FailureReason, tt.wantSuccess, tt.wantReason)
+			}
+		})
+	}
+}
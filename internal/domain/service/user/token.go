@@ -0,0 +1,91 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// randomToken возвращает криптографически случайную строку из n байт в base64url
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken возвращает SHA-256 хеш токена в hex. В базе хранятся только хеши,
+// поэтому утечка таблицы не даёт рабочих токенов.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// signedTokenPayload - содержимое подписанного токена. Purpose не дает использовать
+// токен одного назначения для другого, Email делает токен недействительным после смены адреса.
+// Enrollment есть только у mfa_token: второй шаг входа - настройка 2FA, а не ввод кода.
+// ResourceID - объект, к которому токен дает доступ, например архив выгрузки.
+type signedTokenPayload struct {
+	Purpose    string `json:"p"`
+	UserID     uint   `json:"u"`
+	Email      string `json:"e"`
+	ExpiresAt  int64  `json:"x"`
+	Enrollment bool   `json:"n,omitempty"`
+	ResourceID uint   `json:"r,omitempty"`
+}
+
+// signToken выпускает токен вида base64url(payload).base64url(HMAC-SHA256(payload))
+func signToken(secret string, payload signedTokenPayload) (string, error) {
+	if secret == "" {
+		return "", errEmptySecret
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(tokenSignature(secret, encoded)), nil
+}
+
+// parseSignedToken проверяет подпись, назначение и срок действия токена
+func parseSignedToken(secret, purpose, token string, now time.Time) (signedTokenPayload, error) {
+	var payload signedTokenPayload
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || secret == "" {
+		return payload, errInvalidSignedToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, tokenSignature(secret, encoded)) {
+		return payload, errInvalidSignedToken
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return payload, errInvalidSignedToken
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return payload, errInvalidSignedToken
+	}
+	if payload.Purpose != purpose || now.Unix() >= payload.ExpiresAt {
+		return payload, errInvalidSignedToken
+	}
+	return payload, nil
+}
+
+var (
+	errInvalidSignedToken = errors.New("invalid signed token")
+	// errEmptySecret - секрет подписи не настроен; такие токены не выпускаются и не принимаются
+	errEmptySecret = errors.New("token signing secret is not configured")
+)
+
+func tokenSignature(secret, data string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"awesomeProject/internal/domain/model/user"
+	"awesomeProject/internal/mailer"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+)
+
+const verifyEmailPurpose = "verify-email"
+
+// resendVerificationInterval - минимальный интервал между письмами подтверждения на один адрес
+const resendVerificationInterval = time.Minute
+
+var (
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	ErrEmailNotVerified         = errors.New("email is not verified")
+)
+
+// VerifyEmail подтверждает email по токену из письма. Повторное подтверждение не считается ошибкой.
+func (s *UserService) VerifyEmail(token string) (user.User, error) {
+	payload, err := parseSignedToken(s.config.Verification.Secret, verifyEmailPurpose, token, time.Now())
+	if err != nil {
+		return user.User{}, ErrInvalidVerificationToken
+	}
+
+	u, err := s.userRepo.FindByID(payload.UserID)
+	if err != nil {
+		if errors.Is(err, user.ErrNotFound) {
+			return user.User{}, ErrInvalidVerificationToken
+		}
+		return user.User{}, err
+	}
+	// Токен выдан на другой адрес: email с тех пор сменился
+	if u.Email != payload.Email {
+		return user.User{}, ErrInvalidVerificationToken
+	}
+	if u.IsVerified {
+		return u, nil
+	}
+
+	now := time.Now()
+	if err := s.userRepo.MarkVerified(u.ID, now); err != nil {
+		return user.User{}, err
+	}
+	u.IsVerified = true
+	u.IsVerified_at = now
+	s.invalidateUserCache(u)
+	return u, nil
+}
+
+// ResendVerification повторно отправляет письмо подтверждения. Чтобы ни ответ, ни его время
+// не выдавали зарегистрированные адреса, письмо уходит в фоне, а ошибок у метода нет:
+// они только пишутся в лог. Интервал между письмами хранится в базе и общий для всех экземпляров.
+func (s *UserService) ResendVerification(email string) {
+	u, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		if !errors.Is(err, user.ErrNotFound) {
+			log.Printf("Failed to resend verification email: %v", err)
+		}
+		return
+	}
+	if u.IsVerified {
+		return
+	}
+
+	claimed, err := s.userRepo.ClaimVerificationSend(u.ID, time.Now(), resendVerificationInterval)
+	if err != nil {
+		log.Printf("Failed to resend verification email to user %d: %v", u.ID, err)
+		return
+	}
+	if claimed {
+		s.trySendVerification(u)
+	}
+}
+
+// sendVerification отправляет пользователю письмо со ссылкой подтверждения email
+func (s *UserService) sendVerification(u user.User) error {
+	token, err := signToken(s.config.Verification.Secret, signedTokenPayload{
+		Purpose:   verifyEmailPurpose,
+		UserID:    u.ID,
+		Email:     u.Email,
+		ExpiresAt: time.Now().Add(s.config.Verification.TTL).Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := s.config.Verification.BaseURL + "/verify-email?token=" + url.QueryEscape(token)
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout.RequestTimeout)
+	defer cancel()
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      u.Email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf("Hello, %s!\n\nConfirm your email by opening the link below:\n%s\n\n"+
+			"If the link does not open, send this token to POST /auth/verify-email:\n%s\n\n"+
+			"The link expires in %s.\n", u.Name, link, token, s.config.Verification.TTL),
+	})
+}
+
+// trySendVerification отправляет письмо подтверждения в фоне: медленный SMTP-сервер
+// не задерживает ответ, а ошибка отправки не прерывает основной сценарий
+func (s *UserService) trySendVerification(u user.User) {
+	go func() {
+		if err := s.sendVerification(u); err != nil {
+			log.Printf("Failed to send verification email to %s: %v", u.Email, err)
+		}
+	}()
+}
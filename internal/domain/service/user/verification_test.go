@@ -0,0 +1,177 @@
+package service
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var verificationLink = regexp.MustCompile(`/verify-email\?token=(\S+)`)
+
+// tokenFromMail достает токен подтверждения из ссылки в письме
+func tokenFromMail(t *testing.T, body string) string {
+	t.Helper()
+	match := verificationLink.FindStringSubmatch(body)
+	if match == nil {
+		t.Fatalf("no verification link in %q", body)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestCreateUserSendsVerificationEmail(t *testing.T) {
+	users := newFakeUsers()
+	s, mail := newTestService(t, users)
+
+	created, err := s.CreateUser(CreateUserRequest{Name: "Ann", Age: 30, City: "Riga", Password: "secret1", Email: "ann@example.com"})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if created.IsVerified {
+		t.Fatal("new user must start unverified")
+	}
+
+	msg := waitForMail(t, mail, "ann@example.com")
+	if !strings.Contains(msg.Body, "http://app.test/verify-email?token=") {
+		t.Errorf("body does not link to APP_BASE_URL: %q", msg.Body)
+	}
+	verified, err := s.VerifyEmail(tokenFromMail(t, msg.Body))
+	if err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	if !verified.IsVerified {
+		t.Error("user is not verified")
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	sign := func(t *testing.T, secret string, payload signedTokenPayload) string {
+		t.Helper()
+		token, err := signToken(secret, payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	valid := signedTokenPayload{Purpose: verifyEmailPurpose, UserID: 1, Email: "ann@example.com", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+
+	tests := []struct {
+		name    string
+		token   func(t *testing.T) string
+		secret  string
+		wantErr error
+	}{
+		{
+			name:  "valid",
+			token: func(t *testing.T) string { return sign(t, "test-verification-secret", valid) },
+		},
+		{
+			name:    "another secret",
+			token:   func(t *testing.T) string { return sign(t, "other-secret", valid) },
+			wantErr: ErrInvalidVerificationToken,
+		},
+		{
+			name: "another purpose",
+			token: func(t *testing.T) string {
+				p := valid
+				p.Purpose = mfaChallengePurpose
+				return sign(t, "test-verification-secret", p)
+			},
+			wantErr: ErrInvalidVerificationToken,
+		},
+		{
+			name: "expired",
+			token: func(t *testing.T) string {
+				p := valid
+				p.ExpiresAt = time.Now().Add(-time.Second).Unix()
+				return sign(t, "test-verification-secret", p)
+			},
+			wantErr: ErrInvalidVerificationToken,
+		},
+		{
+			name: "email changed",
+			token: func(t *testing.T) string {
+				p := valid
+				p.Email = "old@example.com"
+				return sign(t, "test-verification-secret", p)
+			},
+			wantErr: ErrInvalidVerificationToken,
+		},
+		{
+			name: "tampered payload",
+			token: func(t *testing.T) string {
+				token := sign(t, "test-verification-secret", valid)
+				return "x" + token[1:]
+			},
+			wantErr: ErrInvalidVerificationToken,
+		},
+		{
+			name:    "secret not configured",
+			token:   func(t *testing.T) string { return sign(t, "test-verification-secret", valid) },
+			secret:  "-",
+			wantErr: ErrInvalidVerificationToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t, newFakeUsers(testUser(1, "ann@example.com")))
+			token := tt.token(t)
+			if tt.secret == "-" {
+				s.config.Verification.Secret = ""
+			}
+			_, err := s.VerifyEmail(token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	verified := testUser(2, "verified@example.com")
+	verified.IsVerified = true
+	recent := time.Now().Add(-10 * time.Second)
+	sentRecently := testUser(3, "recent@example.com")
+	sentRecently.VerificationSentAt = &recent
+	old := time.Now().Add(-time.Hour)
+	sentLongAgo := testUser(4, "old@example.com")
+	sentLongAgo.VerificationSentAt = &old
+
+	tests := []struct {
+		name      string
+		email     string
+		calls     int
+		wantMails int
+	}{
+		{name: "unverified", email: "ann@example.com", calls: 1, wantMails: 1},
+		{name: "throttled", email: "ann@example.com", calls: 3, wantMails: 1},
+		{name: "sent recently, for example by another instance", email: "recent@example.com", calls: 1, wantMails: 0},
+		{name: "sent long ago", email: "old@example.com", calls: 1, wantMails: 1},
+		{name: "already verified", email: "verified@example.com", calls: 1, wantMails: 0},
+		{name: "unknown address", email: "nobody@example.com", calls: 1, wantMails: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mail := newTestService(t, newFakeUsers(testUser(1, "ann@example.com"), verified, sentRecently, sentLongAgo))
+			for i := 0; i < tt.calls; i++ {
+				s.ResendVerification(tt.email)
+			}
+			// Отправка идет в фоне, но решение об отправке принимается до возврата,
+			// поэтому после первого письма новых уже не будет
+			if tt.wantMails > 0 {
+				waitForMail(t, mail, tt.email)
+			}
+			if got := len(mail.Messages()); got != tt.wantMails {
+				t.Errorf("sent %d emails, want %d", got, tt.wantMails)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Exporter выгружает в архив данные одного домена, связанные с пользователем
+type Exporter interface {
+	Export(ctx context.Context, db *gorm.DB, userID uint, archive *Archive) error
+}
+
+// ExporterFunc позволяет использовать обычную функцию как Exporter
+type ExporterFunc func(ctx context.Context, db *gorm.DB, userID uint, archive *Archive) error
+
+func (f ExporterFunc) Export(ctx context.Context, db *gorm.DB, userID uint, archive *Archive) error {
+	return f(ctx, db, userID, archive)
+}
+
+var (
+	mu        sync.RWMutex
+	exporters = make(map[string]Exporter)
+)
+
+// Register добавляет экспортер домена. Пакеты моделей вызывают его в init,
+// поэтому новая модель попадает в выгрузку, как только регистрирует свой экспортер.
+func Register(name string, exporter Exporter) {
+	mu.Lock()
+	defer mu.Unlock()
+	if exporter == nil {
+		panic("export: Register exporter is nil")
+	}
+	if _, dup := exporters[name]; dup {
+		panic("export: Register called twice for exporter " + name)
+	}
+	exporters[name] = exporter
+}
+
+// Names возвращает имена зарегистрированных экспортеров по алфавиту
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(exporters))
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Manifest - описание архива, записывается в manifest.json
+type Manifest struct {
+	UserID      uint      `json:"user_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Sections    []string  `json:"sections"`
+}
+
+// Build собирает ZIP-архив со всеми данными пользователя, вызывая экспортеры по очереди
+func Build(ctx context.Context, db *gorm.DB, userID uint, w io.Writer) error {
+	archive := NewArchive(w)
+	names := Names()
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		mu.RLock()
+		exporter := exporters[name]
+		mu.RUnlock()
+		if err := exporter.Export(ctx, db.WithContext(ctx), userID, archive); err != nil {
+			return fmt.Errorf("export %s: %w", name, err)
+		}
+	}
+	if err := archive.WriteJSON("manifest.json", Manifest{
+		UserID:      userID,
+		GeneratedAt: time.Now(),
+		Sections:    names,
+	}); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// Archive - ZIP-архив выгрузки, в который экспортеры пишут свои файлы
+type Archive struct {
+	zw *zip.Writer
+}
+
+func NewArchive(w io.Writer) *Archive {
+	return &Archive{zw: zip.NewWriter(w)}
+}
+
+// WriteJSON записывает v в архив как JSON-файл name
+func (a *Archive) WriteJSON(name string, v any) error {
+	f, err := a.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// CopyFile копирует в архив файл с диска path под именем name
+func (a *Archive) CopyFile(name, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+
+	dst, err := a.zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// Close дописывает оглавление архива
+func (a *Archive) Close() error {
+	return a.zw.Close()
+}
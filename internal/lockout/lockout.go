@@ -0,0 +1,169 @@
+package lockout
+
+import (
+	"awesomeProject/internal/cache"
+	"awesomeProject/internal/config"
+	"awesomeProject/internal/database"
+	"awesomeProject/internal/metrics"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Области счетчиков: по аккаунту (email) и по IP клиента
+const (
+	ScopeAccount = "account"
+	ScopeIP      = "ip"
+)
+
+var ErrLocked = errors.New("too many failed login attempts")
+
+// LockedError сообщает, через сколько можно повторить попытку входа
+type LockedError struct {
+	Scope      string
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s, retry in %s", ErrLocked, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LockedError) Unwrap() error {
+	return ErrLocked
+}
+
+// Guard ограничивает попытки входа: после каждой неудачи следующая попытка
+// откладывается экспоненциально, а после порога неудач ключ блокируется на время.
+type Guard struct {
+	store  Store
+	config *config.Config
+}
+
+func NewGuard(store Store, cfg *config.Config) *Guard {
+	return &Guard{store: store, config: cfg}
+}
+
+// NewGuardFromConfig создает Guard с хранилищем из LOCKOUT_STORE.
+// Хранилище в кэше не работает при выключенном кэше, тогда используется база.
+func NewGuardFromConfig() *Guard {
+	cfg := config.GetConfig()
+	c := cache.GetCache()
+
+	var store Store
+	switch {
+	case cfg.Lockout.Store == "database":
+		store = NewDBStore(database.GetDB())
+	case !c.Enabled():
+		log.Printf("Cache is disabled, storing login lockouts in the database")
+		store = NewDBStore(database.GetDB())
+	default:
+		store = NewCacheStore(c)
+	}
+	return NewGuard(store, cfg)
+}
+
+// Check возвращает *LockedError, если вход для аккаунта или IP сейчас запрещен
+func (g *Guard) Check(email, ip string) error {
+	if !g.config.Lockout.Enabled {
+		return nil
+	}
+	now := time.Now()
+	for _, k := range g.keys(email, ip) {
+		state, err := g.store.Get(k.key)
+		if err != nil {
+			return err
+		}
+		if now.Before(state.LockedUntil) {
+			metrics.RecordLoginBlocked(k.scope)
+			return &LockedError{Scope: k.scope, RetryAfter: state.LockedUntil.Sub(now)}
+		}
+	}
+	return nil
+}
+
+// Fail учитывает неудачный вход и откладывает следующую попытку
+func (g *Guard) Fail(email, ip string) error {
+	if !g.config.Lockout.Enabled {
+		return nil
+	}
+	ttl := g.config.Lockout.Window
+	if ttl < g.config.Lockout.Duration {
+		ttl = g.config.Lockout.Duration
+	}
+
+	for _, k := range g.keys(email, ip) {
+		state, err := g.store.Increment(k.key, ttl)
+		if err != nil {
+			return err
+		}
+		delay := g.delay(state.Failures, k.threshold)
+		if delay >= g.config.Lockout.Duration {
+			metrics.RecordLoginLockout(k.scope)
+			log.Printf("Login locked for %s %s after %d failed attempts", k.scope, k.value, state.Failures)
+		}
+		if err := g.store.Lock(k.key, time.Now().Add(delay), ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Succeed сбрасывает счетчик аккаунта после успешного входа. Счетчик IP не сбрасывается,
+// иначе вход в свой аккаунт позволял бы продолжать перебор чужих.
+func (g *Guard) Succeed(email string) error {
+	if !g.config.Lockout.Enabled {
+		return nil
+	}
+	return g.store.Reset(accountKey(email))
+}
+
+// Unlock снимает блокировку аккаунта вручную
+func (g *Guard) Unlock(email string) error {
+	return g.store.Reset(accountKey(email))
+}
+
+// delay возвращает задержку перед следующей попыткой после failures неудач
+func (g *Guard) delay(failures, threshold int) time.Duration {
+	if failures >= threshold {
+		return g.config.Lockout.Duration
+	}
+	delay := g.config.Lockout.BaseDelay
+	for i := 1; i < failures && delay < g.config.Lockout.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > g.config.Lockout.MaxDelay {
+		delay = g.config.Lockout.MaxDelay
+	}
+	return delay
+}
+
+type guardKey struct {
+	scope     string
+	value     string
+	key       string
+	threshold int
+}
+
+func (g *Guard) keys(email, ip string) []guardKey {
+	keys := []guardKey{{
+		scope:     ScopeAccount,
+		value:     email,
+		key:       accountKey(email),
+		threshold: g.config.Lockout.AccountThreshold,
+	}}
+	if ip != "" {
+		keys = append(keys, guardKey{
+			scope:     ScopeIP,
+			value:     ip,
+			key:       "ip:" + ip,
+			threshold: g.config.Lockout.IPThreshold,
+		})
+	}
+	return keys
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
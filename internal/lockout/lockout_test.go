@@ -0,0 +1,145 @@
+package lockout
+
+import (
+	"awesomeProject/internal/config"
+	"errors"
+	"testing"
+	"time"
+)
+
+// memoryStore - хранилище счетчиков в памяти для тестов
+type memoryStore map[string]State
+
+func (m memoryStore) Get(key string) (State, error) {
+	return m[key], nil
+}
+
+func (m memoryStore) Increment(key string, _ time.Duration) (State, error) {
+	state := m[key]
+	state.Failures++
+	m[key] = state
+	return state, nil
+}
+
+func (m memoryStore) Lock(key string, until time.Time, _ time.Duration) error {
+	state := m[key]
+	state.LockedUntil = until
+	m[key] = state
+	return nil
+}
+
+func (m memoryStore) Reset(key string) error {
+	delete(m, key)
+	return nil
+}
+
+func testConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Lockout.Enabled = true
+	cfg.Lockout.AccountThreshold = 5
+	cfg.Lockout.IPThreshold = 20
+	cfg.Lockout.BaseDelay = time.Second
+	cfg.Lockout.MaxDelay = 10 * time.Second
+	cfg.Lockout.Duration = 15 * time.Minute
+	cfg.Lockout.Window = time.Hour
+	return cfg
+}
+
+func TestDelay(t *testing.T) {
+	g := NewGuard(memoryStore{}, testConfig())
+	tests := []struct {
+		failures  int
+		threshold int
+		want      time.Duration
+	}{
+		{failures: 1, threshold: 5, want: time.Second},
+		{failures: 2, threshold: 5, want: 2 * time.Second},
+		{failures: 3, threshold: 5, want: 4 * time.Second},
+		{failures: 4, threshold: 20, want: 8 * time.Second},
+		{failures: 6, threshold: 20, want: 10 * time.Second},
+		{failures: 5, threshold: 5, want: 15 * time.Minute},
+		{failures: 9, threshold: 5, want: 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := g.delay(tt.failures, tt.threshold); got != tt.want {
+			t.Errorf("delay(%d, %d) = %s, want %s", tt.failures, tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestGuard(t *testing.T) {
+	tests := []struct {
+		name      string
+		disabled  bool
+		failures  int
+		succeed   bool
+		checkMail string
+		checkIP   string
+		wantScope string
+	}{
+		{name: "no failures", checkMail: "a@example.com", checkIP: "10.0.0.1"},
+		{name: "failure delays the account", failures: 1, checkMail: "a@example.com", checkIP: "10.0.0.2", wantScope: ScopeAccount},
+		{name: "email is normalised", failures: 1, checkMail: " A@Example.com ", checkIP: "10.0.0.2", wantScope: ScopeAccount},
+		{name: "failure delays the ip", failures: 1, checkMail: "b@example.com", checkIP: "10.0.0.1", wantScope: ScopeIP},
+		{name: "success resets the account only", failures: 1, succeed: true, checkMail: "a@example.com", checkIP: "10.0.0.1", wantScope: ScopeIP},
+		{name: "other account and ip are free", failures: 5, checkMail: "b@example.com", checkIP: "10.0.0.2"},
+		{name: "disabled", disabled: true, failures: 10, checkMail: "a@example.com", checkIP: "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Lockout.Enabled = !tt.disabled
+			g := NewGuard(memoryStore{}, cfg)
+
+			for i := 0; i < tt.failures; i++ {
+				if err := g.Fail("a@example.com", "10.0.0.1"); err != nil {
+					t.Fatalf("Fail: %v", err)
+				}
+			}
+			if tt.succeed {
+				if err := g.Succeed("a@example.com"); err != nil {
+					t.Fatalf("Succeed: %v", err)
+				}
+			}
+
+			err := g.Check(tt.checkMail, tt.checkIP)
+			if tt.wantScope == "" {
+				if err != nil {
+					t.Fatalf("Check: %v, want no lock", err)
+				}
+				return
+			}
+			var locked *LockedError
+			if !errors.As(err, &locked) || !errors.Is(err, ErrLocked) {
+				t.Fatalf("Check: %v, want *LockedError", err)
+			}
+			if locked.Scope != tt.wantScope || locked.RetryAfter <= 0 {
+				t.Errorf("locked %s for %s, want scope %s", locked.Scope, locked.RetryAfter, tt.wantScope)
+			}
+		})
+	}
+}
+
+func TestThresholdLocksForDuration(t *testing.T) {
+	cfg := testConfig()
+	g := NewGuard(memoryStore{}, cfg)
+	for i := 0; i < cfg.Lockout.AccountThreshold; i++ {
+		if err := g.Fail("a@example.com", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var locked *LockedError
+	if err := g.Check("a@example.com", ""); !errors.As(err, &locked) {
+		t.Fatalf("Check: %v, want *LockedError", err)
+	}
+	if locked.RetryAfter < cfg.Lockout.Duration-time.Second {
+		t.Errorf("retry after %s, want about %s", locked.RetryAfter, cfg.Lockout.Duration)
+	}
+	if err := g.Unlock("a@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Check("a@example.com", ""); err != nil {
+		t.Errorf("Check after Unlock: %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package lockout
+
+import (
+	"awesomeProject/internal/cache"
+	"awesomeProject/internal/domain/model/login_attempt"
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// State - состояние счетчика неудачных входов по одному ключу
+type State struct {
+	Failures    int
+	LockedUntil time.Time
+}
+
+// Store хранит счетчики неудачных входов. Реализация в кэше подходит для одного
+// экземпляра приложения, при нескольких экземплярах нужно общее хранилище (DBStore).
+type Store interface {
+	// Get возвращает текущее состояние ключа; для неизвестного ключа - нулевое
+	Get(key string) (State, error)
+	// Increment атомарно добавляет неудачу; состояние живет ttl после последней неудачи
+	Increment(key string, ttl time.Duration) (State, error)
+	// Lock запрещает вход по ключу до until
+	Lock(key string, until time.Time, ttl time.Duration) error
+	// Reset удаляет счетчик
+	Reset(key string) error
+}
+
+// CacheStore хранит счетчики в cache.Cache
+type CacheStore struct {
+	cache *cache.Cache
+	mu    sync.Mutex
+}
+
+func NewCacheStore(c *cache.Cache) *CacheStore {
+	return &CacheStore{cache: c}
+}
+
+func (s *CacheStore) Get(key string) (State, error) {
+	if value, ok := s.cache.Get(cacheKey(key)); ok {
+		return value.(State), nil
+	}
+	return State{}, nil
+}
+
+func (s *CacheStore) Increment(key string, ttl time.Duration) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, _ := s.Get(key)
+	state.Failures++
+	s.cache.SetWithTTL(cacheKey(key), state, ttl)
+	return state, nil
+}
+
+func (s *CacheStore) Lock(key string, until time.Time, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, _ := s.Get(key)
+	state.LockedUntil = until
+	s.cache.SetWithTTL(cacheKey(key), state, ttl)
+	return nil
+}
+
+func (s *CacheStore) Reset(key string) error {
+	s.cache.Delete(cacheKey(key))
+	return nil
+}
+
+func cacheKey(key string) string {
+	return "lockout:" + key
+}
+
+// DBStore хранит счетчики в таблице login_attempts_struct и работает между экземплярами
+type DBStore struct {
+	repo login_attempt.Repository
+}
+
+func NewDBStore(db *gorm.DB) *DBStore {
+	return &DBStore{repo: login_attempt.NewRepository(db)}
+}
+
+func (s *DBStore) Get(key string) (State, error) {
+	attempt, err := s.repo.FindActive(key, time.Now())
+	if err != nil {
+		if errors.Is(err, login_attempt.ErrNotFound) {
+			return State{}, nil
+		}
+		return State{}, err
+	}
+	return stateOf(attempt), nil
+}
+
+func (s *DBStore) Increment(key string, ttl time.Duration) (State, error) {
+	attempt, err := s.repo.Increment(key, time.Now(), ttl)
+	if err != nil {
+		return State{}, err
+	}
+	return stateOf(attempt), nil
+}
+
+func (s *DBStore) Lock(key string, until time.Time, _ time.Duration) error {
+	return s.repo.SetLockedUntil(key, until)
+}
+
+func (s *DBStore) Reset(key string) error {
+	return s.repo.Delete(key)
+}
+
+func stateOf(attempt login_attempt.LoginAttempt) State {
+	state := State{Failures: attempt.Failures}
+	if attempt.LockedUntil != nil {
+		state.LockedUntil = *attempt.LockedUntil
+	}
+	return state
+}
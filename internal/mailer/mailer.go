@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"awesomeProject/internal/config"
+	"context"
+	"log"
+	"sync"
+)
+
+// Message - письмо для отправки
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer отправляет письма пользователям
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var (
+	instance Mailer
+	once     sync.Once
+)
+
+// GetMailer возвращает отправщик писем, выбранный в MAIL_DRIVER
+func GetMailer() Mailer {
+	once.Do(func() {
+		instance = New(config.GetConfig())
+	})
+	return instance
+}
+
+// New создает отправщик писем по настройкам: smtp, file или memory
+func New(cfg *config.Config) Mailer {
+	switch cfg.Mail.Driver {
+	case "smtp":
+		return NewSMTPMailer(cfg.Mail.SMTPHost, cfg.Mail.SMTPPort, cfg.Mail.SMTPUsername, cfg.Mail.SMTPPassword, cfg.Mail.From)
+	case "memory":
+		return NewMemoryMailer()
+	case "file":
+		return NewFileMailer(cfg.Mail.Dir, cfg.Mail.From)
+	default:
+		log.Printf("Unknown MAIL_DRIVER %q, writing mail to %s", cfg.Mail.Driver, cfg.Mail.Dir)
+		return NewFileMailer(cfg.Mail.Dir, cfg.Mail.From)
+	}
+}
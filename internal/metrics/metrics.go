@@ -66,6 +66,23 @@ var (
 		},
 	)
 
+	// Метрики защиты от перебора паролей
+	loginBlocked = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "login_blocked_total",
+			Help: "Total number of login attempts rejected because of lockout or backoff",
+		},
+		[]string{"scope"},
+	)
+
+	loginLockouts = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "login_lockouts_total",
+			Help: "Total number of temporary lockouts after too many failed logins",
+		},
+		[]string{"scope"},
+	)
+
 	cacheSize = promauto.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "cache_size",
@@ -92,10 +109,10 @@ func MetricsMiddleware() gin.HandlerFunc {
 		switch {
 		case path == "/protected/news/all" || path == "/protected/news/all-with-details":
 			newsRequestDuration.WithLabelValues(path, method).Observe(duration)
-			newsRequestTotal.WithLabelValues(path, method, string(status)).Inc()
+			newsRequestTotal.WithLabelValues(path, method, strconv.Itoa(status)).Inc()
 		case path == "/protected/user/all" || path == "/protected/user/name/:id":
 			userRequestDuration.WithLabelValues(path, method).Observe(duration)
-			userRequestTotal.WithLabelValues(path, method, string(status)).Inc()
+			userRequestTotal.WithLabelValues(path, method, strconv.Itoa(status)).Inc()
 		}
 	}
 }
@@ -126,6 +143,16 @@ func RecordCacheMiss() {
 	cacheMisses.Inc()
 }
 
+// RecordLoginBlocked записывает отклоненную попытку входа; scope - account или ip
+func RecordLoginBlocked(scope string) {
+	loginBlocked.WithLabelValues(scope).Inc()
+}
+
+// RecordLoginLockout записывает временную блокировку аккаунта или IP
+func RecordLoginLockout(scope string) {
+	loginLockouts.WithLabelValues(scope).Inc()
+}
+
 // UpdateCacheSize обновляет размер кэша
 func UpdateCacheSize(size int) {
 	cacheSize.Set(float64(size))
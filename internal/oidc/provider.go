@@ -0,0 +1,249 @@
+// Package oidc реализует вход через внешнего OpenID Connect провайдера (authorization code + PKCE).
+// HTTP-клиент передается снаружи, поэтому провайдер можно подменить локальным сервером.
+package oidc
+
+import (
+	"awesomeProject/internal/config"
+	"awesomeProject/internal/signing"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrProviderUnavailable = errors.New("identity provider is unavailable")
+	ErrInvalidIDToken      = errors.New("invalid id token")
+)
+
+// jwksRefreshInterval - не чаще этого интервала ключи провайдера перечитываются из-за незнакомого kid
+const jwksRefreshInterval = time.Minute
+
+// Identity - пользователь, подтвержденный провайдером
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// discovery - нужная часть документа /.well-known/openid-configuration
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider - один настроенный провайдер. Документ discovery и ключи загружаются лениво и кэшируются.
+type Provider struct {
+	cfg         config.OIDCProvider
+	redirectURL string
+	client      *http.Client
+
+	mu            sync.Mutex
+	meta          *discovery
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+func NewProvider(cfg config.OIDCProvider, redirectURL string, client *http.Client) *Provider {
+	return &Provider{cfg: cfg, redirectURL: redirectURL, client: client}
+}
+
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// AuthCodeURL возвращает адрес страницы входа провайдера
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.redirectURL},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return meta.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange обменивает код авторизации на id_token и проверяет его
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (Identity, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.redirectURL},
+		"client_id":     {p.cfg.ClientID},
+		"client_secret": {p.cfg.ClientSecret},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Identity{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := p.do(req, &tokens); err != nil {
+		return Identity{}, err
+	}
+	if tokens.IDToken == "" {
+		return Identity{}, fmt.Errorf("%w: token response has no id_token", ErrInvalidIDToken)
+	}
+	return p.verifyIDToken(ctx, meta, tokens.IDToken, nonce)
+}
+
+// idTokenClaims - claims id_token, которые использует приложение
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+func (p *Provider) verifyIDToken(ctx context.Context, meta *discovery, raw, nonce string) (Identity, error) {
+	var claims idTokenClaims
+	_, err := jwt.ParseWithClaims(raw, &claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return p.key(ctx, meta, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256", "ES256", "ES384", "EdDSA"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30*time.Second))
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if claims.Nonce == "" || claims.Nonce != nonce {
+		return Identity{}, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	if claims.Subject == "" {
+		return Identity{}, fmt.Errorf("%w: missing sub", ErrInvalidIDToken)
+	}
+	return Identity{
+		Provider:      p.cfg.Name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	}, nil
+}
+
+// discover загружает документ discovery провайдера и проверяет, что issuer совпадает с настроенным
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	issuer := strings.TrimSuffix(p.cfg.Issuer, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var meta discovery
+	if err := p.do(req, &meta); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(meta.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("%w: discovery issuer %q does not match %q", ErrProviderUnavailable, meta.Issuer, p.cfg.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: incomplete discovery document", ErrProviderUnavailable)
+	}
+	p.meta = &meta
+	return p.meta, nil
+}
+
+// key возвращает ключ проверки id_token. Незнакомый kid означает ротацию ключей
+// у провайдера, поэтому набор перечитывается, но не чаще jwksRefreshInterval.
+func (p *Provider) key(ctx context.Context, meta *discovery, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if p.keys != nil && time.Since(p.keysFetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, meta.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set signing.JWKSet
+	if err := p.do(req, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+// do выполняет запрос к провайдеру и разбирает JSON-ответ
+func (p *Provider) do(req *http.Request, out interface{}) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s returned %d", ErrProviderUnavailable, req.URL.Path, resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	return nil
+}
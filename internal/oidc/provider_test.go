@@ -0,0 +1,219 @@
+package oidc
+
+import (
+	"awesomeProject/internal/config"
+	"awesomeProject/internal/signing"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testClientID = "client"
+
+// fakeProvider - локальный OIDC-провайдер: discovery, JWKS и token endpoint
+type fakeProvider struct {
+	server  *httptest.Server
+	ecKey   *ecdsa.PrivateKey
+	edKey   ed25519.PrivateKey
+	idToken func(issuer string) string
+	// tokenForm - форма последнего запроса к token endpoint
+	tokenForm url.Values
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	t.Helper()
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &fakeProvider{ecKey: ecKey, edKey: edKey}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.server.URL,
+			"authorization_endpoint": p.server.URL + "/authorize",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		coordinate := func(b []byte) string {
+			return base64.RawURLEncoding.EncodeToString(append(make([]byte, 48-len(b)), b...))
+		}
+		json.NewEncoder(w).Encode(signing.JWKSet{Keys: []signing.JWK{
+			{KeyType: "EC", KeyID: "ec", Use: "sig", Algorithm: "ES384", Curve: "P-384",
+				X: coordinate(ecKey.X.Bytes()), Y: coordinate(ecKey.Y.Bytes())},
+			{KeyType: "OKP", KeyID: "ed", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519",
+				X: base64.RawURLEncoding.EncodeToString(edKey.Public().(ed25519.PublicKey))},
+		}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		p.tokenForm = r.PostForm
+		json.NewEncoder(w).Encode(map[string]string{"id_token": p.idToken(p.server.URL)})
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func (p *fakeProvider) provider() *Provider {
+	cfg := config.OIDCProvider{Name: "test", Issuer: p.server.URL, ClientID: testClientID, ClientSecret: "secret", Scopes: []string{"openid", "email"}}
+	return NewProvider(cfg, "https://app.example/auth/oidc/test/callback", p.server.Client())
+}
+
+func (p *fakeProvider) sign(t *testing.T, method jwt.SigningMethod, kid string, claims jwt.Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	var key interface{} = p.ecKey
+	if method == jwt.SigningMethodEdDSA {
+		key = p.edKey
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestExchange(t *testing.T) {
+	validClaims := func(issuer string) idTokenClaims {
+		return idTokenClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    issuer,
+				Subject:   "42",
+				Audience:  jwt.ClaimStrings{testClientID},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+			Nonce:         "nonce",
+			Email:         "user@example.com",
+			EmailVerified: true,
+			Name:          "User",
+		}
+	}
+
+	tests := []struct {
+		name    string
+		method  jwt.SigningMethod
+		kid     string
+		claims  func(c *idTokenClaims)
+		wantErr error
+	}{
+		{name: "ES384", method: jwt.SigningMethodES384, kid: "ec"},
+		{name: "EdDSA", method: jwt.SigningMethodEdDSA, kid: "ed"},
+		{name: "nonce mismatch", method: jwt.SigningMethodES384, kid: "ec", claims: func(c *idTokenClaims) { c.Nonce = "other" }, wantErr: ErrInvalidIDToken},
+		{name: "nonce missing", method: jwt.SigningMethodES384, kid: "ec", claims: func(c *idTokenClaims) { c.Nonce = "" }, wantErr: ErrInvalidIDToken},
+		{name: "expired", method: jwt.SigningMethodES384, kid: "ec", claims: func(c *idTokenClaims) {
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+		}, wantErr: ErrInvalidIDToken},
+		{name: "no expiry", method: jwt.SigningMethodES384, kid: "ec", claims: func(c *idTokenClaims) { c.ExpiresAt = nil }, wantErr: ErrInvalidIDToken},
+		{name: "other issuer", method: jwt.SigningMethodES384, kid: "ec", claims: func(c *idTokenClaims) { c.Issuer = "https://evil.example" }, wantErr: ErrInvalidIDToken},
+		{name: "other audience", method: jwt.SigningMethodES384, kid: "ec", claims: func(c *idTokenClaims) { c.Audience = jwt.ClaimStrings{"other"} }, wantErr: ErrInvalidIDToken},
+		{name: "no subject", method: jwt.SigningMethodES384, kid: "ec", claims: func(c *idTokenClaims) { c.Subject = "" }, wantErr: ErrInvalidIDToken},
+		{name: "unknown key", method: jwt.SigningMethodES384, kid: "missing", wantErr: ErrInvalidIDToken},
+		{name: "key of another type", method: jwt.SigningMethodES384, kid: "ed", wantErr: ErrInvalidIDToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeProvider(t)
+			fake.idToken = func(issuer string) string {
+				claims := validClaims(issuer)
+				if tt.claims != nil {
+					tt.claims(&claims)
+				}
+				return fake.sign(t, tt.method, tt.kid, claims)
+			}
+
+			got, err := fake.provider().Exchange(context.Background(), "code", "verifier", "nonce")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if fake.tokenForm.Get("code_verifier") != "verifier" || fake.tokenForm.Get("code") != "code" {
+				t.Errorf("token request form = %v", fake.tokenForm)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			want := Identity{Provider: "test", Subject: "42", Email: "user@example.com", EmailVerified: true, Name: "User"}
+			if got != want {
+				t.Errorf("identity = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestExchangeWithoutIDToken(t *testing.T) {
+	fake := newFakeProvider(t)
+	fake.idToken = func(string) string { return "" }
+	if _, err := fake.provider().Exchange(context.Background(), "code", "verifier", "nonce"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidIDToken)
+	}
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	fake := newFakeProvider(t)
+	state, err := NewState("test", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := fake.provider().AuthCodeURL(context.Background(), state.State, state.Nonce, state.CodeChallenge())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(raw, fake.server.URL+"/authorize?") {
+		t.Fatalf("url %q does not point to the authorization endpoint", raw)
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	want := map[string]string{
+		"client_id":             testClientID,
+		"state":                 state.State,
+		"nonce":                 state.Nonce,
+		"code_challenge":        state.CodeChallenge(),
+		"code_challenge_method": "S256",
+		"scope":                 "openid email",
+	}
+	for key, value := range want {
+		if query.Get(key) != value {
+			t.Errorf("%s = %q, want %q", key, query.Get(key), value)
+		}
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	// Документ discovery отдает issuer другого сервера
+	fake := newFakeProvider(t)
+	mux := http.NewServeMux()
+	mux.Handle("/.well-known/openid-configuration", http.RedirectHandler(fake.server.URL+"/.well-known/openid-configuration", http.StatusFound))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cfg := config.OIDCProvider{Name: "test", Issuer: server.URL, ClientID: testClientID}
+	provider := NewProvider(cfg, "https://app.example/callback", server.Client())
+	if _, err := provider.AuthCodeURL(context.Background(), "s", "n", "c"); !errors.Is(err, ErrProviderUnavailable) {
+		t.Fatalf("err = %v, want %v", err, ErrProviderUnavailable)
+	}
+}
@@ -0,0 +1,50 @@
+package oidc
+
+import (
+	"awesomeProject/internal/config"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Registry - настроенные провайдеры по имени
+type Registry struct {
+	providers map[string]*Provider
+}
+
+// NewRegistry создает провайдеров из настроек. Адрес возврата строится от baseURL:
+// <baseURL>/auth/oidc/<имя>/callback.
+func NewRegistry(providers []config.OIDCProvider, baseURL string, client *http.Client) *Registry {
+	r := &Registry{providers: make(map[string]*Provider, len(providers))}
+	for _, cfg := range providers {
+		if cfg.Issuer == "" || cfg.ClientID == "" {
+			log.Printf("OIDC provider %s is missing issuer or client id, skipping", cfg.Name)
+			continue
+		}
+		redirectURL := strings.TrimSuffix(baseURL, "/") + "/auth/oidc/" + cfg.Name + "/callback"
+		r.providers[cfg.Name] = NewProvider(cfg, redirectURL, client)
+	}
+	return r
+}
+
+var (
+	registry     *Registry
+	registryOnce sync.Once
+)
+
+// GetRegistry возвращает провайдеров из настроек приложения
+func GetRegistry() *Registry {
+	registryOnce.Do(func() {
+		cfg := config.GetConfig()
+		registry = NewRegistry(cfg.OIDC.Providers, cfg.Verification.BaseURL, &http.Client{Timeout: 10 * time.Second})
+	})
+	return registry
+}
+
+// Provider возвращает провайдера по имени
+func (r *Registry) Provider(name string) (*Provider, bool) {
+	p, ok := r.providers[name]
+	return p, ok
+}
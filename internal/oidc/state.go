@@ -0,0 +1,90 @@
+package oidc
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrInvalidState = errors.New("invalid or expired login state")
+
+// State - данные незавершенного входа. Хранятся у браузера в подписанной cookie,
+// поэтому серверу не нужно их запоминать.
+type State struct {
+	Provider     string `json:"p"`
+	State        string `json:"s"`
+	Nonce        string `json:"n"`
+	CodeVerifier string `json:"v"`
+	ExpiresAt    int64  `json:"x"`
+}
+
+// NewState создает случайные state, nonce и PKCE code_verifier для входа через provider
+func NewState(provider string, ttl time.Duration) (State, error) {
+	values := make([]string, 3)
+	for i := range values {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return State{}, err
+		}
+		values[i] = base64.RawURLEncoding.EncodeToString(b)
+	}
+	return State{
+		Provider:     provider,
+		State:        values[0],
+		Nonce:        values[1],
+		CodeVerifier: values[2],
+		ExpiresAt:    time.Now().Add(ttl).Unix(),
+	}, nil
+}
+
+// CodeChallenge возвращает PKCE code_challenge по методу S256
+func (s State) CodeChallenge() string {
+	sum := sha256.Sum256([]byte(s.CodeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Encode подписывает состояние для cookie
+func (s State) Encode(secret string) (string, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(stateSignature(secret, encoded)), nil
+}
+
+// DecodeState проверяет подпись и срок cookie и сверяет state из ответа провайдера
+func DecodeState(secret, value, provider, state string, now time.Time) (State, error) {
+	var s State
+	encoded, signature, ok := strings.Cut(value, ".")
+	if !ok {
+		return s, ErrInvalidState
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, stateSignature(secret, encoded)) {
+		return s, ErrInvalidState
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return s, ErrInvalidState
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, ErrInvalidState
+	}
+	if s.Provider != provider || now.Unix() >= s.ExpiresAt ||
+		!hmac.Equal([]byte(s.State), []byte(state)) {
+		return s, ErrInvalidState
+	}
+	return s, nil
+}
+
+func stateSignature(secret, data string) []byte {
+	mac := hmac.New(sha256.New, []byte("oidc-state:"+secret))
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,51 @@
+package oidc
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDecodeState(t *testing.T) {
+	const secret = "state-secret"
+	state, err := NewState("google", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := state.Encode(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		secret   string
+		value    string
+		provider string
+		state    string
+		now      time.Time
+		wantErr  error
+	}{
+		{name: "valid", secret: secret, value: encoded, provider: "google", state: state.State, now: now},
+		{name: "state mismatch", secret: secret, value: encoded, provider: "google", state: "other", now: now, wantErr: ErrInvalidState},
+		{name: "other provider", secret: secret, value: encoded, provider: "github", state: state.State, now: now, wantErr: ErrInvalidState},
+		{name: "expired", secret: secret, value: encoded, provider: "google", state: state.State, now: now.Add(2 * time.Minute), wantErr: ErrInvalidState},
+		{name: "other secret", secret: "verification-secret", value: encoded, provider: "google", state: state.State, now: now, wantErr: ErrInvalidState},
+		{name: "tampered payload", secret: secret, value: "x" + encoded, provider: "google", state: state.State, now: now, wantErr: ErrInvalidState},
+		{name: "no signature", secret: secret, value: "payload", provider: "google", state: state.State, now: now, wantErr: ErrInvalidState},
+		{name: "no cookie", secret: secret, value: "", provider: "google", state: state.State, now: now, wantErr: ErrInvalidState},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeState(tt.secret, tt.value, tt.provider, tt.state, tt.now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && got != state {
+				t.Errorf("state = %+v, want %+v", got, state)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var (
+	ErrInvalidLimit  = fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+	ErrInvalidOffset = errors.New("offset must be a non-negative integer")
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrMixedModes    = errors.New("offset and after cannot be used together")
+	ErrInvalidSort   = errors.New("invalid sort field")
+	// ErrCursorMismatch - курсор получен при другой сортировке, чем в текущем запросе
+	ErrCursorMismatch = fmt.Errorf("%w: it was issued for a different sort order", ErrInvalidCursor)
+)
+
+// Keys - query-параметры, которые разбирает Parse. Нужны парсерам фильтров,
+// чтобы не считать их неизвестными полями.
+var Keys = []string{"limit", "offset", "after"}
+
+// IsKey сообщает, является ли key параметром пагинации
+func IsKey(key string) bool {
+	for _, k := range Keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Params описывает запрошенную страницу. Используется либо смещение (Offset),
+// либо курсор (After) - позиция последней записи предыдущей страницы.
+type Params struct {
+	Limit  int
+	Offset int
+	After  *Cursor
+}
+
+// Cursor - непрозрачная для клиента позиция в выборке: ID записи,
+// значение колонки сортировки, если сортировка не по ID, и сама сортировка (Order.Key).
+// Курсор действителен только при той сортировке, для которой он выдан.
+type Cursor struct {
+	ID    uint   `json:"id"`
+	Value string `json:"v,omitempty"`
+	Sort  string `json:"s"`
+}
+
+// Order задает колонку сортировки. ID всегда добавляется вторым ключом,
+// чтобы порядок был однозначным. Пустая колонка означает сортировку только по ID.
+// Cast - тип Postgres, к которому приводится значение курсора (например, timestamptz),
+// так как в курсоре оно хранится строкой.
+type Order struct {
+	Column string
+	Cast   string
+	Desc   bool
+}
+
+// Key возвращает обозначение сортировки в виде параметра sort: "column" или "-column"
+func (o Order) Key() string {
+	key := o.Column
+	if key == "" {
+		key = "id"
+	}
+	if o.Desc {
+		key = "-" + key
+	}
+	return key
+}
+
+// Page - конверт ответа для списочных эндпоинтов
+type Page[T any] struct {
+	Data       []T    `json:"data"`
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Parse читает limit, offset и after из query-параметров
+func Parse(query url.Values) (Params, error) {
+	p := Params{Limit: DefaultLimit}
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return Params{}, ErrInvalidLimit
+		}
+		p.Limit = limit
+	}
+
+	if raw := query.Get("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return Params{}, ErrInvalidOffset
+		}
+		p.Offset = offset
+	}
+
+	if raw := query.Get("after"); raw != "" {
+		if query.Get("offset") != "" {
+			return Params{}, ErrMixedModes
+		}
+		cursor, err := DecodeCursor(raw)
+		if err != nil {
+			return Params{}, err
+		}
+		p.After = &cursor
+	}
+
+	return p, nil
+}
+
+// ParseSort разбирает параметр sort вида "field" или "-field" (по убыванию).
+// Допустимые поля и соответствующие им колонки задаются в allowed; пустое значение дает def.
+func ParseSort(raw string, allowed map[string]Order, def Order) (Order, error) {
+	if raw == "" {
+		return def, nil
+	}
+	desc := false
+	if raw[0] == '-' {
+		desc = true
+		raw = raw[1:]
+	}
+	order, ok := allowed[raw]
+	if !ok {
+		return Order{}, fmt.Errorf("%w: %s", ErrInvalidSort, raw)
+	}
+	order.Desc = desc
+	return order, nil
+}
+
+// EncodeCursor кодирует курсор в строку для next_cursor
+func EncodeCursor(c Cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor разбирает строку, полученную из EncodeCursor
+func DecodeCursor(s string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Scope возвращает GORM scope, который сортирует выборку, применяет курсор или смещение
+// и ограничивает её Limit+1 строками. Лишняя строка показывает, что есть следующая страница.
+// Колонка в order должна приходить из белого списка, а не от клиента напрямую.
+// Курсор, выданный для другой сортировки, дает ошибку ErrCursorMismatch.
+func (p Params) Scope(order Order) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		direction, comparison := "ASC", ">"
+		if order.Desc {
+			direction, comparison = "DESC", "<"
+		}
+
+		if p.After != nil {
+			if p.After.Sort != order.Key() {
+				db.AddError(ErrCursorMismatch)
+				return db
+			}
+			if order.Column == "" || order.Column == "id" {
+				db = db.Where(fmt.Sprintf("id %s ?", comparison), p.After.ID)
+			} else {
+				value := "?"
+				if order.Cast != "" {
+					value = "CAST(? AS " + order.Cast + ")"
+				}
+				db = db.Where(fmt.Sprintf("(%s, id) %s (%s, ?)", order.Column, comparison, value), p.After.Value, p.After.ID)
+			}
+		} else if p.Offset > 0 {
+			db = db.Offset(p.Offset)
+		}
+
+		if order.Column != "" && order.Column != "id" {
+			db = db.Order(fmt.Sprintf("%s %s", order.Column, direction))
+		}
+		return db.Order(fmt.Sprintf("id %s", direction)).Limit(p.Limit + 1)
+	}
+}
+
+// NewPage собирает страницу из результата запроса с Scope: отбрасывает лишнюю строку
+// и, если она была, вычисляет курсор следующей страницы по последней записи
+func NewPage[T any](items []T, total int64, p Params, cursorOf func(T) Cursor) Page[T] {
+	page := Page[T]{
+		Data:   items,
+		Total:  total,
+		Limit:  p.Limit,
+		Offset: p.Offset,
+	}
+	if len(items) > p.Limit {
+		page.Data = items[:p.Limit]
+		page.NextCursor = EncodeCursor(cursorOf(page.Data[p.Limit-1]))
+	}
+	if page.Data == nil {
+		page.Data = []T{}
+	}
+	return page
+}
@@ -0,0 +1,194 @@
+package pagination
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type row struct {
+	ID    uint
+	Title string
+}
+
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=test sslmode=disable"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	return db
+}
+
+func TestParse(t *testing.T) {
+	cursor := EncodeCursor(Cursor{ID: 7, Sort: "id"})
+	tests := []struct {
+		name    string
+		query   string
+		want    Params
+		wantErr error
+	}{
+		{name: "defaults", query: "", want: Params{Limit: DefaultLimit}},
+		{name: "limit and offset", query: "limit=5&offset=10", want: Params{Limit: 5, Offset: 10}},
+		{name: "cursor", query: "after=" + cursor, want: Params{Limit: DefaultLimit, After: &Cursor{ID: 7, Sort: "id"}}},
+		{name: "zero limit", query: "limit=0", wantErr: ErrInvalidLimit},
+		{name: "limit above max", query: "limit=101", wantErr: ErrInvalidLimit},
+		{name: "negative offset", query: "offset=-1", wantErr: ErrInvalidOffset},
+		{name: "offset with cursor", query: "offset=1&after=" + cursor, wantErr: ErrMixedModes},
+		{name: "garbage cursor", query: "after=!!!", wantErr: ErrInvalidCursor},
+		{name: "cursor without id", query: "after=" + EncodeCursor(Cursor{}), wantErr: ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Parse(query)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got.Limit != tt.want.Limit || got.Offset != tt.want.Offset {
+				t.Errorf("got limit %d offset %d, want %d %d", got.Limit, got.Offset, tt.want.Limit, tt.want.Offset)
+			}
+			if (got.After == nil) != (tt.want.After == nil) || got.After != nil && *got.After != *tt.want.After {
+				t.Errorf("after = %+v, want %+v", got.After, tt.want.After)
+			}
+		})
+	}
+}
+
+func TestOrderKey(t *testing.T) {
+	tests := []struct {
+		order Order
+		want  string
+	}{
+		{Order{}, "id"},
+		{Order{Desc: true}, "-id"},
+		{Order{Column: "title"}, "title"},
+		{Order{Column: "created_at", Cast: "timestamptz", Desc: true}, "-created_at"},
+	}
+	for _, tt := range tests {
+		if got := tt.order.Key(); got != tt.want {
+			t.Errorf("%+v.Key() = %q, want %q", tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestScopeCursor(t *testing.T) {
+	byTitle := Order{Column: "title"}
+	byCreated := Order{Column: "created_at", Cast: "timestamptz", Desc: true}
+	tests := []struct {
+		name    string
+		order   Order
+		after   *Cursor
+		wantSQL string
+		wantErr error
+	}{
+		{
+			name:    "first page",
+			order:   byTitle,
+			wantSQL: "ORDER BY title ASC,id ASC LIMIT $1",
+		},
+		{
+			name:    "cursor by id",
+			order:   Order{},
+			after:   &Cursor{ID: 3, Sort: "id"},
+			wantSQL: "WHERE id > $1 ORDER BY id ASC",
+		},
+		{
+			name:    "cursor by column",
+			order:   byTitle,
+			after:   &Cursor{ID: 3, Value: "b", Sort: "title"},
+			wantSQL: "WHERE (title, id) > ($1, $2) ORDER BY title ASC,id ASC",
+		},
+		{
+			name:    "cursor with cast",
+			order:   byCreated,
+			after:   &Cursor{ID: 3, Value: "2024-01-01T00:00:00Z", Sort: "-created_at"},
+			wantSQL: "WHERE (created_at, id) < (CAST($1 AS timestamptz), $2)",
+		},
+		{
+			name:    "cursor from another sort",
+			order:   byCreated,
+			after:   &Cursor{ID: 3, Value: "b", Sort: "title"},
+			wantErr: ErrCursorMismatch,
+		},
+		{
+			name:    "cursor from another direction",
+			order:   Order{Desc: true},
+			after:   &Cursor{ID: 3, Sort: "id"},
+			wantErr: ErrCursorMismatch,
+		},
+		{
+			name:    "cursor without sort",
+			order:   Order{},
+			after:   &Cursor{ID: 3},
+			wantErr: ErrInvalidCursor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := Params{Limit: 10, After: tt.after}
+			var rows []row
+			tx := dryRunDB(t).Scopes(params.Scope(tt.order)).Find(&rows)
+			if !errors.Is(tx.Error, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", tx.Error, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if sql := tx.Statement.SQL.String(); !strings.Contains(sql, tt.wantSQL) {
+				t.Errorf("sql = %q, want it to contain %q", sql, tt.wantSQL)
+			}
+		})
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	cursorOf := func(r row) Cursor { return Cursor{ID: r.ID, Value: r.Title, Sort: "title"} }
+	tests := []struct {
+		name       string
+		items      []row
+		wantLen    int
+		wantCursor *Cursor
+	}{
+		{name: "empty", items: nil, wantLen: 0},
+		{name: "last page", items: []row{{1, "a"}, {2, "b"}}, wantLen: 2},
+		{name: "has next page", items: []row{{1, "a"}, {2, "b"}, {3, "c"}}, wantLen: 2, wantCursor: &Cursor{ID: 2, Value: "b", Sort: "title"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := NewPage(tt.items, int64(len(tt.items)), Params{Limit: 2}, cursorOf)
+			if page.Data == nil || len(page.Data) != tt.wantLen {
+				t.Fatalf("data = %v, want %d items", page.Data, tt.wantLen)
+			}
+			if tt.wantCursor == nil {
+				if page.NextCursor != "" {
+					t.Errorf("next cursor = %q, want none", page.NextCursor)
+				}
+				return
+			}
+			got, err := DecodeCursor(page.NextCursor)
+			if err != nil {
+				t.Fatalf("decode next cursor: %v", err)
+			}
+			if got != *tt.wantCursor {
+				t.Errorf("next cursor = %+v, want %+v", got, *tt.wantCursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+// Package signing подписывает и проверяет JWT асимметричными ключами (RS256 или EdDSA).
+// Токены подписываются одним ключом, а проверяются любым из набора: при ротации новый
+// ключ становится подписывающим, а старый остается в наборе, пока не истекут его токены.
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// minRSAKeyBits - минимальная длина RSA-ключа
+const minRSAKeyBits = 2048
+
+// Key - ключ подписи. У ключей только для проверки Private пустой.
+type Key struct {
+	ID        string
+	Algorithm string
+	Private   crypto.Signer
+	Public    crypto.PublicKey
+}
+
+// newKey определяет алгоритм по типу ключа и вычисляет kid как отпечаток RFC 7638
+func newKey(private crypto.Signer, public crypto.PublicKey) (*Key, error) {
+	key := &Key{Private: private, Public: public}
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("rsa key is too short: %d bits, need at least %d", pub.N.BitLen(), minRSAKeyBits)
+		}
+		key.Algorithm = AlgorithmRS256
+	case ed25519.PublicKey:
+		key.Algorithm = AlgorithmEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, use RSA or Ed25519", public)
+	}
+
+	thumbprint, err := key.thumbprint()
+	if err != nil {
+		return nil, err
+	}
+	key.ID = thumbprint
+	return key, nil
+}
+
+// LoadKeyFile читает PEM-файл с приватным (PKCS#8 или PKCS#1) или публичным (PKIX) ключом
+func LoadKeyFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", path)
+	}
+
+	var key *Key
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("%s: unsupported private key type %T", path, parsed)
+		}
+		key, err = newKey(signer, signer.Public())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		key, err = newKey(parsed, &parsed.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		key, err = newKey(nil, parsed)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+	}
+	return key, nil
+}
+
+// GenerateEd25519 создает временный ключ Ed25519
+func GenerateEd25519() (*Key, error) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return nil, err
+	}
+	return newKey(private, public)
+}
+
+// JWK - публичный ключ в формате RFC 7517
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JWK возвращает публичную часть ключа
+func (k *Key) JWK() JWK {
+	jwk := JWK{KeyID: k.ID, Use: "sig", Algorithm: k.Algorithm}
+	switch pub := k.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
+
+// PublicKey восстанавливает публичный ключ из JWK: RSA, EC (P-256, P-384) или Ed25519
+func (j JWK) PublicKey() (crypto.PublicKey, error) {
+	switch j.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: invalid n: %w", j.KeyID, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("jwk %s: invalid e", j.KeyID)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("jwk %s: unsupported curve %q", j.KeyID, j.Curve)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(j.X)
+		y, errY := base64.RawURLEncoding.DecodeString(j.Y)
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("jwk %s: invalid coordinates", j.KeyID)
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("jwk %s: point is not on curve", j.KeyID)
+		}
+		return key, nil
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if j.Curve != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("jwk %s: invalid Ed25519 key", j.KeyID)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("jwk %s: unsupported key type %q", j.KeyID, j.KeyType)
+	}
+}
+
+// thumbprint вычисляет отпечаток ключа по RFC 7638: SHA-256 от JSON с обязательными
+// полями JWK в лексикографическом порядке
+func (k *Key) thumbprint() (string, error) {
+	jwk := k.JWK()
+	var canonical []byte
+	var err error
+	switch jwk.KeyType {
+	case "RSA":
+		canonical, err = json.Marshal(struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.KeyType, jwk.N})
+	case "OKP":
+		canonical, err = json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Curve, jwk.KeyType, jwk.X})
+	default:
+		return "", errors.New("unsupported key type")
+	}
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,137 @@
+package signing
+
+import (
+	"awesomeProject/internal/config"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrUnknownKey   = errors.New("token signed with unknown key")
+	ErrKeyMismatch  = errors.New("token algorithm does not match its key")
+	ErrNoSigningKey = errors.New("JWT_SIGNING_KEY_FILE is not set; set JWT_ALLOW_EPHEMERAL_KEY=true to use a temporary key in development")
+)
+
+// KeySet - подписывающий ключ и все ключи, которыми принимаются токены
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+	order   []string
+}
+
+// NewKeySet собирает набор ключей. Подписывающий ключ всегда входит в набор проверки.
+func NewKeySet(signingKey *Key, verificationKeys ...*Key) (*KeySet, error) {
+	if signingKey == nil || signingKey.Private == nil {
+		return nil, errors.New("signing key must contain a private key")
+	}
+	ks := &KeySet{signing: signingKey, keys: make(map[string]*Key)}
+	for _, key := range append([]*Key{signingKey}, verificationKeys...) {
+		if _, ok := ks.keys[key.ID]; ok {
+			continue
+		}
+		ks.keys[key.ID] = key
+		ks.order = append(ks.order, key.ID)
+	}
+	return ks, nil
+}
+
+var (
+	instance *KeySet
+	once     sync.Once
+)
+
+// GetKeySet возвращает набор ключей из настроек JWT_SIGNING_KEY_FILE и JWT_VERIFICATION_KEY_FILES.
+// Без файла подписи приложение не запускается. Временный ключ Ed25519 создается только
+// при JWT_ALLOW_EPHEMERAL_KEY: это годится для разработки, так как после перезапуска
+// выданные токены перестанут приниматься.
+func GetKeySet() *KeySet {
+	once.Do(func() {
+		ks, err := loadKeySet(config.GetConfig())
+		if err != nil {
+			log.Fatalf("Failed to load JWT signing keys: %v", err)
+		}
+		instance = ks
+	})
+	return instance
+}
+
+func loadKeySet(cfg *config.Config) (*KeySet, error) {
+	var signingKey *Key
+	var err error
+	if cfg.JWT.SigningKeyFile == "" {
+		if !cfg.JWT.AllowEphemeralKey {
+			return nil, ErrNoSigningKey
+		}
+		log.Printf("JWT_SIGNING_KEY_FILE is not set, using an ephemeral Ed25519 key; tokens will not survive a restart")
+		signingKey, err = GenerateEd25519()
+	} else {
+		signingKey, err = LoadKeyFile(cfg.JWT.SigningKeyFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	verificationKeys := make([]*Key, 0, len(cfg.JWT.VerificationKeyFiles))
+	for _, path := range cfg.JWT.VerificationKeyFiles {
+		key, err := LoadKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		verificationKeys = append(verificationKeys, key)
+	}
+
+	ks, err := NewKeySet(signingKey, verificationKeys...)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("JWT signing key %s (%s), %d verification keys", signingKey.ID, signingKey.Algorithm, len(ks.keys))
+	return ks, nil
+}
+
+// Sign подписывает claims текущим ключом и указывает его kid в заголовке
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	method := jwt.GetSigningMethod(ks.signing.Algorithm)
+	if method == nil {
+		return "", fmt.Errorf("unsupported signing algorithm %s", ks.signing.Algorithm)
+	}
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = ks.signing.ID
+	return token.SignedString(ks.signing.Private)
+}
+
+// Parse проверяет подпись и стандартные claims токена
+func (ks *KeySet) Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, ks.Keyfunc,
+		jwt.WithValidMethods([]string{AlgorithmRS256, AlgorithmEdDSA}))
+}
+
+// Keyfunc выбирает ключ проверки по kid из заголовка токена
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, ErrKeyMismatch
+	}
+	return key.Public, nil
+}
+
+// JWKSet - документ /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS возвращает публичные ключи проверки, подписывающий ключ первым
+func (ks *KeySet) JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(ks.order))}
+	for _, kid := range ks.order {
+		set.Keys = append(set.Keys, ks.keys[kid].JWK())
+	}
+	return set
+}
@@ -0,0 +1,206 @@
+package signing
+
+import (
+	"awesomeProject/internal/config"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writePEM сохраняет блок PEM во временный файл и возвращает путь
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func ed25519File(t *testing.T) (string, ed25519.PublicKey) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, "PRIVATE KEY", der), public
+}
+
+func rsaFile(t *testing.T, bits int) string {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(private))
+}
+
+func TestLoadKeySet(t *testing.T) {
+	edPath, edPublic := ed25519File(t)
+	publicDER, err := x509.MarshalPKIXPublicKey(edPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPath := writePEM(t, "PUBLIC KEY", publicDER)
+	rsaPath := rsaFile(t, 2048)
+	shortRSAPath := rsaFile(t, 1024)
+
+	tests := []struct {
+		name         string
+		signingFile  string
+		verification []string
+		ephemeral    bool
+		wantAlg      string
+		wantKeys     int
+		wantErr      error
+		wantAnyErr   bool
+	}{
+		{name: "no key file", wantErr: ErrNoSigningKey},
+		{name: "ephemeral key in development", ephemeral: true, wantAlg: AlgorithmEdDSA, wantKeys: 1},
+		{name: "ed25519 pkcs8", signingFile: edPath, wantAlg: AlgorithmEdDSA, wantKeys: 1},
+		{name: "rsa pkcs1", signingFile: rsaPath, wantAlg: AlgorithmRS256, wantKeys: 1},
+		{name: "rotation keeps the old key", signingFile: rsaPath, verification: []string{publicPath}, wantAlg: AlgorithmRS256, wantKeys: 2},
+		{name: "signing key listed twice", signingFile: edPath, verification: []string{publicPath}, wantAlg: AlgorithmEdDSA, wantKeys: 1},
+		{name: "public key cannot sign", signingFile: publicPath, wantAnyErr: true},
+		{name: "short rsa key", signingFile: shortRSAPath, wantAnyErr: true},
+		{name: "missing file", signingFile: filepath.Join(t.TempDir(), "missing.pem"), wantAnyErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.JWT.SigningKeyFile = tt.signingFile
+			cfg.JWT.VerificationKeyFiles = tt.verification
+			cfg.JWT.AllowEphemeralKey = tt.ephemeral
+
+			ks, err := loadKeySet(cfg)
+			if tt.wantErr != nil || tt.wantAnyErr {
+				if err == nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadKeySet: %v", err)
+			}
+			if ks.signing.Algorithm != tt.wantAlg {
+				t.Errorf("algorithm = %s, want %s", ks.signing.Algorithm, tt.wantAlg)
+			}
+			if len(ks.JWKS().Keys) != tt.wantKeys {
+				t.Errorf("jwks has %d keys, want %d", len(ks.JWKS().Keys), tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestSignAndParse(t *testing.T) {
+	oldKey, err := GenerateEd25519()
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := GenerateEd25519()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldSet, _ := NewKeySet(oldKey)
+	rotated, _ := NewKeySet(newKey, oldKey)
+	newOnly, _ := NewKeySet(newKey)
+
+	claims := func(expiresIn time.Duration) jwt.RegisteredClaims {
+		return jwt.RegisteredClaims{Subject: "1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn))}
+	}
+	sign := func(ks *KeySet, c jwt.RegisteredClaims) string {
+		token, err := ks.Sign(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims(time.Minute)).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		parser  *KeySet
+		wantErr error
+	}{
+		{name: "same key", token: sign(oldSet, claims(time.Minute)), parser: oldSet},
+		{name: "old token after rotation", token: sign(oldSet, claims(time.Minute)), parser: rotated},
+		{name: "new token after rotation", token: sign(rotated, claims(time.Minute)), parser: rotated},
+		{name: "old key removed", token: sign(oldSet, claims(time.Minute)), parser: newOnly, wantErr: ErrUnknownKey},
+		{name: "expired", token: sign(oldSet, claims(-time.Minute)), parser: oldSet, wantErr: jwt.ErrTokenExpired},
+		{name: "alg none", token: unsigned, parser: oldSet, wantErr: jwt.ErrTokenSignatureInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got jwt.RegisteredClaims
+			token, err := tt.parser.Parse(tt.token, &got)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (!token.Valid || got.Subject != "1") {
+				t.Errorf("token valid=%v subject=%q", token.Valid, got.Subject)
+			}
+		})
+	}
+}
+
+// TestThumbprint проверяет kid на примерах RFC 7638 (RSA) и RFC 8037 (Ed25519)
+func TestThumbprint(t *testing.T) {
+	tests := []struct {
+		name string
+		jwk  JWK
+		want string
+	}{
+		{
+			name: "rfc 7638 rsa",
+			jwk: JWK{
+				KeyType: "RSA",
+				N:       "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+				E:       "AQAB",
+			},
+			want: "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs",
+		},
+		{
+			name: "rfc 8037 ed25519",
+			jwk:  JWK{KeyType: "OKP", Curve: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+			want: "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			public, err := tt.jwk.PublicKey()
+			if err != nil {
+				t.Fatalf("PublicKey: %v", err)
+			}
+			key, err := newKey(nil, public)
+			if err != nil {
+				t.Fatalf("newKey: %v", err)
+			}
+			if key.ID != tt.want {
+				t.Errorf("kid = %s, want %s", key.ID, tt.want)
+			}
+			if jwk := key.JWK(); jwk.N != tt.jwk.N || jwk.E != tt.jwk.E || jwk.X != tt.jwk.X {
+				t.Errorf("jwk round trip changed the key: %+v", jwk)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Package totp реализует одноразовые пароли по времени (RFC 6238) поверх HOTP (RFC 4226)
+// с параметрами, которые понимают все приложения-аутентификаторы: SHA-1, 6 цифр, шаг 30 секунд.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Digits - длина кода
+	Digits = 6
+	// Period - шаг времени в секундах
+	Period = 30
+	// Skew - сколько соседних шагов принимается для компенсации расхождения часов
+	Skew = 1
+	// secretSize - длина секрета в байтах (160 бит, как рекомендует RFC 4226)
+	secretSize = 20
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret создает случайный секрет в base32 без выравнивания
+func GenerateSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(b), nil
+}
+
+// Counter возвращает номер шага времени для момента t
+func Counter(t time.Time) int64 {
+	return t.Unix() / Period
+}
+
+// Code вычисляет код для шага counter
+func Code(secret string, counter int64) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid totp secret: %w", err)
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Динамическое усечение (RFC 4226, раздел 5.3)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", Digits, value%mod), nil
+}
+
+// Validate проверяет код на момент t с допуском Skew шагов в обе стороны.
+// Возвращает шаг, которому соответствует код, чтобы вызывающий мог запретить повторное
+// использование кода: принимать стоит только шаги больше последнего использованного.
+func Validate(secret, code string, t time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != Digits {
+		return 0, false
+	}
+	current := Counter(t)
+	for counter := current - Skew; counter <= current+Skew; counter++ {
+		expected, err := Code(secret, counter)
+		if err != nil {
+			return 0, false
+		}
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+// ProvisioningURI возвращает otpauth:// URI для QR-кода приложения-аутентификатора
+func ProvisioningURI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(Period))
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
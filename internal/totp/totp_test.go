@@ -0,0 +1,129 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret - ключ SHA-1 из приложения B RFC 6238 ("12345678901234567890") в base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestCodeRFC6238 проверяет тестовые векторы RFC 6238 для SHA-1. В RFC коды
+// восьмизначные, наши - последние Digits цифр того же значения.
+func TestCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		rfc  string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tt := range tests {
+		counter := Counter(time.Unix(tt.unix, 0))
+		got, err := Code(rfcSecret, counter)
+		if err != nil {
+			t.Fatalf("Code(%d): %v", tt.unix, err)
+		}
+		if want := tt.rfc[len(tt.rfc)-Digits:]; got != want {
+			t.Errorf("T=%d: code %s, want %s", tt.unix, got, want)
+		}
+	}
+}
+
+func TestCodeAcceptsLowercaseSecret(t *testing.T) {
+	upper, err := Code(rfcSecret, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lower, err := Code(strings.ToLower(rfcSecret), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upper != lower {
+		t.Errorf("lowercase secret gives %s, want %s", lower, upper)
+	}
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("invalid secret must be rejected")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code := func(offset int64) string {
+		c, err := Code(rfcSecret, Counter(now)+offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name        string
+		code        string
+		wantOK      bool
+		wantCounter int64
+	}{
+		{name: "current step", code: code(0), wantOK: true, wantCounter: Counter(now)},
+		{name: "previous step", code: code(-1), wantOK: true, wantCounter: Counter(now) - 1},
+		{name: "next step", code: code(1), wantOK: true, wantCounter: Counter(now) + 1},
+		{name: "surrounding spaces", code: " " + code(0) + " ", wantOK: true, wantCounter: Counter(now)},
+		{name: "two steps ago", code: code(-2)},
+		{name: "two steps ahead", code: code(2)},
+		{name: "too short", code: code(0)[1:]},
+		{name: "empty", code: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter, ok := Validate(rfcSecret, tt.code, now)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && counter != tt.wantCounter {
+				t.Errorf("counter = %d, want %d", counter, tt.wantCounter)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Error("secrets must be random")
+	}
+	key, err := encoding.DecodeString(a)
+	if err != nil || len(key) != secretSize {
+		t.Errorf("secret %q decodes to %d bytes (%v), want %d", a, len(key), err, secretSize)
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	raw := ProvisioningURI("Awesome Project", "ann@example.com", rfcSecret)
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Awesome Project:ann@example.com" {
+		t.Errorf("unexpected uri %s", raw)
+	}
+	query := u.Query()
+	for key, want := range map[string]string{"secret": rfcSecret, "issuer": "Awesome Project", "digits": "6", "period": "30", "algorithm": "SHA1"} {
+		if got := query.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}